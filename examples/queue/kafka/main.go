@@ -1,10 +1,14 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
+	"os/signal"
 
 	"github.com/Shopify/sarama"
+
+	"github.com/go-eagle/eagle/pkg/queue"
 	"github.com/go-eagle/eagle/pkg/queue/kafka"
 )
 
@@ -17,11 +21,37 @@ func main() {
 		brokers = []string{"localhost:9093"}
 		message = "Hello World Kafka!"
 	)
+	config.Version = sarama.V2_0_0_0
+	ctx := context.Background()
 
 	// kafka publish message
-	kafka.NewProducer(config, logger, topic, brokers).Publish(message)
+	producer, err := kafka.NewProducer(config, logger, brokers)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer producer.Close()
+
+	msg := queue.NewMessage([]byte(message)).WithKey("hello")
+	if err := producer.Publish(ctx, topic, msg); err != nil {
+		log.Fatal(err)
+	}
 
 	// kafka consume message
-	kafka.NewConsumer(config, logger, topic, groupID, brokers).Consume()
+	consumer, err := kafka.NewConsumer(config, logger, groupID, brokers)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer consumer.Close()
+
+	err = consumer.Subscribe(ctx, topic, func(ctx context.Context, msg *queue.Message) error {
+		log.Printf("Message topic:%q key:%q message: %s", msg.Topic, msg.Key, msg.Body)
+		return nil
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
 
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+	<-signals
 }
@@ -1,12 +1,11 @@
 package main
 
 import (
-	"encoding/base64"
+	"context"
 	"log"
-	"strings"
-	"sync"
 	"time"
 
+	"github.com/go-eagle/eagle/pkg/queue"
 	"github.com/go-eagle/eagle/pkg/queue/nats"
 )
 
@@ -16,48 +15,29 @@ func main() {
 		topic = "hello"
 	)
 	producer := nats.NewProducer(addr)
-	consumer := nats.NewConsumer(addr)
+	defer producer.Close()
+	consumer := nats.NewConsumer(addr, "")
+	defer consumer.Close()
 
-	published := make(chan struct{})
+	ctx := context.Background()
 	received := make(chan struct{})
-	wg := sync.WaitGroup{}
-	wg.Add(2)
-	go func() {
-		for {
-			select {
-			case <-published:
-				time.Sleep(3 * time.Second)
-				if err := producer.Publish(topic, []byte("hello nats")); err != nil {
-					log.Fatal(err)
-				}
-				log.Println("producer handler publish msg: ", "hello nats")
+	handler := func(ctx context.Context, msg *queue.Message) error {
+		log.Println("consumer handler receive msg: ", string(msg.Body))
+		close(received)
+		return nil
+	}
+	if err := consumer.Subscribe(ctx, topic, handler); err != nil {
+		log.Fatal(err)
+	}
 
-			case <-received:
-				wg.Done()
-				break
-			}
-		}
-	}()
-	go func() {
-		for {
-			// nolint: gosimple
-			select {
-			default:
-				handler := func(message []byte) error {
-					decodeMessage, _ := base64.StdEncoding.DecodeString(strings.Trim(string(message), "\""))
-					log.Println("consumer handler receive msg: ", string(decodeMessage))
-					received <- struct{}{}
-					wg.Done()
-					return nil
-				}
-				if err := consumer.Consume(topic, handler); err != nil {
-					log.Fatal(err)
-				}
-				time.Sleep(5 * time.Second)
-			}
-		}
-	}()
+	if err := producer.Publish(ctx, topic, queue.NewMessage([]byte("hello nats"))); err != nil {
+		log.Fatal(err)
+	}
+	log.Println("producer handler publish msg: ", "hello nats")
 
-	published <- struct{}{}
-	wg.Wait()
+	select {
+	case <-received:
+	case <-time.After(5 * time.Second):
+		log.Println("no message received")
+	}
 }
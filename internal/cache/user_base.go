@@ -78,6 +78,27 @@ func (c *Cache) GetUserBaseCache(ctx context.Context, userID uint64) (data *mode
 	return data, nil
 }
 
+// FetchUserBaseCache get user cache, on miss load it by loader and write it back,
+// cache.ErrPlaceholder is returned if the user does not exist
+func (c *Cache) FetchUserBaseCache(ctx context.Context, userID uint64,
+	loader func(ctx context.Context) (*model.UserBaseModel, error)) (data *model.UserBaseModel, err error) {
+	ctx, span := c.tracer.Start(ctx, "FetchUserBaseCache")
+	defer span.End()
+
+	cacheKey := c.GetUserBaseCacheKey(userID)
+	err = cache.Fetch(ctx, cacheKey, &data, func(ctx context.Context) (interface{}, error) {
+		user, err := loader(ctx)
+		if err != nil || user == nil || user.ID == 0 {
+			return nil, err
+		}
+		return user, nil
+	}, cache.WithFetchCache(c.cache), cache.WithJitter(time.Minute))
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
 // MultiGetUserBaseCache Get user caches in batches
 func (c *Cache) MultiGetUserBaseCache(ctx context.Context, userIDs []uint64) (map[string]*model.UserBaseModel, error) {
 	ctx, span := c.tracer.Start(ctx, "MultiGetUserBaseCache")
@@ -2,23 +2,18 @@ package repository
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/pkg/errors"
 	"github.com/spf13/cast"
 	"go.opentelemetry.io/otel/attribute"
 	oteltrace "go.opentelemetry.io/otel/trace"
-	"golang.org/x/sync/singleflight"
 	"gorm.io/gorm"
 
 	"github.com/go-eagle/eagle/internal/model"
 	"github.com/go-eagle/eagle/pkg/cache"
 	"github.com/go-eagle/eagle/pkg/log"
-	"github.com/go-eagle/eagle/pkg/redis"
 )
 
-var g singleflight.Group
-
 // CreateUser create user
 func (d *repository) CreateUser(ctx context.Context, user *model.UserBaseModel) (id uint64, err error) {
 	err = d.orm.Create(&user).Error
@@ -60,72 +55,32 @@ func (d *repository) GetUser(ctx context.Context, uid uint64) (userBase *model.U
 	))
 	defer span.End()
 
-	var data *model.UserBaseModel
-
-	userBase, err = d.userCache.GetUserBaseCache(ctx, uid)
+	userBase, err = d.userCache.FetchUserBaseCache(ctx, uid, func(ctx context.Context) (*model.UserBaseModel, error) {
+		data := new(model.UserBaseModel)
+		// 从数据库中获取
+		err := d.orm.WithContext(ctx).First(data, uid).Error
+		// if data is empty, a not found cache is set to prevent cache penetration(缓存穿透)
+		if errors.Is(err, ErrNotFound) {
+			return nil, nil
+		} else if err != nil {
+			span.SetName("find from db err")
+			span.RecordError(err)
+			//prom.BusinessErrCount.Incr("mysql: getOneUser")
+			return nil, errors.Wrapf(err, "[repo.user_base] query db err")
+		}
+		return data, nil
+	})
 	if errors.Is(err, cache.ErrPlaceholder) {
 		span.SetName("eq ErrPlaceholder")
 		span.RecordError(err)
 		return nil, ErrNotFound
-	} else if errors.Is(err, redis.ErrRedisNotFound) {
-		// use sync/singleflight mode to get data
-		// demo see: https://github.com/go-demo/singleflight-demo/blob/master/main.go
-		// https://juejin.cn/post/6844904084445593613
-		key := fmt.Sprintf("get_user_base_%d", uid)
-		val, err, _ := g.Do(key, func() (interface{}, error) {
-			data := new(model.UserBaseModel)
-			// 从数据库中获取
-			err = d.orm.WithContext(ctx).First(data, uid).Error
-			// if data is empty, set not found cache to prevent cache penetration(缓存穿透)
-			if errors.Is(err, ErrNotFound) {
-				err = d.userCache.SetCacheWithNotFound(ctx, uid)
-				if err != nil {
-					span.SetName("SetCacheWithNotFound")
-					span.RecordError(err)
-					log.Warnf("[repo.user_base] SetCacheWithNotFound err, uid: %d", uid)
-				}
-				return nil, ErrNotFound
-			} else if err != nil {
-				span.SetName("find from db err")
-				span.RecordError(err)
-				//prom.BusinessErrCount.Incr("mysql: getOneUser")
-				return nil, errors.Wrapf(err, "[repo.user_base] query db err")
-			}
-
-			// set cache
-			err = d.userCache.SetUserBaseCache(ctx, uid, data, cache.DefaultExpireTime)
-			if err != nil {
-				span.SetName("SetUserBaseCache")
-				span.RecordError(err)
-				return nil, errors.Wrap(err, "[repo.user_base] SetUserBaseCache err")
-			}
-			return data, nil
-		})
-
-		if err != nil && err != ErrNotFound {
-			span.SetName("sg.do")
-			span.RecordError(err)
-			return nil, errors.Wrap(err, "[repo.user_base] get user base err via single flight do")
-		}
-		if val != nil {
-			data = val.(*model.UserBaseModel)
-		}
 	} else if err != nil {
 		// fail fast, if cache error return, don't request to db
-		return nil, err
-	}
-
-	// cache hit
-	if userBase != nil {
-		//prom.CacheHit.Incr("getOneUser")
-		log.WithContext(ctx).Warnf("[repo.user_base] get user base data from cache, uid: %d", uid)
-		return
+		span.RecordError(err)
+		return nil, errors.Wrap(err, "[repo.user_base] fetch user base err")
 	}
 
-	// cache miss
-	//prom.CacheMiss.Incr("getOneUser")
-
-	return data, nil
+	return userBase, nil
 }
 
 // GetUsersByIds Get users in batches
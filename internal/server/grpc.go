@@ -1,20 +1,31 @@
 package server
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/go-eagle/eagle/pkg/app"
 	"github.com/go-eagle/eagle/pkg/transport/grpc"
+	"github.com/go-eagle/eagle/pkg/transport/tlsconfig"
 )
 
 // NewGRPCServer creates a gRPC server
 func NewGRPCServer(cfg *app.ServerConfig) *grpc.Server {
 
-	grpcServer := grpc.NewServer(
+	opts := []grpc.ServerOption{
 		grpc.Network("tcp"),
 		grpc.Address(":9090"),
-		grpc.Timeout(3*time.Second),
-	)
+		grpc.Timeout(3 * time.Second),
+	}
+	if cfg.EnableTLS() {
+		tlsConf, reloader, err := tlsconfig.NewServerConfig(cfg.CertFile, cfg.KeyFile, cfg.CAFile)
+		if err != nil {
+			panic(fmt.Sprintf("load grpc tls config err: %v", err))
+		}
+		// stop watching the cert files on shutdown
+		opts = append(opts, grpc.TLSConfig(tlsConf), grpc.Closer(reloader))
+	}
+	grpcServer := grpc.NewServer(opts...)
 
 	// register biz service
 	// v1.RegisterUserServiceServer(grpcServer, service.Svc.Users())
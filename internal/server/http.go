@@ -1,20 +1,32 @@
 package server
 
 import (
+	"fmt"
+
 	"github.com/go-eagle/eagle/internal/routers"
 	"github.com/go-eagle/eagle/pkg/app"
 	"github.com/go-eagle/eagle/pkg/transport/http"
+	"github.com/go-eagle/eagle/pkg/transport/tlsconfig"
 )
 
 // NewHTTPServer creates a HTTP server
 func NewHTTPServer(c *app.ServerConfig) *http.Server {
 	router := routers.NewRouter()
 
-	srv := http.NewServer(
+	opts := []http.ServerOption{
 		http.WithAddress(c.Addr),
 		http.WithReadTimeout(c.ReadTimeout),
 		http.WithWriteTimeout(c.WriteTimeout),
-	)
+	}
+	if c.EnableTLS() {
+		tlsConf, reloader, err := tlsconfig.NewServerConfig(c.CertFile, c.KeyFile, c.CAFile)
+		if err != nil {
+			panic(fmt.Sprintf("load http tls config err: %v", err))
+		}
+		// stop watching the cert files on shutdown
+		opts = append(opts, http.WithTLSConfig(tlsConf), http.WithCloser(reloader))
+	}
+	srv := http.NewServer(opts...)
 
 	srv.Handler = router
 	// NOTE: register svc to http server
@@ -0,0 +1,32 @@
+package server
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+
+	"github.com/go-eagle/eagle/pkg/outbox"
+	"github.com/go-eagle/eagle/pkg/queue"
+	// register queue drivers, the driver is chosen by config
+	_ "github.com/go-eagle/eagle/pkg/queue/kafka"
+	_ "github.com/go-eagle/eagle/pkg/queue/memory"
+	_ "github.com/go-eagle/eagle/pkg/queue/nats"
+	_ "github.com/go-eagle/eagle/pkg/queue/rabbitmq"
+)
+
+// ErrOutboxNotDurable the memory queue drops the messages without subscribers,
+// the relay marks them as sent, so they are lost.
+var ErrOutboxNotDurable = errors.New("outbox relay needs a durable queue driver, not memory")
+
+// NewOutboxRelay creates a relay which publishes the events in outbox table to queue
+func NewOutboxRelay(db *gorm.DB, c *queue.Config) (*outbox.Relay, error) {
+	if c.Driver == "memory" {
+		return nil, ErrOutboxNotDurable
+	}
+	producer, err := queue.NewProducer(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return outbox.NewRelay(db, producer), nil
+}
@@ -2,12 +2,16 @@ package service
 
 import (
 	"context"
+	"strconv"
+	"time"
 
 	"github.com/pkg/errors"
+	"gorm.io/gorm"
 
 	"github.com/go-eagle/eagle/internal/model"
 	"github.com/go-eagle/eagle/internal/repository"
 	"github.com/go-eagle/eagle/pkg/log"
+	"github.com/go-eagle/eagle/pkg/outbox"
 )
 
 const (
@@ -15,8 +19,20 @@ const (
 	FollowStatusNormal int = 1 //normal
 	// FollowStatusDelete Follow Status - Delete
 	FollowStatusDelete = 0 // delete
+
+	// TopicUserFollowed the event is published after a user follows another one
+	TopicUserFollowed = "user-followed"
+	// TopicUserUnfollowed the event is published after a user unfollows another one
+	TopicUserUnfollowed = "user-unfollowed"
 )
 
+// FollowEvent payload of follow and unfollow events
+type FollowEvent struct {
+	UserID      uint64    `json:"user_id"`
+	FollowedUID uint64    `json:"followed_uid"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
 // RelationService .
 type RelationService interface {
 	Follow(ctx context.Context, userID uint64, followedUID uint64) error
@@ -89,9 +105,17 @@ func (s *relationService) Follow(ctx context.Context, userID uint64, followedUID
 	// Add followers
 	err = s.repo.IncrFollowerCount(ctx, tx, followedUID, 1)
 	if err != nil {
+		tx.Rollback()
 		return errors.Wrap(err, "update user fans count err")
 	}
 
+	// publish event in the same tx
+	err = s.publishFollowEvent(ctx, tx, TopicUserFollowed, userID, followedUID)
+	if err != nil {
+		tx.Rollback()
+		return errors.Wrap(err, "publish user followed event err")
+	}
+
 	err = tx.Commit().Error
 	if err != nil {
 		tx.Rollback()
@@ -139,6 +163,13 @@ func (s *relationService) Unfollow(ctx context.Context, userID uint64, followedU
 		return errors.Wrap(err, "update user fans count err")
 	}
 
+	// publish event in the same tx
+	err = s.publishFollowEvent(ctx, tx, TopicUserUnfollowed, userID, followedUID)
+	if err != nil {
+		tx.Rollback()
+		return errors.Wrap(err, "publish user unfollowed event err")
+	}
+
 	err = tx.Commit().Error
 	if err != nil {
 		tx.Rollback()
@@ -148,6 +179,16 @@ func (s *relationService) Unfollow(ctx context.Context, userID uint64, followedU
 	return nil
 }
 
+// publishFollowEvent write a follow event to outbox, the user id is used as key to keep the order
+func (s *relationService) publishFollowEvent(ctx context.Context, tx *gorm.DB, topic string, userID, followedUID uint64) error {
+	event := FollowEvent{
+		UserID:      userID,
+		FollowedUID: followedUID,
+		CreatedAt:   time.Now(),
+	}
+	return outbox.PublishJSON(ctx, tx, topic, strconv.FormatUint(userID, 10), event)
+}
+
 // GetFollowingUserList Get a list of users you are following
 func (s *relationService) GetFollowingUserList(ctx context.Context, userID uint64, lastID uint64, limit int) ([]*model.UserFollowModel, error) {
 	if lastID == 0 {
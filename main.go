@@ -13,6 +13,7 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -29,7 +30,12 @@ import (
 	eagle "github.com/go-eagle/eagle/pkg/app"
 	"github.com/go-eagle/eagle/pkg/config"
 	logger "github.com/go-eagle/eagle/pkg/log"
+	"github.com/go-eagle/eagle/pkg/queue"
+	"github.com/go-eagle/eagle/pkg/ratelimit"
 	"github.com/go-eagle/eagle/pkg/redis"
+	"github.com/go-eagle/eagle/pkg/storage/orm"
+	"github.com/go-eagle/eagle/pkg/trace"
+	"github.com/go-eagle/eagle/pkg/transport"
 	v "github.com/go-eagle/eagle/pkg/version"
 )
 
@@ -61,6 +67,12 @@ func main() {
 
 	// init config
 	c := config.New(*cfgDir, config.WithEnv(*env))
+
+	// check the config files before deploy, eg: ./eagle config check -c config -e prod
+	if pflag.Arg(0) == "config" && pflag.Arg(1) == "check" {
+		os.Exit(checkConfig(c))
+	}
+
 	var cfg eagle.Config
 	if err := c.Load("app", &cfg); err != nil {
 		panic(err)
@@ -68,6 +80,11 @@ func main() {
 	// set global
 	eagle.Conf = &cfg
 
+	var queueCfg queue.Config
+	if err := c.Load("queue", &queueCfg); err != nil {
+		panic(err)
+	}
+
 	// -------------- init resource -------------
 	logger.Init()
 	// init db
@@ -80,6 +97,21 @@ func main() {
 
 	gin.SetMode(cfg.Mode)
 
+	// init http server
+	servers := []transport.Server{server.NewHTTPServer(&cfg.HTTP)}
+
+	// init outbox relay, the events are kept in outbox until a durable queue is configured
+	relay, err := server.NewOutboxRelay(model.GetDB(), &queueCfg)
+	switch {
+	case errors.Is(err, server.ErrOutboxNotDurable):
+		log.Printf("outbox relay is disabled: %v", err)
+	case err != nil:
+		panic(err)
+	default:
+		// publish the events in outbox
+		servers = append(servers, relay)
+	}
+
 	// init pprof server
 	go func() {
 		fmt.Printf("Listening and serving PProf HTTP on %s\n", cfg.PprofPort)
@@ -93,13 +125,31 @@ func main() {
 		eagle.WithName(cfg.Name),
 		eagle.WithVersion(cfg.Version),
 		eagle.WithLogger(logger.GetLogger()),
-		eagle.WithServer(
-			// init http server
-			server.NewHTTPServer(&cfg.HTTP),
-		),
+		eagle.WithServer(servers...),
 	)
 
 	if err := app.Run(); err != nil {
 		panic(err)
 	}
 }
+
+// checkConfig validate the config files of the env strictly, returns the exit code
+func checkConfig(c *config.Config) int {
+	errs := c.Check(map[string]interface{}{
+		"app":       eagle.Config{},
+		"database":  orm.Config{},
+		"logger":    logger.Config{},
+		"queue":     queue.Config{},
+		"ratelimit": ratelimit.Config{},
+		"redis":     map[string]redis.Config{},
+		"trace":     trace.Config{},
+	})
+	for _, err := range errs {
+		fmt.Println(err)
+	}
+	if len(errs) > 0 {
+		return 1
+	}
+	fmt.Println("config is ok")
+	return 0
+}
@@ -152,11 +152,23 @@ func (a *App) buildInstance() (*registry.ServiceInstance, error) {
 			}
 		}
 	}
+	// merge the metadata of servers, eg: the topics of queue consumers
+	metadata := make(map[string]string, len(a.opts.metadata))
+	for k, v := range a.opts.metadata {
+		metadata[k] = v
+	}
+	for _, srv := range a.opts.servers {
+		if m, ok := srv.(transport.Metadata); ok {
+			for k, v := range m.Metadata() {
+				metadata[k] = v
+			}
+		}
+	}
 	return &registry.ServiceInstance{
 		ID:        a.opts.id,
 		Name:      a.opts.name,
 		Version:   a.opts.version,
-		Metadata:  a.opts.metadata,
+		Metadata:  metadata,
 		Endpoints: endpoints,
 	}, nil
 }
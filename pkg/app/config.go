@@ -12,13 +12,13 @@ var (
 // Config global config
 // nolint
 type Config struct {
-	Name              string
+	Name              string `validate:"required"`
 	Version           string
-	Mode              string
+	Mode              string `validate:"oneof=debug release test"`
 	PprofPort         string
 	URL               string
-	JwtSecret         string
-	JwtTimeout        int
+	JwtSecret         string `validate:"required"`
+	JwtTimeout        int    `validate:"gt=0"`
 	SSL               bool
 	CtxDefaultTimeout time.Duration
 	CSRF              bool
@@ -32,7 +32,17 @@ type Config struct {
 // ServerConfig server config.
 type ServerConfig struct {
 	Network      string
-	Addr         string
-	ReadTimeout  time.Duration
-	WriteTimeout time.Duration
+	Addr         string        `validate:"required"`
+	ReadTimeout  time.Duration `validate:"gte=0"`
+	WriteTimeout time.Duration `validate:"gte=0"`
+	// TLS is enabled if the cert and key files are set
+	CertFile string `validate:"required_with=KeyFile"`
+	KeyFile  string `validate:"required_with=CertFile"`
+	// CAFile enable mTLS, the client certificates are verified by it
+	CAFile string
+}
+
+// EnableTLS check if the cert and key files are set
+func (c *ServerConfig) EnableTLS() bool {
+	return c.CertFile != "" && c.KeyFile != ""
 }
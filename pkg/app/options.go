@@ -68,7 +68,8 @@ func WithSignal(sigs ...os.Signal) Option {
 	}
 }
 
-// WithMetadata with service metadata.
+// WithMetadata with service metadata,
+// eg: registry.MetadataWeight, registry.MetadataZone and registry.MetadataColor for routing.
 func WithMetadata(md map[string]string) Option {
 	return func(o *options) { o.metadata = md }
 }
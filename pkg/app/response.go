@@ -56,6 +56,8 @@ func (r *Response) Error(c *gin.Context, err error) {
 		})
 		return
 	}
+	// record the error, so that the middlewares can classify the result, eg: breaker
+	_ = c.Error(err)
 
 	if v, ok := err.(*errcode.Error); ok {
 		response := Response{
@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"time"
+
+	"github.com/go-redis/redis/v8"
 )
 
 var (
@@ -18,6 +20,8 @@ var (
 	// DefaultClient Generate a cache client, where keyPrefix is generally a business prefix
 	DefaultClient Cache
 
+	// ErrNotFound returned by Get when the key does not exist, same as redis.Nil
+	ErrNotFound = redis.Nil
 	// ErrPlaceholder .
 	ErrPlaceholder = errors.New("cache: placeholder")
 	// ErrSetMemoryWithNotFound .
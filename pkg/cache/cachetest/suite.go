@@ -0,0 +1,249 @@
+// Package cachetest provides a conformance test suite for cache.Cache implementations.
+package cachetest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/go-eagle/eagle/pkg/cache"
+)
+
+// waitFor the max time to wait for an asynchronous write, eg: ristretto
+const waitFor = time.Second
+
+// Object the value used by the suite,
+// the newObject of a redis cache should return a *Object
+type Object struct {
+	ID   int64
+	Name string
+}
+
+// NewObject can be passed to cache.NewRedisCache as newObject
+func NewObject() interface{} {
+	return new(Object)
+}
+
+// Option suite option
+type Option func(*options)
+
+type options struct {
+	keyPrefix string
+	sleep     func(d time.Duration)
+}
+
+// WithKeyPrefix the key prefix used by the cache, MultiGet returns the map keyed by the prefixed key
+func WithKeyPrefix(prefix string) Option {
+	return func(o *options) {
+		o.keyPrefix = prefix
+	}
+}
+
+// WithSleep set how to let time pass, eg: miniredis.FastForward, default is time.Sleep
+func WithSleep(sleep func(d time.Duration)) Option {
+	return func(o *options) {
+		o.sleep = sleep
+	}
+}
+
+type suite struct {
+	options
+	newCache func() cache.Cache
+}
+
+// RunSuite check the whole cache.Cache contract against the cache created by newCache
+func RunSuite(t *testing.T, newCache func() cache.Cache, opts ...Option) {
+	s := &suite{
+		options: options{
+			sleep: time.Sleep,
+		},
+		newCache: newCache,
+	}
+	for _, o := range opts {
+		o(&s.options)
+	}
+
+	t.Run("SetGet", s.testSetGet)
+	t.Run("Miss", s.testMiss)
+	t.Run("EmptyKey", s.testEmptyKey)
+	t.Run("TTL", s.testTTL)
+	t.Run("Placeholder", s.testPlaceholder)
+	t.Run("MultiSetGet", s.testMultiSetGet)
+	t.Run("Del", s.testDel)
+}
+
+// key return a unique key, so that the caches created by newCache can share the same backend
+func (s *suite) key(name string) string {
+	return name + "-" + uuid.New().String()
+}
+
+func (s *suite) cacheKey(t *testing.T, key string) string {
+	cacheKey, err := cache.BuildCacheKey(s.keyPrefix, key)
+	require.NoError(t, err)
+	return cacheKey
+}
+
+// get wait until the key is visible
+func (s *suite) get(t *testing.T, c cache.Cache, key string, val interface{}) error {
+	var err error
+	assert.Eventually(t, func() bool {
+		err = c.Get(context.Background(), key, val)
+		return !errors.Is(err, cache.ErrNotFound)
+	}, waitFor, 5*time.Millisecond, "key %s is not visible", key)
+	return err
+}
+
+// miss wait until the key is gone
+func (s *suite) miss(t *testing.T, c cache.Cache, key string) {
+	assert.Eventually(t, func() bool {
+		var val Object
+		return errors.Is(c.Get(context.Background(), key, &val), cache.ErrNotFound)
+	}, waitFor, 5*time.Millisecond, "key %s is not gone", key)
+}
+
+func (s *suite) testSetGet(t *testing.T) {
+	c := s.newCache()
+	ctx := context.Background()
+	key := s.key("set-get")
+
+	want := &Object{ID: 1, Name: "eagle"}
+	require.NoError(t, c.Set(ctx, key, want, time.Minute))
+
+	var got Object
+	require.NoError(t, s.get(t, c, key, &got))
+	assert.Equal(t, *want, got)
+
+	// overwrite
+	want = &Object{ID: 2, Name: "eagle2"}
+	require.NoError(t, c.Set(ctx, key, want, time.Minute))
+	assert.Eventually(t, func() bool {
+		got = Object{}
+		return c.Get(ctx, key, &got) == nil && got == *want
+	}, waitFor, 5*time.Millisecond)
+
+	// value must be a pointer
+	assert.Error(t, c.Set(ctx, key, Object{}, time.Minute))
+}
+
+func (s *suite) testMiss(t *testing.T) {
+	c := s.newCache()
+
+	var got Object
+	err := c.Get(context.Background(), s.key("miss"), &got)
+	assert.True(t, errors.Is(err, cache.ErrNotFound), "want ErrNotFound, got %v", err)
+	assert.Equal(t, Object{}, got)
+}
+
+func (s *suite) testEmptyKey(t *testing.T) {
+	c := s.newCache()
+	ctx := context.Background()
+
+	var got Object
+	assert.Error(t, c.Set(ctx, "", &Object{}, time.Minute))
+	assert.Error(t, c.Get(ctx, "", &got))
+	assert.Error(t, c.Del(ctx, ""))
+	assert.Error(t, c.SetCacheWithNotFound(ctx, ""))
+}
+
+func (s *suite) testTTL(t *testing.T) {
+	c := s.newCache()
+	ctx := context.Background()
+	expiring, persistent := s.key("ttl"), s.key("ttl-default")
+
+	require.NoError(t, c.Set(ctx, expiring, &Object{ID: 1}, 100*time.Millisecond))
+	// zero expiration uses cache.DefaultExpireTime
+	require.NoError(t, c.Set(ctx, persistent, &Object{ID: 2}, 0))
+
+	var got Object
+	require.NoError(t, s.get(t, c, expiring, &got))
+	require.NoError(t, s.get(t, c, persistent, &got))
+
+	s.sleep(200 * time.Millisecond)
+	err := c.Get(ctx, expiring, &got)
+	assert.True(t, errors.Is(err, cache.ErrNotFound), "want ErrNotFound, got %v", err)
+	assert.NoError(t, c.Get(ctx, persistent, &got))
+}
+
+func (s *suite) testPlaceholder(t *testing.T) {
+	c := s.newCache()
+	ctx := context.Background()
+	key := s.key("placeholder")
+
+	require.NoError(t, c.SetCacheWithNotFound(ctx, key))
+
+	var got Object
+	err := s.get(t, c, key, &got)
+	assert.True(t, errors.Is(err, cache.ErrPlaceholder), "want ErrPlaceholder, got %v", err)
+
+	// placeholders are skipped by MultiGet
+	valueMap := make(map[string]*Object)
+	require.NoError(t, c.MultiGet(ctx, []string{key}, valueMap))
+	assert.Empty(t, valueMap)
+}
+
+func (s *suite) testMultiSetGet(t *testing.T) {
+	c := s.newCache()
+	ctx := context.Background()
+	k1, k2, k3 := s.key("multi"), s.key("multi"), s.key("multi")
+
+	require.NoError(t, c.MultiSet(ctx, nil, time.Minute))
+	require.NoError(t, c.MultiSet(ctx, map[string]interface{}{
+		k1: &Object{ID: 1, Name: "a"},
+		k2: &Object{ID: 2, Name: "b"},
+	}, time.Minute))
+
+	// the map is keyed by the prefixed cache key, missing keys are skipped
+	valueMap := make(map[string]*Object)
+	assert.Eventually(t, func() bool {
+		err := c.MultiGet(ctx, []string{k1, k2, k3}, valueMap)
+		return err == nil && len(valueMap) == 2
+	}, waitFor, 5*time.Millisecond)
+	if assert.Contains(t, valueMap, s.cacheKey(t, k1)) {
+		assert.Equal(t, Object{ID: 1, Name: "a"}, *valueMap[s.cacheKey(t, k1)])
+	}
+	if assert.Contains(t, valueMap, s.cacheKey(t, k2)) {
+		assert.Equal(t, Object{ID: 2, Name: "b"}, *valueMap[s.cacheKey(t, k2)])
+	}
+	assert.NotContains(t, valueMap, s.cacheKey(t, k3))
+
+	// MultiSet applies the expiration to every key
+	require.NoError(t, c.MultiSet(ctx, map[string]interface{}{
+		k3: &Object{ID: 3},
+	}, 100*time.Millisecond))
+	var got Object
+	require.NoError(t, s.get(t, c, k3, &got))
+	s.sleep(200 * time.Millisecond)
+	assert.True(t, errors.Is(c.Get(ctx, k3, &got), cache.ErrNotFound))
+
+	// no keys
+	require.NoError(t, c.MultiGet(ctx, nil, valueMap))
+}
+
+func (s *suite) testDel(t *testing.T) {
+	c := s.newCache()
+	ctx := context.Background()
+	k1, k2, k3 := s.key("del"), s.key("del"), s.key("del")
+
+	for _, key := range []string{k1, k2, k3} {
+		require.NoError(t, c.Set(ctx, key, &Object{Name: key}, time.Minute))
+	}
+	var got Object
+	for _, key := range []string{k1, k2, k3} {
+		require.NoError(t, s.get(t, c, key, &got))
+	}
+
+	// delete all the given keys
+	require.NoError(t, c.Del(ctx))
+	require.NoError(t, c.Del(ctx, k1, k2))
+	s.miss(t, c, k1)
+	s.miss(t, c, k2)
+	assert.NoError(t, c.Get(ctx, k3, &got))
+
+	// deleting a missing key is not an error
+	assert.NoError(t, c.Del(ctx, s.key("del")))
+}
@@ -0,0 +1,87 @@
+package cache_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+
+	"github.com/go-eagle/eagle/pkg/cache"
+	"github.com/go-eagle/eagle/pkg/cache/cachetest"
+	"github.com/go-eagle/eagle/pkg/encoding"
+)
+
+var encodings = map[string]encoding.Encoding{
+	"json":        encoding.JSONEncoding{},
+	"json-gzip":   encoding.JSONGzipEncoding{},
+	"json-snappy": encoding.JSONSnappyEncoding{},
+	"gob":         encoding.GobEncoding{},
+	"msgpack":     encoding.MsgPackEncoding{},
+}
+
+var prefixes = []string{"", "conformance"}
+
+func TestMemoryCache_Conformance(t *testing.T) {
+	for name, enc := range encodings {
+		for _, prefix := range prefixes {
+			enc, prefix := enc, prefix
+			t.Run(name+"/"+prefix, func(t *testing.T) {
+				cachetest.RunSuite(t, func() cache.Cache {
+					return cache.NewMemoryCache(prefix, enc)
+				}, cachetest.WithKeyPrefix(prefix))
+			})
+		}
+	}
+}
+
+func TestRedisCache_Conformance(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mr.Close()
+
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer rdb.Close()
+
+	for name, enc := range encodings {
+		for _, prefix := range prefixes {
+			enc, prefix := enc, prefix
+			t.Run(name+"/"+prefix, func(t *testing.T) {
+				cachetest.RunSuite(t, func() cache.Cache {
+					return cache.NewRedisCache(rdb, prefix, enc, cachetest.NewObject)
+				}, cachetest.WithKeyPrefix(prefix), cachetest.WithSleep(mr.FastForward))
+			})
+		}
+	}
+}
+
+func TestMultiLevelCache_Conformance(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mr.Close()
+
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer rdb.Close()
+
+	// l1 expires by the real time, l2 by the time of miniredis
+	sleep := func(d time.Duration) {
+		mr.FastForward(d)
+		time.Sleep(d)
+	}
+	for name, enc := range encodings {
+		for _, prefix := range prefixes {
+			enc, prefix := enc, prefix
+			t.Run(name+"/"+prefix, func(t *testing.T) {
+				cachetest.RunSuite(t, func() cache.Cache {
+					l1 := cache.NewMemoryCache(prefix, enc)
+					l2 := cache.NewRedisCache(rdb, prefix, enc, cachetest.NewObject)
+					return cache.NewMultiLevelCache(l1, l2)
+				}, cachetest.WithKeyPrefix(prefix), cachetest.WithSleep(sleep))
+			})
+		}
+	}
+}
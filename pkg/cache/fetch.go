@@ -0,0 +1,290 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"reflect"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+
+	"github.com/go-eagle/eagle/pkg/encoding"
+	"github.com/go-eagle/eagle/pkg/log"
+)
+
+var (
+	fetchGroup singleflight.Group
+	// refreshing keys which are being refreshed in background
+	refreshing sync.Map
+)
+
+// Loader load data from the source(eg: db) when cache miss,
+// return a nil value if the data does not exist
+type Loader func(ctx context.Context) (interface{}, error)
+
+// FetchOption fetch option
+type FetchOption func(*fetchOptions)
+
+type fetchOptions struct {
+	cache      Cache
+	expiration time.Duration
+	jitter     time.Duration
+	stale      time.Duration
+	beta       float64
+	timeout    time.Duration
+	encoding   encoding.Encoding
+}
+
+// WithFetchCache set the cache to fetch from, default is DefaultClient
+func WithFetchCache(c Cache) FetchOption {
+	return func(o *fetchOptions) {
+		o.cache = c
+	}
+}
+
+// WithFetchExpiration set the expiration time of the loaded data
+func WithFetchExpiration(expiration time.Duration) FetchOption {
+	return func(o *fetchOptions) {
+		o.expiration = expiration
+	}
+}
+
+// WithFetchTimeout set the timeout of the loader, default is 3s,
+// the loader is shared by the concurrent misses, so it's not canceled with the ctx of any caller
+func WithFetchTimeout(timeout time.Duration) FetchOption {
+	return func(o *fetchOptions) {
+		o.timeout = timeout
+	}
+}
+
+// WithFetchEncoding set the encoding used to copy the loaded data to every caller, default is json
+func WithFetchEncoding(e encoding.Encoding) FetchOption {
+	return func(o *fetchOptions) {
+		o.encoding = e
+	}
+}
+
+// WithJitter add a random duration in [0, jitter) to the expiration time,
+// to prevent a large number of keys from expiring at the same time(cache avalanche)
+func WithJitter(jitter time.Duration) FetchOption {
+	return func(o *fetchOptions) {
+		o.jitter = jitter
+	}
+}
+
+// WithStale keep the data for another stale duration after it expires,
+// the stale data is served while it is refreshed in background
+func WithStale(stale time.Duration) FetchOption {
+	return func(o *fetchOptions) {
+		o.stale = stale
+	}
+}
+
+// WithEarlyRefresh refresh the data in background before it expires,
+// with a probability growing as the expiration approaches,
+// beta > 1 favors earlier refresh, 1 is a good default.
+// see: https://cseweb.ucsd.edu/~avattani/papers/cache_stampede.pdf
+func WithEarlyRefresh(beta float64) FetchOption {
+	return func(o *fetchOptions) {
+		o.beta = beta
+	}
+}
+
+// fetchMeta the metadata used to decide whether to refresh
+type fetchMeta struct {
+	// Delta the time cost of the loader, in nanoseconds
+	Delta int64
+	// Expire the logical expiration time, in unix nanoseconds
+	Expire int64
+}
+
+// Fetch get data from cache into dst, on miss call loader and write the result back.
+// concurrent misses of the same key only call the loader once,
+// ErrPlaceholder is returned if the data does not exist.
+func Fetch(ctx context.Context, key string, dst interface{}, loader Loader, opts ...FetchOption) error {
+	o := fetchOptions{
+		cache:      DefaultClient,
+		expiration: DefaultExpireTime,
+		timeout:    3 * time.Second,
+		encoding:   encoding.JSONEncoding{},
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.cache == nil {
+		return errors.New("cache: fetch cache is nil")
+	}
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return encoding.ErrNotAPointer
+	}
+
+	err := o.cache.Get(ctx, key, dst)
+	switch {
+	case err == nil:
+		if o.refreshable() && o.shouldRefresh(ctx, key) {
+			o.refresh(key, loader)
+		}
+		return nil
+	case errors.Is(err, ErrPlaceholder):
+		return err
+	case !errors.Is(err, ErrNotFound):
+		// fail fast, don't request the source if the cache is broken
+		return err
+	}
+
+	ch := fetchGroup.DoChan(o.groupKey(key), func() (interface{}, error) {
+		return o.load(detach(ctx), key, loader)
+	})
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case res := <-ch:
+		if res.Err != nil {
+			return res.Err
+		}
+		// NOTE: decode for every caller, so the slices and maps in it are not shared
+		return encoding.Unmarshal(o.encoding, res.Val.([]byte), dst)
+	}
+}
+
+func (o *fetchOptions) groupKey(key string) string {
+	return fmt.Sprintf("%p:%s", o.cache, key)
+}
+
+func (o *fetchOptions) refreshable() bool {
+	return o.stale > 0 || o.beta > 0
+}
+
+// load call the loader and write the result to cache, return the encoded result
+func (o *fetchOptions) load(ctx context.Context, key string, loader Loader) (interface{}, error) {
+	if o.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	val, err := loader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if isNil(val) {
+		if err := o.cache.SetCacheWithNotFound(ctx, key); err != nil {
+			log.Warnf("[cache] fetch set not found err: %v, key: %s", err, key)
+		}
+		return nil, ErrPlaceholder
+	}
+	val = toPointer(val)
+	data, err := encoding.Marshal(o.encoding, val)
+	if err != nil {
+		return nil, err
+	}
+
+	expiration := o.expiration
+	if o.jitter > 0 {
+		expiration += time.Duration(rand.Int63n(int64(o.jitter)))
+	}
+	if err := o.cache.Set(ctx, key, val, expiration+o.stale); err != nil {
+		log.Warnf("[cache] fetch set err: %v, key: %s", err, key)
+		return data, nil
+	}
+	if o.refreshable() {
+		meta := &fetchMeta{
+			Delta:  int64(time.Since(start)),
+			Expire: start.Add(expiration).UnixNano(),
+		}
+		if err := o.cache.Set(ctx, metaKey(key), meta, expiration+o.stale); err != nil {
+			log.Warnf("[cache] fetch set meta err: %v, key: %s", err, key)
+		}
+	}
+	return data, nil
+}
+
+// shouldRefresh the data is stale or the early refresh is hit
+func (o *fetchOptions) shouldRefresh(ctx context.Context, key string) bool {
+	meta := new(fetchMeta)
+	if err := o.cache.Get(ctx, metaKey(key), meta); err != nil || meta.Expire == 0 {
+		return false
+	}
+	now := time.Now().UnixNano()
+	if now >= meta.Expire {
+		return true
+	}
+	if o.beta <= 0 {
+		return false
+	}
+	// XFetch: now - delta * beta * ln(rand) >= expire
+	gap := -float64(meta.Delta) * o.beta * math.Log(1-rand.Float64())
+	return float64(now)+gap >= float64(meta.Expire)
+}
+
+// refresh reload the data in background, only one refresh per key at a time
+func (o *fetchOptions) refresh(key string, loader Loader) {
+	groupKey := o.groupKey(key)
+	if _, loaded := refreshing.LoadOrStore(groupKey, struct{}{}); loaded {
+		return
+	}
+	go func() {
+		defer refreshing.Delete(groupKey)
+		_, err, _ := fetchGroup.Do(groupKey, func() (interface{}, error) {
+			return o.load(context.Background(), key, loader)
+		})
+		if err != nil && !errors.Is(err, ErrPlaceholder) {
+			log.Warnf("[cache] fetch refresh err: %v, key: %s", err, key)
+		}
+	}()
+}
+
+func metaKey(key string) string {
+	return key + ":meta"
+}
+
+func isNil(val interface{}) bool {
+	if val == nil {
+		return true
+	}
+	rv := reflect.ValueOf(val)
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+		return rv.IsNil()
+	}
+	return false
+}
+
+// toPointer the encoding only accepts pointers
+func toPointer(val interface{}) interface{} {
+	rv := reflect.ValueOf(val)
+	if rv.Kind() == reflect.Ptr {
+		return val
+	}
+	ptr := reflect.New(rv.Type())
+	ptr.Elem().Set(rv)
+	return ptr.Interface()
+}
+
+// detachedContext keep the values of the parent, but it's never canceled
+type detachedContext struct {
+	context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (detachedContext) Err() error {
+	return nil
+}
+
+// detach the loader is shared by the callers, so it should not be canceled by the first one
+func detach(ctx context.Context) context.Context {
+	return detachedContext{Context: ctx}
+}
@@ -0,0 +1,199 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/go-eagle/eagle/pkg/encoding"
+)
+
+type fetchUser struct {
+	ID   int64
+	Name string
+}
+
+// isRefreshing whether there is a background refresh running
+func isRefreshing() bool {
+	running := false
+	refreshing.Range(func(key, value interface{}) bool {
+		running = true
+		return false
+	})
+	return running
+}
+
+func TestFetch(t *testing.T) {
+	rdb := newTestRedis(t)
+	c := NewRedisCache(rdb, "", encoding.JSONEncoding{}, nil)
+	ctx := context.Background()
+
+	var calls int32
+	loader := func(ctx context.Context) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(20 * time.Millisecond)
+		return &fetchUser{ID: 1, Name: "eagle"}, nil
+	}
+
+	// concurrent misses only call the loader once
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var u fetchUser
+			assert.NoError(t, Fetch(ctx, "user:1", &u, loader, WithFetchCache(c)))
+			assert.Equal(t, "eagle", u.Name)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	// hit cache
+	var u *fetchUser
+	assert.NoError(t, Fetch(ctx, "user:1", &u, loader, WithFetchCache(c)))
+	assert.Equal(t, int64(1), u.ID)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestFetch_Shared(t *testing.T) {
+	rdb := newTestRedis(t)
+	c := NewRedisCache(rdb, "", encoding.JSONEncoding{}, nil)
+
+	started := make(chan struct{})
+	loader := func(ctx context.Context) (interface{}, error) {
+		close(started)
+		time.Sleep(50 * time.Millisecond)
+		// the loader is not canceled by the first caller
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		return []string{"a", "b"}, nil
+	}
+
+	// the first caller gives up
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	go func() {
+		var tags []string
+		first <- Fetch(ctx, "tags", &tags, loader, WithFetchCache(c))
+	}()
+	<-started
+	cancel()
+	assert.True(t, errors.Is(<-first, context.Canceled))
+
+	var wg sync.WaitGroup
+	results := make([][]string, 2)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			assert.NoError(t, Fetch(context.Background(), "tags", &results[i], loader, WithFetchCache(c)))
+		}(i)
+	}
+	wg.Wait()
+
+	// every caller gets a copy
+	assert.Equal(t, []string{"a", "b"}, results[0])
+	results[0][0] = "changed"
+	assert.Equal(t, []string{"a", "b"}, results[1])
+}
+
+func TestFetch_NotFound(t *testing.T) {
+	rdb := newTestRedis(t)
+	c := NewRedisCache(rdb, "", encoding.JSONEncoding{}, nil)
+	ctx := context.Background()
+
+	var calls int32
+	loader := func(ctx context.Context) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		return nil, nil
+	}
+
+	var u fetchUser
+	err := Fetch(ctx, "user:2", &u, loader, WithFetchCache(c))
+	assert.True(t, errors.Is(err, ErrPlaceholder))
+	assert.Equal(t, NotFoundPlaceholder, rdb.Get(ctx, "user:2").Val())
+
+	err = Fetch(ctx, "user:2", &u, loader, WithFetchCache(c))
+	assert.True(t, errors.Is(err, ErrPlaceholder))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	// loader error is returned and not cached
+	loadErr := errors.New("db down")
+	err = Fetch(ctx, "user:3", &u, func(ctx context.Context) (interface{}, error) {
+		return nil, loadErr
+	}, WithFetchCache(c))
+	assert.Equal(t, loadErr, err)
+	assert.Equal(t, int64(0), rdb.Exists(ctx, "user:3").Val())
+}
+
+func TestFetch_Jitter(t *testing.T) {
+	rdb := newTestRedis(t)
+	c := NewRedisCache(rdb, "", encoding.JSONEncoding{}, nil)
+	ctx := context.Background()
+
+	var n int64
+	assert.NoError(t, Fetch(ctx, "num", &n, func(ctx context.Context) (interface{}, error) {
+		return int64(10), nil
+	}, WithFetchCache(c), WithFetchExpiration(time.Minute), WithJitter(time.Minute)))
+	assert.Equal(t, int64(10), n)
+
+	ttl := rdb.TTL(ctx, "num").Val()
+	assert.True(t, ttl >= time.Minute && ttl < 2*time.Minute)
+}
+
+func TestFetch_Stale(t *testing.T) {
+	rdb := newTestRedis(t)
+	c := NewRedisCache(rdb, "", encoding.JSONEncoding{}, nil)
+	ctx := context.Background()
+
+	var version int64
+	loader := func(ctx context.Context) (interface{}, error) {
+		return atomic.AddInt64(&version, 1), nil
+	}
+	opts := []FetchOption{WithFetchCache(c), WithFetchExpiration(50 * time.Millisecond), WithStale(time.Minute)}
+
+	var n int64
+	assert.NoError(t, Fetch(ctx, "stale", &n, loader, opts...))
+	assert.Equal(t, int64(1), n)
+
+	// expired, serve the stale value and refresh in background
+	time.Sleep(60 * time.Millisecond)
+	assert.NoError(t, Fetch(ctx, "stale", &n, loader, opts...))
+	assert.Equal(t, int64(1), n)
+
+	assert.Eventually(t, func() bool {
+		var got int64
+		return c.Get(ctx, "stale", &got) == nil && got == 2 && !isRefreshing()
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestFetch_EarlyRefresh(t *testing.T) {
+	rdb := newTestRedis(t)
+	c := NewRedisCache(rdb, "", encoding.JSONEncoding{}, nil)
+	ctx := context.Background()
+
+	var version int64
+	loader := func(ctx context.Context) (interface{}, error) {
+		time.Sleep(10 * time.Millisecond)
+		return atomic.AddInt64(&version, 1), nil
+	}
+	// a huge beta always refreshes early
+	opts := []FetchOption{WithFetchCache(c), WithFetchExpiration(time.Minute), WithEarlyRefresh(1e6)}
+
+	var n int64
+	assert.NoError(t, Fetch(ctx, "early", &n, loader, opts...))
+	assert.NoError(t, Fetch(ctx, "early", &n, loader, opts...))
+	assert.Equal(t, int64(1), n)
+
+	assert.Eventually(t, func() bool {
+		var got int64
+		return c.Get(ctx, "early", &got) == nil && got == 2 && !isRefreshing()
+	}, time.Second, 5*time.Millisecond)
+}
@@ -9,9 +9,10 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/go-eagle/eagle/pkg/encoding"
-	"github.com/go-eagle/eagle/pkg/log"
 )
 
+const backendMemory = "memory"
+
 type memoryCache struct {
 	client    *ristretto.Cache
 	KeyPrefix string
@@ -19,6 +20,7 @@ type memoryCache struct {
 }
 
 // NewMemoryCache create a memory cache
+// NOTE: writes are applied asynchronously by ristretto, a value may not be visible right after Set
 func NewMemoryCache(keyPrefix string, encoding encoding.Encoding) Cache {
 	// see: https://dgraph.io/blog/post/introducing-ristretto-high-perf-go-cache/
 	//		https://www.start.io/blog/we-chose-ristretto-cache-for-go-heres-why/
@@ -26,6 +28,9 @@ func NewMemoryCache(keyPrefix string, encoding encoding.Encoding) Cache {
 		NumCounters: 1e7,     // number of keys to track frequency of (10M).
 		MaxCost:     1 << 30, // maximum cost of cache (1GB).
 		BufferItems: 64,      // number of keys per Get buffer.
+		OnEvict: func(key, conflict uint64, value interface{}, cost int64) {
+			_metricEvictions.Inc(backendMemory, keyPrefix)
+		},
 	}
 	store, _ := ristretto.NewCache(config)
 	return &memoryCache{
@@ -45,7 +50,11 @@ func (m *memoryCache) Set(ctx context.Context, key string, val interface{}, expi
 	if err != nil {
 		return errors.Wrapf(err, "build cache key err, key is %+v", key)
 	}
-	m.client.SetWithTTL(cacheKey, buf, 0, expiration)
+	if expiration == 0 {
+		expiration = DefaultExpireTime
+	}
+	// the cost is the size of the data, MaxCost is the max memory used
+	m.client.SetWithTTL(cacheKey, buf, int64(len(buf)), expiration)
 	return nil
 }
 
@@ -55,50 +64,109 @@ func (m *memoryCache) Get(ctx context.Context, key string, val interface{}) erro
 	if err != nil {
 		return errors.Wrapf(err, "build cache key err, key is %+v", key)
 	}
-	data, ok := m.client.Get(cacheKey)
+	data, ok := m.getBytes(cacheKey)
 	if !ok {
+		return ErrNotFound
+	}
+
+	// Prevent Unmarshal from reporting an error when data is empty
+	if len(data) == 0 {
 		return nil
 	}
-	if data == NotFoundPlaceholder {
+	if string(data) == NotFoundPlaceholder {
 		return ErrPlaceholder
 	}
-	err = encoding.Unmarshal(m.encoding, data.([]byte), val)
+	err = encoding.Unmarshal(m.encoding, data, val)
 	if err != nil {
 		return errors.Wrapf(err, "unmarshal data error, key=%s, cacheKey=%s type=%v, json is %+v ",
-			key, cacheKey, reflect.TypeOf(val), string(data.([]byte)))
+			key, cacheKey, reflect.TypeOf(val), string(data))
 	}
 	return nil
 }
 
 // Del delete
 func (m *memoryCache) Del(ctx context.Context, keys ...string) error {
-	if len(keys) == 0 {
-		return nil
-	}
-
-	key := keys[0]
-	cacheKey, err := BuildCacheKey(m.KeyPrefix, key)
-	if err != nil {
-		log.Warnf("build cache key err: %+v, key is %+v", err, key)
-		return err
+	for _, key := range keys {
+		cacheKey, err := BuildCacheKey(m.KeyPrefix, key)
+		if err != nil {
+			return errors.Wrapf(err, "build cache key err, key is %+v", key)
+		}
+		m.client.Del(cacheKey)
 	}
-	m.client.Del(cacheKey)
 	return nil
 }
 
 // MultiSet batch set
 func (m *memoryCache) MultiSet(ctx context.Context, valMap map[string]interface{}, expiration time.Duration) error {
-	panic("implement me")
+	for key, val := range valMap {
+		if err := m.Set(ctx, key, val, expiration); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-// MultiGet Bulk acquisition
+// MultiGet Bulk acquisition, the value map is keyed by cache key, same as redis cache
 func (m *memoryCache) MultiGet(ctx context.Context, keys []string, val interface{}) error {
-	panic("implement me")
+	if len(keys) == 0 {
+		return nil
+	}
+	valueMap := reflect.ValueOf(val)
+	if valueMap.Kind() != reflect.Map {
+		return errors.Errorf("value must be a map, got %v", reflect.TypeOf(val))
+	}
+	elemType := valueMap.Type().Elem()
+
+	for _, key := range keys {
+		cacheKey, err := BuildCacheKey(m.KeyPrefix, key)
+		if err != nil {
+			return errors.Wrapf(err, "build cache key err, key is %+v", key)
+		}
+		data, ok := m.getBytes(cacheKey)
+		if !ok || len(data) == 0 || string(data) == NotFoundPlaceholder {
+			continue
+		}
+
+		// map[string]*T or map[string]T
+		object := reflect.New(elemType)
+		if elemType.Kind() == reflect.Ptr {
+			object = reflect.New(elemType.Elem())
+		}
+		err = encoding.Unmarshal(m.encoding, data, object.Interface())
+		if err != nil {
+			return errors.Wrapf(err, "unmarshal data error, key=%s, cacheKey=%s type=%v",
+				key, cacheKey, elemType)
+		}
+		if elemType.Kind() != reflect.Ptr {
+			object = object.Elem()
+		}
+		valueMap.SetMapIndex(reflect.ValueOf(cacheKey), object)
+	}
+	return nil
 }
 
+// SetCacheWithNotFound set a placeholder to prevent cache penetration
 func (m *memoryCache) SetCacheWithNotFound(ctx context.Context, key string) error {
-	if m.client.Set(key, NotFoundPlaceholder, int64(DefaultNotFoundExpireTime)) {
+	cacheKey, err := BuildCacheKey(m.KeyPrefix, key)
+	if err != nil {
+		return errors.Wrapf(err, "build cache key err, key is %+v", key)
+	}
+	buf := []byte(NotFoundPlaceholder)
+	if m.client.SetWithTTL(cacheKey, buf, int64(len(buf)), DefaultNotFoundExpireTime) {
 		return nil
 	}
 	return ErrSetMemoryWithNotFound
 }
+
+// getBytes get the raw data and record hit/miss
+func (m *memoryCache) getBytes(cacheKey string) ([]byte, bool) {
+	data, ok := m.client.Get(cacheKey)
+	if !ok {
+		_metricMisses.Inc(backendMemory, m.KeyPrefix)
+		return nil, false
+	}
+	_metricHits.Inc(backendMemory, m.KeyPrefix)
+
+	buf, ok := data.([]byte)
+	return buf, ok
+}
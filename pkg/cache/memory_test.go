@@ -2,7 +2,9 @@ package cache
 
 import (
 	"context"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -20,8 +22,11 @@ func TestMemoStore_Set(t *testing.T) {
 	asserts := assert.New(t)
 
 	store := NewMemoryCache("memory-unit-test", encoding.JSONEncoding{})
-	err := store.Set(context.Background(), "test-key", "test-val", -1)
-	asserts.NoError(err)
+	val := "test-val"
+	asserts.NoError(store.Set(context.Background(), "test-key", &val, time.Minute))
+
+	// the value must be a pointer
+	asserts.Error(store.Set(context.Background(), "test-key", val, time.Minute))
 }
 
 func TestMemoStore_Get(t *testing.T) {
@@ -33,11 +38,47 @@ func TestMemoStore_Get(t *testing.T) {
 	{
 		var gotVal string
 		setVal := "test-val"
-		err := store.Set(ctx, "test-get-key", setVal, 3600)
-		asserts.NoError(err)
-		err = store.Get(ctx, "test-get-key", &gotVal)
+		err := store.Set(ctx, "test-get-key", &setVal, time.Hour)
 		asserts.NoError(err)
-		t.Log(setVal, gotVal)
+		waitGet(t, store, "test-get-key", &gotVal)
 		asserts.Equal(setVal, gotVal)
 	}
 }
+
+func TestMemoStore_Miss(t *testing.T) {
+	store := NewMemoryCache("memory-unit-test", encoding.JSONEncoding{})
+	ctx := context.Background()
+
+	var gotVal string
+	err := store.Get(ctx, "not-exist-key", &gotVal)
+	assert.True(t, errors.Is(err, ErrNotFound))
+
+	// placeholder respects the key prefix
+	assert.NoError(t, store.SetCacheWithNotFound(ctx, "placeholder-key"))
+	assert.Eventually(t, func() bool {
+		return errors.Is(store.Get(ctx, "placeholder-key", &gotVal), ErrPlaceholder)
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestMemoStore_MultiSetGetDel(t *testing.T) {
+	store := NewMemoryCache("memory-unit-test", encoding.JSONEncoding{})
+	ctx := context.Background()
+
+	a, b := int64(1), int64(2)
+	err := store.MultiSet(ctx, map[string]interface{}{"k1": &a, "k2": &b}, time.Minute)
+	assert.NoError(t, err)
+
+	valMap := make(map[string]*int64)
+	assert.Eventually(t, func() bool {
+		err = store.MultiGet(ctx, []string{"k1", "k2", "k3"}, valMap)
+		return err == nil && len(valMap) == 2
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, int64(1), *valMap["memory-unit-test:k1"])
+	assert.Equal(t, int64(2), *valMap["memory-unit-test:k2"])
+
+	// delete all the keys
+	assert.NoError(t, store.Del(ctx, "k1", "k2"))
+	var got int64
+	assert.True(t, errors.Is(store.Get(ctx, "k1", &got), ErrNotFound))
+	assert.True(t, errors.Is(store.Get(ctx, "k2", &got), ErrNotFound))
+}
@@ -1 +1,32 @@
 package cache
+
+import (
+	"github.com/go-eagle/eagle/pkg/metric"
+)
+
+const namespace = "cache_client"
+
+// nolint
+var (
+	_metricHits = metric.NewCounterVec(&metric.CounterVecOpts{
+		Namespace: namespace,
+		Subsystem: "requests",
+		Name:      "hits_total",
+		Help:      "cache client hits count.",
+		Labels:    []string{"backend", "name"},
+	})
+	_metricMisses = metric.NewCounterVec(&metric.CounterVecOpts{
+		Namespace: namespace,
+		Subsystem: "requests",
+		Name:      "misses_total",
+		Help:      "cache client misses count.",
+		Labels:    []string{"backend", "name"},
+	})
+	_metricEvictions = metric.NewCounterVec(&metric.CounterVecOpts{
+		Namespace: namespace,
+		Subsystem: "items",
+		Name:      "evictions_total",
+		Help:      "cache client evicted items count.",
+		Labels:    []string{"backend", "name"},
+	})
+)
@@ -0,0 +1,247 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+
+	"github.com/go-eagle/eagle/pkg/log"
+)
+
+var (
+	// DefaultL1ExpireTime the max expiration time of the local cache
+	DefaultL1ExpireTime = time.Minute
+	// DefaultInvalidateChannel redis pub/sub channel used to broadcast invalidations
+	DefaultInvalidateChannel = "eagle:cache:invalidate"
+)
+
+// MultiLevelOption multi level cache option
+type MultiLevelOption func(*multiLevelCache)
+
+// WithL1Expiration set the max expiration time of the local cache,
+// it should be shorter than the L2 expiration time
+func WithL1Expiration(expiration time.Duration) MultiLevelOption {
+	return func(c *multiLevelCache) {
+		c.l1Expiration = expiration
+	}
+}
+
+// WithInvalidation broadcast invalidations to peer processes via redis pub/sub,
+// so that local copies don't go stale across replicas
+func WithInvalidation(client *redis.Client, channel string) MultiLevelOption {
+	return func(c *multiLevelCache) {
+		c.client = client
+		if channel != "" {
+			c.channel = channel
+		}
+	}
+}
+
+// ttlCache is implemented by the caches which tell the remaining time to live of a key, eg: redis
+type ttlCache interface {
+	TTL(ctx context.Context, key string) (time.Duration, error)
+}
+
+// invalidation the message broadcast to peers
+type invalidation struct {
+	From string   `json:"from"`
+	Keys []string `json:"keys"`
+}
+
+// multiLevelCache local cache(L1) + distributed cache(L2)
+type multiLevelCache struct {
+	l1           Cache
+	l2           Cache
+	l1Expiration time.Duration
+
+	id      string
+	client  *redis.Client
+	channel string
+	pubsub  *redis.PubSub
+	wg      sync.WaitGroup
+}
+
+// NewMultiLevelCache create a multi level cache,
+// reads go through l1 -> l2, writes and deletes go to both levels.
+// the returned cache implements io.Closer to stop listening for invalidations.
+func NewMultiLevelCache(l1, l2 Cache, opts ...MultiLevelOption) Cache {
+	c := &multiLevelCache{
+		l1:           l1,
+		l2:           l2,
+		l1Expiration: DefaultL1ExpireTime,
+		id:           uuid.New().String(),
+		channel:      DefaultInvalidateChannel,
+	}
+	for _, o := range opts {
+		o(c)
+	}
+
+	if c.client != nil {
+		c.pubsub = c.client.Subscribe(context.Background(), c.channel)
+		c.wg.Add(1)
+		go c.listen()
+	}
+
+	return c
+}
+
+// Set write to l2 then l1 and notify peers
+func (c *multiLevelCache) Set(ctx context.Context, key string, val interface{}, expiration time.Duration) error {
+	if err := c.l2.Set(ctx, key, val, expiration); err != nil {
+		return err
+	}
+	if err := c.l1.Set(ctx, key, val, c.getL1Expiration(expiration)); err != nil {
+		return err
+	}
+	return c.publish(ctx, key)
+}
+
+// Get read from l1 first, on miss read from l2 and back-fill l1
+func (c *multiLevelCache) Get(ctx context.Context, key string, val interface{}) error {
+	err := c.l1.Get(ctx, key, val)
+	if err == nil || errors.Is(err, ErrPlaceholder) {
+		return err
+	}
+
+	err = c.l2.Get(ctx, key, val)
+	switch {
+	case err == nil:
+		expiration, ok := c.l2TTL(ctx, key)
+		if !ok {
+			return nil
+		}
+		if err := c.l1.Set(ctx, key, val, c.getL1Expiration(expiration)); err != nil {
+			log.Warnf("[cache] back-fill l1 err: %v, key: %s", err, key)
+		}
+	case errors.Is(err, ErrPlaceholder):
+		if err := c.l1.SetCacheWithNotFound(ctx, key); err != nil {
+			log.Warnf("[cache] back-fill l1 not found err: %v, key: %s", err, key)
+		}
+	}
+	return err
+}
+
+// MultiSet write to l2 then l1 and notify peers
+func (c *multiLevelCache) MultiSet(ctx context.Context, valMap map[string]interface{}, expiration time.Duration) error {
+	if len(valMap) == 0 {
+		return nil
+	}
+	if err := c.l2.MultiSet(ctx, valMap, expiration); err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(valMap))
+	for key, val := range valMap {
+		if err := c.l1.Set(ctx, key, val, c.getL1Expiration(expiration)); err != nil {
+			return err
+		}
+		keys = append(keys, key)
+	}
+	return c.publish(ctx, keys...)
+}
+
+// MultiGet read from l2 directly, the map is keyed by the l2 cache key
+func (c *multiLevelCache) MultiGet(ctx context.Context, keys []string, valueMap interface{}) error {
+	return c.l2.MultiGet(ctx, keys, valueMap)
+}
+
+// Del delete from l2 then l1 and notify peers
+func (c *multiLevelCache) Del(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	if err := c.l2.Del(ctx, keys...); err != nil {
+		return err
+	}
+	if err := c.l1.Del(ctx, keys...); err != nil {
+		return err
+	}
+	return c.publish(ctx, keys...)
+}
+
+// SetCacheWithNotFound write the placeholder to both levels and notify peers
+func (c *multiLevelCache) SetCacheWithNotFound(ctx context.Context, key string) error {
+	if err := c.l2.SetCacheWithNotFound(ctx, key); err != nil {
+		return err
+	}
+	if err := c.l1.SetCacheWithNotFound(ctx, key); err != nil {
+		return err
+	}
+	return c.publish(ctx, key)
+}
+
+// Close stop listening for invalidations
+func (c *multiLevelCache) Close() error {
+	if c.pubsub == nil {
+		return nil
+	}
+	err := c.pubsub.Close()
+	c.wg.Wait()
+	return err
+}
+
+// getL1Expiration the local cache expires at least half earlier than l2,
+// expiration is the expiration of l2 or the remaining ttl of it, zero means the default expiration.
+func (c *multiLevelCache) getL1Expiration(expiration time.Duration) time.Duration {
+	if expiration <= 0 {
+		expiration = DefaultExpireTime
+	}
+	expiration /= 2
+	if c.l1Expiration > 0 && c.l1Expiration < expiration {
+		return c.l1Expiration
+	}
+	return expiration
+}
+
+// l2TTL the remaining time to live of the key in l2, it's zero if l2 can't tell,
+// false is returned if the key is gone, so l1 should not be back-filled.
+func (c *multiLevelCache) l2TTL(ctx context.Context, key string) (time.Duration, bool) {
+	l2, ok := c.l2.(ttlCache)
+	if !ok {
+		return 0, true
+	}
+	ttl, err := l2.TTL(ctx, key)
+	if err != nil {
+		if !errors.Is(err, ErrNotFound) {
+			log.Warnf("[cache] get l2 ttl err: %v, key: %s", err, key)
+		}
+		return 0, false
+	}
+	return ttl, true
+}
+
+// publish notify peers to drop their local copies
+func (c *multiLevelCache) publish(ctx context.Context, keys ...string) error {
+	if c.client == nil {
+		return nil
+	}
+	buf, err := json.Marshal(invalidation{From: c.id, Keys: keys})
+	if err != nil {
+		return err
+	}
+	return c.client.Publish(ctx, c.channel, buf).Err()
+}
+
+// listen drop local copies invalidated by peers
+func (c *multiLevelCache) listen() {
+	defer c.wg.Done()
+
+	for msg := range c.pubsub.Channel() {
+		var inv invalidation
+		if err := json.Unmarshal([]byte(msg.Payload), &inv); err != nil {
+			log.Warnf("[cache] unmarshal invalidation err: %v, payload: %s", err, msg.Payload)
+			continue
+		}
+		// skip the message sent by self
+		if inv.From == c.id || len(inv.Keys) == 0 {
+			continue
+		}
+		if err := c.l1.Del(context.Background(), inv.Keys...); err != nil {
+			log.Warnf("[cache] invalidate l1 err: %v, keys: %v", err, inv.Keys)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/go-eagle/eagle/pkg/encoding"
+)
+
+func newTestRedis(t *testing.T) *redis.Client {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(mr.Close)
+
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = rdb.Close() })
+	return rdb
+}
+
+func strPtr(s string) *string {
+	return &s
+}
+
+// waitGet wait for the async write of the memory cache
+func waitGet(t *testing.T, c Cache, key string, val interface{}) {
+	assert.Eventually(t, func() bool {
+		return c.Get(context.Background(), key, val) == nil
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestMultiLevelCache_Get(t *testing.T) {
+	rdb := newTestRedis(t)
+	ctx := context.Background()
+
+	l1 := NewMemoryCache("ml-test", encoding.JSONEncoding{})
+	l2 := NewRedisCache(rdb, "ml-test", encoding.JSONEncoding{}, nil)
+	c := NewMultiLevelCache(l1, l2)
+
+	// miss both levels
+	var got string
+	err := c.Get(ctx, "key", &got)
+	assert.True(t, errors.Is(err, ErrNotFound))
+
+	// hit l2 and back-fill l1
+	assert.NoError(t, l2.Set(ctx, "key", strPtr("val"), time.Minute))
+	assert.NoError(t, c.Get(ctx, "key", &got))
+	assert.Equal(t, "val", got)
+
+	var local string
+	waitGet(t, l1, "key", &local)
+	assert.Equal(t, "val", local)
+
+	// placeholder
+	assert.NoError(t, l2.SetCacheWithNotFound(ctx, "none"))
+	err = c.Get(ctx, "none", &got)
+	assert.True(t, errors.Is(err, ErrPlaceholder))
+}
+
+// expirationCache record the expiration of Set
+type expirationCache struct {
+	Cache
+	expiration time.Duration
+}
+
+func (c *expirationCache) Set(ctx context.Context, key string, val interface{}, expiration time.Duration) error {
+	c.expiration = expiration
+	return c.Cache.Set(ctx, key, val, expiration)
+}
+
+func TestMultiLevelCache_BackFillExpiration(t *testing.T) {
+	rdb := newTestRedis(t)
+	ctx := context.Background()
+
+	l1 := &expirationCache{Cache: NewMemoryCache("ml-ttl-test", encoding.JSONEncoding{})}
+	l2 := NewRedisCache(rdb, "ml-ttl-test", encoding.JSONEncoding{}, nil)
+	c := NewMultiLevelCache(l1, l2)
+
+	// l1 expires earlier than the remaining ttl of l2
+	assert.NoError(t, l2.Set(ctx, "key", strPtr("val"), 10*time.Second))
+	var got string
+	assert.NoError(t, c.Get(ctx, "key", &got))
+	assert.Equal(t, "val", got)
+	assert.Equal(t, 5*time.Second, l1.expiration)
+}
+
+func TestMultiLevelCache_SetDel(t *testing.T) {
+	rdb := newTestRedis(t)
+	ctx := context.Background()
+
+	l1 := NewMemoryCache("ml-test", encoding.JSONEncoding{})
+	l2 := NewRedisCache(rdb, "ml-test", encoding.JSONEncoding{}, nil)
+	c := NewMultiLevelCache(l1, l2)
+
+	assert.NoError(t, c.Set(ctx, "key", strPtr("val"), time.Minute))
+
+	var got string
+	assert.NoError(t, l2.Get(ctx, "key", &got))
+	assert.Equal(t, "val", got)
+	waitGet(t, l1, "key", &got)
+	assert.Equal(t, "val", got)
+
+	// l1 expires at least half earlier than l2
+	ttl := rdb.TTL(ctx, "ml-test:key").Val()
+	assert.True(t, ttl > 30*time.Second)
+
+	assert.NoError(t, c.Del(ctx, "key"))
+	assert.True(t, errors.Is(l2.Get(ctx, "key", &got), ErrNotFound))
+	assert.True(t, errors.Is(l1.Get(ctx, "key", &got), ErrNotFound))
+}
+
+func TestMultiLevelCache_Invalidation(t *testing.T) {
+	rdb := newTestRedis(t)
+	ctx := context.Background()
+
+	l2 := NewRedisCache(rdb, "ml-test", encoding.JSONEncoding{}, nil)
+	l1a := NewMemoryCache("ml-test", encoding.JSONEncoding{})
+	l1b := NewMemoryCache("ml-test", encoding.JSONEncoding{})
+	a := NewMultiLevelCache(l1a, l2, WithInvalidation(rdb, "test:invalidate"))
+	b := NewMultiLevelCache(l1b, l2, WithInvalidation(rdb, "test:invalidate"))
+	defer a.(io.Closer).Close()
+	defer b.(io.Closer).Close()
+
+	// wait for both subscriptions
+	assert.Eventually(t, func() bool {
+		return rdb.PubSubNumSub(ctx, "test:invalidate").Val()["test:invalidate"] == 2
+	}, time.Second, 5*time.Millisecond)
+
+	// b keeps a local copy
+	assert.NoError(t, a.Set(ctx, "key", strPtr("v1"), time.Minute))
+	var got string
+	assert.NoError(t, b.Get(ctx, "key", &got))
+	assert.Equal(t, "v1", got)
+	waitGet(t, l1b, "key", &got)
+
+	// a updates, b drops its local copy
+	assert.NoError(t, a.Set(ctx, "key", strPtr("v2"), time.Minute))
+	assert.Eventually(t, func() bool {
+		return errors.Is(l1b.Get(ctx, "key", &got), ErrNotFound)
+	}, time.Second, 5*time.Millisecond)
+	assert.NoError(t, b.Get(ctx, "key", &got))
+	assert.Equal(t, "v2", got)
+
+	// a keeps its own local copy
+	waitGet(t, l1a, "key", &got)
+	assert.Equal(t, "v2", got)
+}
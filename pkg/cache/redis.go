@@ -108,7 +108,7 @@ func (c *redisCache) MultiSet(ctx context.Context, valueMap map[string]interface
 	for i := 0; i < len(paris); i = i + 2 {
 		switch paris[i].(type) {
 		case []byte:
-			pipeline.Expire(ctx, string(paris[i].([]byte)), expiration)
+			pipeline.PExpire(ctx, string(paris[i].([]byte)), expiration)
 		default:
 			log.Warnf("redis expire is unsupported key type: %+v", reflect.TypeOf(paris[i]))
 		}
@@ -140,7 +140,7 @@ func (c *redisCache) MultiGet(ctx context.Context, keys []string, value interfac
 	// Injected into map via reflection
 	valueMap := reflect.ValueOf(value)
 	for i, value := range values {
-		if value == nil {
+		if value == nil || value.(string) == "" || value.(string) == NotFoundPlaceholder {
 			continue
 		}
 		object := c.newObject()
@@ -165,8 +165,7 @@ func (c *redisCache) Del(ctx context.Context, keys ...string) error {
 	for index, key := range keys {
 		cacheKey, err := BuildCacheKey(c.KeyPrefix, key)
 		if err != nil {
-			log.Warnf("build cache key err: %+v, key is %+v", err, key)
-			continue
+			return errors.Wrapf(err, "build cache key err, key is %+v", key)
 		}
 		cacheKeys[index] = cacheKey
 	}
@@ -178,5 +177,29 @@ func (c *redisCache) Del(ctx context.Context, keys ...string) error {
 }
 
 func (c *redisCache) SetCacheWithNotFound(ctx context.Context, key string) error {
-	return c.client.Set(ctx, key, NotFoundPlaceholder, DefaultNotFoundExpireTime).Err()
+	cacheKey, err := BuildCacheKey(c.KeyPrefix, key)
+	if err != nil {
+		return errors.Wrapf(err, "build cache key err, key is %+v", key)
+	}
+	return c.client.Set(ctx, cacheKey, NotFoundPlaceholder, DefaultNotFoundExpireTime).Err()
+}
+
+// TTL return the remaining time to live of the key, ErrNotFound is returned if it does not exist,
+// zero is returned if it never expires.
+func (c *redisCache) TTL(ctx context.Context, key string) (time.Duration, error) {
+	cacheKey, err := BuildCacheKey(c.KeyPrefix, key)
+	if err != nil {
+		return 0, errors.Wrapf(err, "build cache key err, key is %+v", key)
+	}
+	ttl, err := c.client.PTTL(ctx, cacheKey).Result()
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case ttl == -2:
+		return 0, ErrNotFound
+	case ttl < 0:
+		return 0, nil
+	}
+	return ttl, nil
 }
@@ -39,7 +39,7 @@ func Test_redisCache_SetGet(t *testing.T) {
 		{
 			"test redis set",
 			cache,
-			setArgs{"key-001", "val-001", 60 * time.Second},
+			setArgs{"key-001", strPtr("val-001"), 60 * time.Second},
 			false,
 		},
 	}
@@ -0,0 +1,198 @@
+package httpclient
+
+import (
+	"errors"
+	"math/rand"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-eagle/eagle/pkg/registry"
+)
+
+const (
+	// MetadataWeight the weight of the instance in metadata, default is 100
+	MetadataWeight = registry.MetadataWeight
+	// DefaultWeight the default weight of the instance
+	DefaultWeight = registry.DefaultWeight
+
+	// ewmaAlpha the weight of the latest latency
+	ewmaAlpha = 0.3
+)
+
+// ErrNoAvailable no available node
+var ErrNoAvailable = errors.New("[httpClient] no available node")
+
+// Node is a http endpoint of a service instance
+type Node struct {
+	scheme   string
+	addr     string
+	weight   int64
+	instance atomic.Value
+
+	// stats
+	inflight     int64
+	latency      int64
+	failures     int64
+	ejectedUntil int64
+
+	// currentWeight the state of smooth weighted round robin, it's guarded by the balancer,
+	// it's kept across the picks of the different subsets, eg: by zone or color,
+	// and dropped with the node when it's removed by discovery.
+	currentWeight int64
+}
+
+func newNode(endpoint *url.URL, ins *registry.ServiceInstance) *Node {
+	n := &Node{
+		scheme: endpoint.Scheme,
+		addr:   endpoint.Host,
+		weight: ins.Weight(),
+	}
+	n.instance.Store(ins)
+	return n
+}
+
+// Address the host:port of the node
+func (n *Node) Address() string {
+	return n.addr
+}
+
+// Weight the weight of the node
+func (n *Node) Weight() int64 {
+	return n.weight
+}
+
+// Instance the service instance of the node
+func (n *Node) Instance() *registry.ServiceInstance {
+	ins, _ := n.instance.Load().(*registry.ServiceInstance)
+	return ins
+}
+
+// Inflight the number of the running requests
+func (n *Node) Inflight() int64 {
+	return atomic.LoadInt64(&n.inflight)
+}
+
+// Latency the ewma latency of the requests
+func (n *Node) Latency() time.Duration {
+	return time.Duration(atomic.LoadInt64(&n.latency))
+}
+
+// load the lower the better
+func (n *Node) load() int64 {
+	return (atomic.LoadInt64(&n.latency) + 1) * (n.Inflight() + 1)
+}
+
+// start a request, the returned func must be called with the result when it is done
+func (n *Node) start() func(success bool) {
+	atomic.AddInt64(&n.inflight, 1)
+	begin := time.Now()
+	return func(success bool) {
+		atomic.AddInt64(&n.inflight, -1)
+
+		rtt := int64(time.Since(begin))
+		old := atomic.LoadInt64(&n.latency)
+		if old > 0 {
+			rtt = int64(float64(old)*(1-ewmaAlpha) + float64(rtt)*ewmaAlpha)
+		}
+		atomic.StoreInt64(&n.latency, rtt)
+
+		if success {
+			atomic.StoreInt64(&n.failures, 0)
+		} else {
+			atomic.AddInt64(&n.failures, 1)
+		}
+	}
+}
+
+// Balancer pick a node from the available nodes
+type Balancer interface {
+	Pick(nodes []*Node) (*Node, error)
+}
+
+// NewRoundRobin create a round robin balancer
+func NewRoundRobin() Balancer {
+	return &roundRobin{}
+}
+
+type roundRobin struct {
+	next uint64
+}
+
+func (b *roundRobin) Pick(nodes []*Node) (*Node, error) {
+	if len(nodes) == 0 {
+		return nil, ErrNoAvailable
+	}
+	i := atomic.AddUint64(&b.next, 1) - 1
+	return nodes[i%uint64(len(nodes))], nil
+}
+
+// NewWeightedRoundRobin create a smooth weighted round robin balancer,
+// the weight is read from the instance metadata
+// see: https://github.com/phusion/nginx/commit/27e94984486058d73157038f7950a0a36ecc6e35
+func NewWeightedRoundRobin() Balancer {
+	return &weightedRoundRobin{}
+}
+
+type weightedRoundRobin struct {
+	mu sync.Mutex
+}
+
+func (b *weightedRoundRobin) Pick(nodes []*Node) (*Node, error) {
+	if len(nodes) == 0 {
+		return nil, ErrNoAvailable
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var (
+		selected *Node
+		total    int64
+	)
+	for _, n := range nodes {
+		n.currentWeight += n.weight
+		total += n.weight
+		if selected == nil || n.currentWeight > selected.currentWeight {
+			selected = n
+		}
+	}
+	selected.currentWeight -= total
+	return selected, nil
+}
+
+// NewP2C create a power of two choices balancer,
+// it picks two nodes randomly and chooses the one with the lower latency * inflight
+func NewP2C() Balancer {
+	return &p2c{
+		r: rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+type p2c struct {
+	mu sync.Mutex
+	r  *rand.Rand
+}
+
+func (b *p2c) Pick(nodes []*Node) (*Node, error) {
+	switch len(nodes) {
+	case 0:
+		return nil, ErrNoAvailable
+	case 1:
+		return nodes[0], nil
+	}
+
+	b.mu.Lock()
+	a := b.r.Intn(len(nodes))
+	c := b.r.Intn(len(nodes) - 1)
+	b.mu.Unlock()
+	if c >= a {
+		c++
+	}
+
+	if nodes[c].load() < nodes[a].load() {
+		return nodes[c], nil
+	}
+	return nodes[a], nil
+}
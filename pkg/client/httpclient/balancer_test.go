@@ -0,0 +1,97 @@
+package httpclient
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/go-eagle/eagle/pkg/registry"
+)
+
+func testNodes(weights ...string) []*Node {
+	nodes := make([]*Node, 0, len(weights))
+	for i, w := range weights {
+		u := &url.URL{Scheme: "http", Host: "127.0.0.1:" + string(rune('0'+i))}
+		nodes = append(nodes, newNode(u, &registry.ServiceInstance{Metadata: map[string]string{MetadataWeight: w}}))
+	}
+	return nodes
+}
+
+func TestRoundRobin(t *testing.T) {
+	b := NewRoundRobin()
+	_, err := b.Pick(nil)
+	assert.Equal(t, ErrNoAvailable, err)
+
+	nodes := testNodes("", "", "")
+	for i := 0; i < 6; i++ {
+		n, err := b.Pick(nodes)
+		assert.NoError(t, err)
+		assert.Equal(t, nodes[i%3], n)
+	}
+}
+
+func TestWeightedRoundRobin(t *testing.T) {
+	b := NewWeightedRoundRobin()
+	_, err := b.Pick(nil)
+	assert.Equal(t, ErrNoAvailable, err)
+
+	// invalid weight falls back to the default weight
+	nodes := testNodes("100", "300", "invalid")
+	assert.Equal(t, int64(DefaultWeight), nodes[2].Weight())
+
+	count := make(map[*Node]int)
+	for i := 0; i < 500; i++ {
+		n, err := b.Pick(nodes)
+		assert.NoError(t, err)
+		count[n]++
+	}
+	assert.Equal(t, 100, count[nodes[0]])
+	assert.Equal(t, 300, count[nodes[1]])
+	assert.Equal(t, 100, count[nodes[2]])
+}
+
+func TestWeightedRoundRobin_Subset(t *testing.T) {
+	b := NewWeightedRoundRobin()
+	nodes := testNodes("100", "300", "100")
+
+	// eg: the requests of zone a and zone b are interleaved
+	count := make(map[*Node]int)
+	for i := 0; i < 400; i++ {
+		n, err := b.Pick(nodes[:2])
+		assert.NoError(t, err)
+		count[n]++
+		n, err = b.Pick(nodes[2:])
+		assert.NoError(t, err)
+		count[n]++
+	}
+	// the weights are kept across the subsets
+	assert.Equal(t, 100, count[nodes[0]])
+	assert.Equal(t, 300, count[nodes[1]])
+	assert.Equal(t, 400, count[nodes[2]])
+}
+
+func TestP2C(t *testing.T) {
+	b := NewP2C()
+	_, err := b.Pick(nil)
+	assert.Equal(t, ErrNoAvailable, err)
+
+	nodes := testNodes("", "")
+	n, err := b.Pick(nodes[:1])
+	assert.NoError(t, err)
+	assert.Equal(t, nodes[0], n)
+
+	// the slow node is not picked
+	done := nodes[0].start()
+	time.Sleep(10 * time.Millisecond)
+	done(true)
+	nodes[1].start()(true)
+	assert.True(t, nodes[0].Latency() > nodes[1].Latency())
+
+	for i := 0; i < 10; i++ {
+		n, err := b.Pick(nodes)
+		assert.NoError(t, err)
+		assert.Equal(t, nodes[1], n)
+	}
+}
@@ -7,15 +7,19 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"math/rand"
 	"net/http"
 	"net/url"
 	"time"
 
-	"github.com/go-eagle/eagle/pkg/utils"
-
+	"github.com/go-kratos/aegis/circuitbreaker"
 	"github.com/pkg/errors"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+
+	"github.com/go-eagle/eagle/pkg/registry"
+	"github.com/go-eagle/eagle/pkg/utils"
 )
 
 // see: https://github.com/iiinsomnia/gochat/blob/master/utils/http.go
@@ -30,82 +34,303 @@ const (
 	DefaultTimeout = 3 * time.Second
 )
 
+var defaultClient = New()
+
 // ------------------ JSON ------------------
 
 // GetJSON get json data by get method
 func GetJSON(ctx context.Context, url string, options ...Option) ([]byte, error) {
-	return withJSONBody(ctx, http.MethodGet, url, nil, options...)
+	var body []byte
+	err := clientWith(options).send(ctx, http.MethodGet, url, ContentTypeJSON, nil, &body)
+	return body, err
 }
 
 // PostJSON send json data by post method
 func PostJSON(ctx context.Context, url string, data json.RawMessage, options ...Option) ([]byte, error) {
-	return withJSONBody(ctx, http.MethodPost, url, data, options...)
-}
-
-func withJSONBody(ctx context.Context, method, url string, raw json.RawMessage, options ...Option) (body []byte, err error) {
-	opt := defaultOptions()
-	for _, o := range options {
-		o(opt)
-	}
-	opt.header["Content-Type"] = []string{ContentTypeJSON}
-	return doRequest(ctx, method, url, raw, opt)
+	var body []byte
+	err := clientWith(options).send(ctx, http.MethodPost, url, ContentTypeJSON, data, &body)
+	return body, err
 }
 
 // ------------------ request form ------------------
 
 // PostForm send form data by post method
 func PostForm(ctx context.Context, url string, form url.Values, options ...Option) ([]byte, error) {
-	return withFormBody(ctx, http.MethodPost, url, form, options...)
+	var body []byte
+	err := clientWith(options).send(ctx, http.MethodPost, url, ContentTypeForm,
+		utils.StringToBytes(form.Encode()), &body)
+	return body, err
+}
+
+func clientWith(options []Option) *Client {
+	if len(options) == 0 {
+		return defaultClient
+	}
+	return New(options...)
 }
 
-func withFormBody(ctx context.Context, method, url string, form url.Values, options ...Option) (body []byte, err error) {
+// ------------------ client ------------------
+
+// Client is a reusable http client
+type Client struct {
+	opts   *options
+	client *http.Client
+}
+
+// New create a http client, it should be reused
+func New(options ...Option) *Client {
 	opt := defaultOptions()
 	for _, o := range options {
 		o(opt)
 	}
-	opt.header["Content-Type"] = []string{ContentTypeForm}
-	formValue := form.Encode()
-	return doRequest(ctx, method, url, utils.StringToBytes(formValue), opt)
+	if opt.tlsConfig != nil {
+		opt.transport = withTLSConfig(opt.transport, opt.tlsConfig)
+	}
+	return &Client{
+		opts: opt,
+		client: &http.Client{
+			// add header and set response status for tracing
+			Transport: otelhttp.NewTransport(opt.transport),
+			Timeout:   opt.timeout,
+		},
+	}
+}
+
+// Get send a get request and decode the json response into out
+func (c *Client) Get(ctx context.Context, url string, out interface{}) error {
+	return c.send(ctx, http.MethodGet, url, "", nil, out)
+}
+
+// Delete send a delete request and decode the json response into out
+func (c *Client) Delete(ctx context.Context, url string, out interface{}) error {
+	return c.send(ctx, http.MethodDelete, url, "", nil, out)
 }
 
-func doRequest(ctx context.Context, method, url string, payload []byte, opt *options) (ret []byte, err error) {
-	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewBuffer(payload))
+// Post send in as json by post method and decode the json response into out
+func (c *Client) Post(ctx context.Context, url string, in, out interface{}) error {
+	body, err := encode(in)
 	if err != nil {
-		return nil, errors.Wrapf(err, "[httpClient] get req err")
+		return err
 	}
+	return c.send(ctx, http.MethodPost, url, ContentTypeJSON, body, out)
+}
 
-	client := &http.Client{
-		// add header and set response status for tracing
-		Transport: otelhttp.NewTransport(http.DefaultTransport),
-		Timeout:   opt.timeout,
+// Put send in as json by put method and decode the json response into out
+func (c *Client) Put(ctx context.Context, url string, in, out interface{}) error {
+	body, err := encode(in)
+	if err != nil {
+		return err
 	}
+	return c.send(ctx, http.MethodPut, url, ContentTypeJSON, body, out)
+}
 
-	// set header
-	for key, value := range opt.header {
-		req.Header.Set(key, value[0])
-	}
+// PostForm send form data by post method and decode the json response into out
+func (c *Client) PostForm(ctx context.Context, url string, form url.Values, out interface{}) error {
+	return c.send(ctx, http.MethodPost, url, ContentTypeForm, utils.StringToBytes(form.Encode()), out)
+}
 
-	ctx, span := tracer.Start(req.Context(), fmt.Sprintf("HTTP %s", method))
+// Do send a request with the headers, hooks, retries and circuit breaker of the client,
+// a *StatusError is returned if the status code is not success,
+// the caller must close the body of the returned response.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	ctx, span := tracer.Start(req.Context(), fmt.Sprintf("HTTP %s", req.Method))
 	defer span.End()
 
-	resp, err := client.Do(req)
+	req = req.Clone(ctx)
+	for key, values := range c.opts.header {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	// propagate the routing hints to the downstream services
+	if r, ok := registry.RouteFromContext(ctx); ok {
+		if r.Color != "" && req.Header.Get(registry.HeaderColor) == "" {
+			req.Header.Set(registry.HeaderColor, r.Color)
+		}
+		if r.Zone != "" && req.Header.Get(registry.HeaderZone) == "" {
+			req.Header.Set(registry.HeaderZone, r.Zone)
+		}
+	}
+	for _, hook := range c.opts.requestHooks {
+		if err := hook(req); err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := c.doWithRetry(ctx, req)
 	if err != nil {
-		return nil, errors.Wrapf(err, "[httpClient] do request from [%s %s] err", method, url)
+		return nil, errors.Wrapf(err, "[httpClient] do request from [%s %s] err", req.Method, req.URL)
 	}
-	if resp != nil {
+	for _, hook := range c.opts.responseHooks {
+		if err := hook(req, resp); err != nil {
+			_ = resp.Body.Close()
+			return nil, err
+		}
+	}
+
+	if !isSuccess(resp.StatusCode) {
 		defer func() {
 			_ = resp.Body.Close()
 		}()
+		body, _ := ioutil.ReadAll(resp.Body)
+		return nil, &StatusError{
+			Method:     req.Method,
+			URL:        req.URL.String(),
+			StatusCode: resp.StatusCode,
+			Header:     resp.Header,
+			Body:       body,
+		}
 	}
+	return resp, nil
+}
 
-	if !isSuccess(resp.StatusCode) {
-		return nil, errors.Errorf("[httpClient] status code is %d", resp.StatusCode)
+func (c *Client) send(ctx context.Context, method, url, contentType string, body []byte, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
 	}
-	body, err := ioutil.ReadAll(resp.Body)
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
 	if err != nil {
-		return nil, errors.Wrapf(err, "[httpClient] read resp body from [%s %s] err", method, url)
+		return errors.Wrapf(err, "[httpClient] get req err")
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := c.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	return decode(resp, out)
+}
+
+func (c *Client) doWithRetry(ctx context.Context, req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+
+		resp, err := c.roundTrip(req)
+		if attempt >= c.opts.maxRetries || !c.retryable(req, resp, err) {
+			return resp, err
+		}
+		if resp != nil {
+			_, _ = io.Copy(ioutil.Discard, resp.Body)
+			_ = resp.Body.Close()
+		}
+
+		timer := time.NewTimer(c.backoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// roundTrip send the request through the circuit breaker of the host
+func (c *Client) roundTrip(req *http.Request) (*http.Response, error) {
+	if c.opts.breakers == nil {
+		return c.client.Do(req)
+	}
+
+	breaker := c.opts.breakers.Get(req.URL.Host).(circuitbreaker.CircuitBreaker)
+	if err := breaker.Allow(); err != nil {
+		// NOTE: when client reject requests locally,
+		// continue add counter let the drop ratio higher.
+		breaker.MarkFailed()
+		return nil, ErrNotAllowed
+	}
+	resp, err := c.client.Do(req)
+	// NOTE: need to check internal and service unavailable error
+	if err != nil || resp.StatusCode == http.StatusInternalServerError ||
+		resp.StatusCode == http.StatusServiceUnavailable || resp.StatusCode == http.StatusGatewayTimeout {
+		breaker.MarkFailed()
+	} else {
+		breaker.MarkSuccess()
+	}
+	return resp, err
+}
+
+// retryable only the idempotent requests are retried
+func (c *Client) retryable(req *http.Request, resp *http.Response, err error) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete, http.MethodTrace:
+	default:
+		return false
+	}
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	if err != nil {
+		return !errors.Is(err, ErrNotAllowed) && req.Context().Err() == nil
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// backoff exponential backoff with jitter
+func (c *Client) backoff(attempt int) time.Duration {
+	d := c.opts.minBackoff << uint(attempt)
+	if d <= 0 || d > c.opts.maxBackoff {
+		d = c.opts.maxBackoff
+	}
+	if d <= 0 {
+		return 0
+	}
+	// full jitter in [d/2, d)
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// encode the request body, []byte and json.RawMessage are sent as it is
+func encode(in interface{}) ([]byte, error) {
+	switch v := in.(type) {
+	case nil:
+		return nil, nil
+	case []byte:
+		return v, nil
+	case json.RawMessage:
+		return v, nil
+	default:
+		body, err := json.Marshal(in)
+		if err != nil {
+			return nil, errors.Wrapf(err, "[httpClient] marshal req body err")
+		}
+		return body, nil
+	}
+}
+
+// decode the response body into out, *[]byte receives the raw body
+func decode(resp *http.Response, out interface{}) error {
+	switch v := out.(type) {
+	case nil:
+		_, err := io.Copy(ioutil.Discard, resp.Body)
+		return err
+	case *[]byte:
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return errors.Wrapf(err, "[httpClient] read resp body err")
+		}
+		*v = body
+		return nil
+	default:
+		err := json.NewDecoder(resp.Body).Decode(out)
+		if err != nil && err != io.EOF {
+			return errors.Wrapf(err, "[httpClient] decode resp body err")
+		}
+		return nil
 	}
-	return body, nil
 }
 
 // isSuccess check is success
@@ -3,8 +3,14 @@ package httpclient
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -71,3 +77,122 @@ func TestHttpClient(t *testing.T) {
 		assert.NotEmpty(t, r.Form)
 	})
 }
+
+func TestClient_Decode(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, _ := r.BasicAuth()
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"method": r.Method,
+			"tags":   r.Header.Values("X-Tag"),
+			"user":   user + ":" + pass,
+		})
+	}))
+	defer srv.Close()
+
+	c := New(WithHeader("X-Tag", "a"), WithHeader("X-Tag", "b"), WithBasicAuth("eagle", "secret"))
+
+	var out struct {
+		Method string   `json:"method"`
+		Tags   []string `json:"tags"`
+		User   string   `json:"user"`
+	}
+	assert.NoError(t, c.Get(context.Background(), srv.URL, &out))
+	assert.Equal(t, http.MethodGet, out.Method)
+	assert.Equal(t, []string{"a", "b"}, out.Tags)
+	assert.Equal(t, "eagle:secret", out.User)
+
+	assert.NoError(t, c.Post(context.Background(), srv.URL, map[string]string{"k": "v"}, &out))
+	assert.Equal(t, http.MethodPost, out.Method)
+
+	var raw []byte
+	assert.NoError(t, c.Put(context.Background(), srv.URL, nil, &raw))
+	assert.Contains(t, string(raw), `"method":"PUT"`)
+}
+
+func TestClient_StatusError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte("not found"))
+	}))
+	defer srv.Close()
+
+	err := New().Get(context.Background(), srv.URL, nil)
+	se, ok := IsStatusError(err)
+	if assert.True(t, ok) {
+		assert.Equal(t, http.StatusNotFound, se.StatusCode)
+		assert.Equal(t, "not found", string(se.Body))
+	}
+}
+
+func TestClient_Retry(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write(body)
+	}))
+	defer srv.Close()
+
+	c := New(WithRetry(3, time.Millisecond, 10*time.Millisecond))
+
+	// idempotent requests are retried with the same body
+	var raw []byte
+	assert.NoError(t, c.Put(context.Background(), srv.URL, []byte("payload"), &raw))
+	assert.Equal(t, "payload", string(raw))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+
+	// post is not retried
+	atomic.StoreInt32(&calls, 0)
+	err := c.Post(context.Background(), srv.URL, []byte("payload"), nil)
+	_, ok := IsStatusError(err)
+	assert.True(t, ok)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestClient_Breaker(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	c := New(WithBreaker())
+	var rejected bool
+	for i := 0; i < 200; i++ {
+		err := c.Get(context.Background(), srv.URL, nil)
+		if errors.Is(err, ErrNotAllowed) {
+			rejected = true
+		}
+	}
+	assert.True(t, rejected)
+	assert.True(t, atomic.LoadInt32(&calls) < 200)
+}
+
+func TestClient_Hooks(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Trace", r.Header.Get("X-Trace"))
+	}))
+	defer srv.Close()
+
+	var got string
+	c := New(
+		WithRequestHook(func(req *http.Request) error {
+			req.Header.Set("X-Trace", "abc")
+			return nil
+		}),
+		WithResponseHook(func(req *http.Request, resp *http.Response) error {
+			got = resp.Header.Get("X-Trace")
+			return nil
+		}),
+	)
+	assert.NoError(t, c.Get(context.Background(), srv.URL, nil))
+	assert.Equal(t, "abc", got)
+
+	hookErr := errors.New("abort")
+	err := New(WithRequestHook(func(req *http.Request) error { return hookErr })).Get(context.Background(), srv.URL, nil)
+	assert.Equal(t, hookErr, err)
+}
@@ -0,0 +1,34 @@
+package httpclient
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrNotAllowed is request failed due to circuit breaker triggered.
+var ErrNotAllowed = errors.New("[httpClient] request failed due to circuit breaker is open")
+
+// StatusError is returned when the status code of the response is not success
+type StatusError struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// Error implements error
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("[httpClient] request [%s %s] status code is %d, body: %s",
+		e.Method, e.URL, e.StatusCode, e.Body)
+}
+
+// IsStatusError return the StatusError if err is caused by a non-success status code
+func IsStatusError(err error) (*StatusError, bool) {
+	var se *StatusError
+	if errors.As(err, &se) {
+		return se, true
+	}
+	return nil, false
+}
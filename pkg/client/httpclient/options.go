@@ -1,21 +1,54 @@
 package httpclient
 
-import "time"
+import (
+	"crypto/tls"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/go-kratos/aegis/circuitbreaker/sre"
+
+	"github.com/go-eagle/eagle/pkg/container/group"
+)
 
 // Option is a function that sets some option on the client.
 type Option func(c *options)
 
+// RequestHook is called before a request is sent, return an error to abort the request
+type RequestHook func(req *http.Request) error
+
+// ResponseHook is called after a response is received, return an error to fail the request
+type ResponseHook func(req *http.Request, resp *http.Response) error
+
 // Options control behavior of the client.
 type options struct {
-	header map[string][]string
+	header http.Header
 	// timeout of per request
 	timeout time.Duration
+	// transport of the client, eg: a discovery transport
+	transport http.RoundTripper
+	// tlsConfig is set on the transport
+	tlsConfig *tls.Config
+
+	// retry the idempotent requests
+	maxRetries int
+	minBackoff time.Duration
+	maxBackoff time.Duration
+
+	// circuit breakers by host, nil means disabled
+	breakers *group.Group
+
+	requestHooks  []RequestHook
+	responseHooks []ResponseHook
 }
 
 func defaultOptions() *options {
 	return &options{
-		header:  make(map[string][]string),
-		timeout: DefaultTimeout,
+		header:     make(http.Header),
+		timeout:    DefaultTimeout,
+		transport:  http.DefaultTransport,
+		minBackoff: 100 * time.Millisecond,
+		maxBackoff: 2 * time.Second,
 	}
 }
 
@@ -25,3 +58,98 @@ func WithTimeout(duration time.Duration) Option {
 		cfg.timeout = duration
 	}
 }
+
+// WithTransport with a transport, eg: NewTransport(discovery) to request discovery://service/path
+func WithTransport(transport http.RoundTripper) Option {
+	return func(cfg *options) {
+		cfg.transport = transport
+	}
+}
+
+// WithTLSConfig with the tls config of the transport, eg: tlsconfig.NewClientConfig,
+// it's set on the transport of WithTransport whatever the order is:
+// a *http.Transport is cloned with it, the base of a discovery Transport is set to use it.
+func WithTLSConfig(c *tls.Config) Option {
+	return func(cfg *options) {
+		cfg.tlsConfig = c
+	}
+}
+
+// withTLSConfig set the tls config on the transport
+func withTLSConfig(rt http.RoundTripper, c *tls.Config) http.RoundTripper {
+	switch t := rt.(type) {
+	case *http.Transport:
+		// NOTE: don't change the shared one, eg: http.DefaultTransport
+		t = t.Clone()
+		t.TLSClientConfig = c
+		return t
+	case *Transport:
+		t.setBase(withTLSConfig(t.getBase(), c))
+		return t
+	}
+	log.Printf("[httpClient] tls config is not applied to the transport %T", rt)
+	return rt
+}
+
+// WithHeader add a header value for every request
+func WithHeader(key, value string) Option {
+	return func(cfg *options) {
+		cfg.header.Add(key, value)
+	}
+}
+
+// WithBasicAuth set the basic auth for every request
+func WithBasicAuth(username, password string) Option {
+	return func(cfg *options) {
+		cfg.requestHooks = append(cfg.requestHooks, func(req *http.Request) error {
+			req.SetBasicAuth(username, password)
+			return nil
+		})
+	}
+}
+
+// WithBearerToken set the bearer token for every request
+func WithBearerToken(token string) Option {
+	return func(cfg *options) {
+		cfg.header.Set("Authorization", "Bearer "+token)
+	}
+}
+
+// WithRetry retry the idempotent requests on network errors and 429/502/503/504 responses,
+// the backoff grows exponentially from minBackoff to maxBackoff
+func WithRetry(maxRetries int, minBackoff, maxBackoff time.Duration) Option {
+	return func(cfg *options) {
+		cfg.maxRetries = maxRetries
+		cfg.minBackoff = minBackoff
+		cfg.maxBackoff = maxBackoff
+	}
+}
+
+// WithBreaker enable a circuit breaker per host
+func WithBreaker() Option {
+	return WithBreakerGroup(group.NewGroup(func() interface{} {
+		return sre.NewBreaker()
+	}))
+}
+
+// WithBreakerGroup enable the circuit breakers per host with a group.
+// NOTE: implements generics circuitbreaker.CircuitBreaker
+func WithBreakerGroup(g *group.Group) Option {
+	return func(cfg *options) {
+		cfg.breakers = g
+	}
+}
+
+// WithRequestHook add hooks which are called before a request is sent
+func WithRequestHook(hooks ...RequestHook) Option {
+	return func(cfg *options) {
+		cfg.requestHooks = append(cfg.requestHooks, hooks...)
+	}
+}
+
+// WithResponseHook add hooks which are called after a response is received
+func WithResponseHook(hooks ...ResponseHook) Option {
+	return func(cfg *options) {
+		cfg.responseHooks = append(cfg.responseHooks, hooks...)
+	}
+}
@@ -0,0 +1,299 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-eagle/eagle/pkg/registry"
+)
+
+// Scheme the scheme resolved by registry.Discovery, eg: discovery://helloworld/v1/users
+const Scheme = "discovery"
+
+// TransportOption is a function that sets some option on the transport.
+type TransportOption func(t *Transport)
+
+// WithBase set the underlying round tripper, default is http.DefaultTransport
+func WithBase(base http.RoundTripper) TransportOption {
+	return func(t *Transport) {
+		t.base = base
+	}
+}
+
+// WithBalancer set the balancer, default is round robin
+func WithBalancer(b Balancer) TransportOption {
+	return func(t *Transport) {
+		t.balancer = b
+	}
+}
+
+// WithOutlierDetection eject a node for ejection time after consecutive failures,
+// a failure is a transport error or a 5xx response
+func WithOutlierDetection(consecutiveFailures int, ejection time.Duration) TransportOption {
+	return func(t *Transport) {
+		t.maxFailures = int64(consecutiveFailures)
+		t.ejection = ejection
+	}
+}
+
+// WithResolveTimeout the max time to wait for the first instances of a service
+func WithResolveTimeout(timeout time.Duration) TransportOption {
+	return func(t *Transport) {
+		t.resolveTimeout = timeout
+	}
+}
+
+// Transport is a http.RoundTripper which resolves discovery://service/path by registry.Discovery,
+// and balances the requests across the instances. other schemes are sent by the base transport.
+type Transport struct {
+	discovery      registry.Discovery
+	base           http.RoundTripper
+	balancer       Balancer
+	maxFailures    int64
+	ejection       time.Duration
+	resolveTimeout time.Duration
+
+	mu        sync.Mutex
+	resolvers map[string]*resolver
+	ctx       context.Context
+	cancel    context.CancelFunc
+}
+
+// NewTransport create a discovery transport, it should be reused
+func NewTransport(d registry.Discovery, opts ...TransportOption) *Transport {
+	t := &Transport{
+		discovery:      d,
+		base:           http.DefaultTransport,
+		balancer:       NewRoundRobin(),
+		maxFailures:    5,
+		ejection:       30 * time.Second,
+		resolveTimeout: 3 * time.Second,
+		resolvers:      make(map[string]*resolver),
+	}
+	for _, o := range opts {
+		o(t)
+	}
+	t.ctx, t.cancel = context.WithCancel(context.Background())
+	return t
+}
+
+func (t *Transport) getBase() http.RoundTripper {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.base
+}
+
+// setBase replace the base, eg: by httpclient.WithTLSConfig
+func (t *Transport) setBase(base http.RoundTripper) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.base = base
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Scheme != Scheme {
+		return t.getBase().RoundTrip(req)
+	}
+
+	r, err := t.resolver(req.URL.Host)
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := r.wait(req.Context(), t.resolveTimeout)
+	if err != nil {
+		return nil, err
+	}
+	node, err := t.balancer.Pick(t.available(t.route(req, nodes)))
+	if err != nil {
+		return nil, err
+	}
+
+	// send to the picked node
+	out := req.Clone(req.Context())
+	out.URL.Scheme = node.scheme
+	out.URL.Host = node.addr
+	out.Host = ""
+
+	done := node.start()
+	resp, err := t.getBase().RoundTrip(out)
+	success := err == nil && resp.StatusCode < http.StatusInternalServerError
+	done(success)
+	if !success && t.maxFailures > 0 && atomic.LoadInt64(&node.failures) >= t.maxFailures {
+		atomic.StoreInt64(&node.ejectedUntil, time.Now().Add(t.ejection).UnixNano())
+		atomic.StoreInt64(&node.failures, 0)
+	}
+	return resp, err
+}
+
+// Close stop watching the services
+func (t *Transport) Close() error {
+	t.cancel()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for name, r := range t.resolvers {
+		if err := r.watcher.Stop(); err != nil {
+			log.Printf("[httpClient] stop watcher of %s err: %v", name, err)
+		}
+		delete(t.resolvers, name)
+	}
+	return nil
+}
+
+// route select the nodes matching the route of the request, see registry.Route
+func (t *Transport) route(req *http.Request, nodes []*Node) []*Node {
+	ins := make([]*registry.ServiceInstance, 0, len(nodes))
+	seen := make(map[*registry.ServiceInstance]bool, len(nodes))
+	for _, n := range nodes {
+		if in := n.Instance(); !seen[in] {
+			seen[in] = true
+			ins = append(ins, in)
+		}
+	}
+
+	matched := make(map[*registry.ServiceInstance]bool, len(ins))
+	for _, in := range routeOf(req).Filter(ins) {
+		matched[in] = true
+	}
+	items := make([]*Node, 0, len(nodes))
+	for _, n := range nodes {
+		if matched[n.Instance()] {
+			items = append(items, n)
+		}
+	}
+	return items
+}
+
+// routeOf the route of the request, the headers take precedence over the context
+func routeOf(req *http.Request) registry.Route {
+	r, _ := registry.RouteFromContext(req.Context())
+	if color := req.Header.Get(registry.HeaderColor); color != "" {
+		r.Color = color
+	}
+	if zone := req.Header.Get(registry.HeaderZone); zone != "" {
+		r.Zone = zone
+	}
+	return r
+}
+
+// available filter out the ejected nodes, all the nodes are used if all of them are ejected
+func (t *Transport) available(nodes []*Node) []*Node {
+	now := time.Now().UnixNano()
+	items := make([]*Node, 0, len(nodes))
+	for _, n := range nodes {
+		if atomic.LoadInt64(&n.ejectedUntil) <= now {
+			items = append(items, n)
+		}
+	}
+	if len(items) == 0 {
+		return nodes
+	}
+	return items
+}
+
+func (t *Transport) resolver(name string) (*resolver, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if r, ok := t.resolvers[name]; ok {
+		return r, nil
+	}
+	if t.ctx.Err() != nil {
+		return nil, errors.New("[httpClient] transport is closed")
+	}
+	w, err := t.discovery.Watch(t.ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	r := &resolver{
+		name:    name,
+		watcher: w,
+		ready:   make(chan struct{}),
+	}
+	go r.watch(t.ctx)
+	t.resolvers[name] = r
+	return r, nil
+}
+
+// resolver keep the nodes of a service up to date by the watcher
+type resolver struct {
+	name    string
+	watcher registry.Watcher
+	nodes   atomic.Value
+	ready   chan struct{}
+	once    sync.Once
+}
+
+func (r *resolver) watch(ctx context.Context) {
+	for {
+		ins, err := r.watcher.Next()
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			log.Printf("[httpClient] watch service %s err: %v", r.name, err)
+			time.Sleep(time.Second)
+			continue
+		}
+		r.update(ins)
+	}
+}
+
+func (r *resolver) update(ins []*registry.ServiceInstance) {
+	// keep the stats of the existing nodes
+	old := make(map[string]*Node)
+	if nodes, ok := r.nodes.Load().([]*Node); ok {
+		for _, n := range nodes {
+			old[n.scheme+"://"+n.addr] = n
+		}
+	}
+
+	nodes := make([]*Node, 0, len(ins))
+	for _, in := range ins {
+		for _, e := range in.Endpoints {
+			u, err := url.Parse(e)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				continue
+			}
+			n := newNode(u, in)
+			if o, ok := old[u.Scheme+"://"+u.Host]; ok && o.weight == n.weight {
+				o.instance.Store(in)
+				n = o
+			}
+			nodes = append(nodes, n)
+		}
+	}
+	r.nodes.Store(nodes)
+	r.once.Do(func() {
+		close(r.ready)
+	})
+}
+
+// wait for the first instances of the service
+func (r *resolver) wait(ctx context.Context, timeout time.Duration) ([]*Node, error) {
+	select {
+	case <-r.ready:
+	default:
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		select {
+		case <-r.ready:
+		case <-timer.C:
+			return nil, ErrNoAvailable
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	nodes, _ := r.nodes.Load().([]*Node)
+	if len(nodes) == 0 {
+		return nil, ErrNoAvailable
+	}
+	return nodes, nil
+}
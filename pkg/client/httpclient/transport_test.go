@@ -0,0 +1,182 @@
+package httpclient
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/go-eagle/eagle/pkg/registry"
+	"github.com/go-eagle/eagle/pkg/registry/memory"
+)
+
+func newTestServer(t *testing.T, name string, status int) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(name + r.URL.Path))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func get(t *testing.T, c *http.Client, url string) (int, string) {
+	resp, err := c.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := ioutil.ReadAll(resp.Body)
+	return resp.StatusCode, string(body)
+}
+
+func TestTransport(t *testing.T) {
+	ctx := context.Background()
+	s1 := newTestServer(t, "s1", http.StatusOK)
+	s2 := newTestServer(t, "s2", http.StatusOK)
+
+	r := memory.New()
+	assert.NoError(t, r.Register(ctx, &registry.ServiceInstance{
+		ID: "1", Name: "helloworld", Endpoints: []string{"grpc://127.0.0.1:9000", s1.URL},
+	}))
+	assert.NoError(t, r.Register(ctx, &registry.ServiceInstance{
+		ID: "2", Name: "helloworld", Endpoints: []string{s2.URL},
+	}))
+
+	tr := NewTransport(r)
+	defer tr.Close()
+	c := &http.Client{Transport: tr}
+
+	got := make(map[string]int)
+	for i := 0; i < 4; i++ {
+		status, body := get(t, c, "discovery://helloworld/v1/users")
+		assert.Equal(t, http.StatusOK, status)
+		got[body]++
+	}
+	assert.Equal(t, map[string]int{"s1/v1/users": 2, "s2/v1/users": 2}, got)
+
+	// follow the instance changes
+	assert.NoError(t, r.Deregister(ctx, &registry.ServiceInstance{ID: "1", Name: "helloworld"}))
+	assert.Eventually(t, func() bool {
+		_, body := get(t, c, "discovery://helloworld/")
+		_, body2 := get(t, c, "discovery://helloworld/")
+		return body == "s2/" && body2 == "s2/"
+	}, time.Second, 10*time.Millisecond)
+
+	// other schemes are sent directly
+	_, body := get(t, c, s1.URL+"/direct")
+	assert.Equal(t, "s1/direct", body)
+
+	// unknown service
+	tr2 := NewTransport(r, WithResolveTimeout(10*time.Millisecond))
+	defer tr2.Close()
+	_, err := (&http.Client{Transport: tr2}).Get("discovery://unknown/")
+	assert.Error(t, err)
+}
+
+func TestTransport_OutlierDetection(t *testing.T) {
+	ctx := context.Background()
+	ok := newTestServer(t, "ok", http.StatusOK)
+	bad := newTestServer(t, "bad", http.StatusInternalServerError)
+
+	r := memory.New()
+	assert.NoError(t, r.Register(ctx, &registry.ServiceInstance{ID: "1", Name: "helloworld", Endpoints: []string{ok.URL}}))
+	assert.NoError(t, r.Register(ctx, &registry.ServiceInstance{ID: "2", Name: "helloworld", Endpoints: []string{bad.URL}}))
+
+	tr := NewTransport(r, WithOutlierDetection(2, time.Minute))
+	defer tr.Close()
+	c := &http.Client{Transport: tr}
+
+	// the bad node is ejected after 2 consecutive failures
+	failures := 0
+	for i := 0; i < 10; i++ {
+		status, _ := get(t, c, "discovery://helloworld/")
+		if status != http.StatusOK {
+			failures++
+		}
+	}
+	assert.Equal(t, 2, failures)
+}
+
+func TestTransport_Route(t *testing.T) {
+	ctx := context.Background()
+	stable := newTestServer(t, "stable", http.StatusOK)
+	canary := newTestServer(t, "canary", http.StatusOK)
+
+	r := memory.New()
+	assert.NoError(t, r.Register(ctx, &registry.ServiceInstance{
+		ID: "1", Name: "helloworld", Endpoints: []string{stable.URL},
+	}))
+	assert.NoError(t, r.Register(ctx, &registry.ServiceInstance{
+		ID: "2", Name: "helloworld", Endpoints: []string{canary.URL},
+		Metadata: map[string]string{registry.MetadataColor: "canary"},
+	}))
+
+	tr := NewTransport(r)
+	defer tr.Close()
+	c := New(WithTransport(tr))
+
+	send := func(ctx context.Context, header string) string {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, "discovery://helloworld/", nil)
+		assert.NoError(t, err)
+		if header != "" {
+			req.Header.Set(registry.HeaderColor, header)
+		}
+		resp, err := c.Do(req)
+		if !assert.NoError(t, err) {
+			return ""
+		}
+		defer resp.Body.Close()
+		body, _ := ioutil.ReadAll(resp.Body)
+		return string(body)
+	}
+
+	for i := 0; i < 3; i++ {
+		// the canary instance is out of the default pool
+		assert.Equal(t, "stable/", send(ctx, ""))
+		assert.Equal(t, "canary/", send(ctx, "canary"))
+		// unknown color fallback to the default pool
+		assert.Equal(t, "stable/", send(ctx, "blue"))
+		// the hints in the context are propagated by the header
+		assert.Equal(t, "canary/", send(registry.NewRouteContext(ctx, registry.Route{Color: "canary"}), ""))
+	}
+}
+
+func TestTransport_TLSConfig(t *testing.T) {
+	ctx := context.Background()
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"name":"tls"}`))
+	}))
+	defer srv.Close()
+	tlsConf := srv.Client().Transport.(*http.Transport).TLSClientConfig
+
+	r := memory.New()
+	assert.NoError(t, r.Register(ctx, &registry.ServiceInstance{
+		ID: "1", Name: "helloworld", Endpoints: []string{srv.URL},
+	}))
+
+	// the tls config is set on the discovery transport whatever the order is
+	for name, opts := range map[string]func(tr *Transport) []Option{
+		"tls first": func(tr *Transport) []Option { return []Option{WithTLSConfig(tlsConf), WithTransport(tr)} },
+		"tls last":  func(tr *Transport) []Option { return []Option{WithTransport(tr), WithTLSConfig(tlsConf)} },
+	} {
+		t.Run(name, func(t *testing.T) {
+			tr := NewTransport(r)
+			defer tr.Close()
+
+			var out struct {
+				Name string `json:"name"`
+			}
+			assert.NoError(t, New(opts(tr)...).Get(ctx, "discovery://helloworld/", &out))
+			assert.Equal(t, "tls", out.Name)
+		})
+	}
+
+	// the default transport is not changed
+	if c := http.DefaultTransport.(*http.Transport).TLSClientConfig; c != nil {
+		assert.Nil(t, c.RootCAs)
+	}
+}
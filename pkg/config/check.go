@@ -0,0 +1,81 @@
+package config
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// Check alias for config func.
+func Check(schemas map[string]interface{}) []error { return conf.Check(schemas) }
+
+// Check validate all the config files of the env strictly, eg: before deploy.
+// the files in schemas are decoded into the new values of the types, eg: {"app": app.Config{}},
+// the other files in the directory are only parsed, all the errors are returned.
+func (c *Config) Check(schemas map[string]interface{}) []error {
+	files, err := c.files()
+	if err != nil {
+		return []error{err}
+	}
+	for filename := range schemas {
+		if _, ok := files[filename]; !ok {
+			files[filename] = c.configType
+		}
+	}
+	names := make([]string, 0, len(files))
+	for filename := range files {
+		names = append(names, filename)
+	}
+	sort.Strings(names)
+
+	var errs []error
+	for _, filename := range names {
+		v, err := c.read(filename, files[filename])
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		schema, ok := schemas[filename]
+		if !ok {
+			continue
+		}
+		typ := reflect.TypeOf(schema)
+		for typ.Kind() == reflect.Ptr {
+			typ = typ.Elem()
+		}
+		if err := decode(filename, v, reflect.New(typ).Interface(), true); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// files the config files in the directory of the env by the types
+func (c *Config) files() (map[string]string, error) {
+	entries, err := ioutil.ReadDir(c.path())
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string]string)
+	for _, e := range entries {
+		ext := strings.TrimPrefix(filepath.Ext(e.Name()), ".")
+		if e.IsDir() || !supported(ext) {
+			continue
+		}
+		files[strings.TrimSuffix(e.Name(), "."+ext)] = ext
+	}
+	return files, nil
+}
+
+func supported(ext string) bool {
+	for _, e := range viper.SupportedExts {
+		if e == ext {
+			return true
+		}
+	}
+	return false
+}
@@ -2,12 +2,12 @@ package config
 
 import (
 	"errors"
+	"fmt"
 	"log"
 	"os"
 	"path/filepath"
 	"sync"
 
-	"github.com/fsnotify/fsnotify"
 	"github.com/spf13/viper"
 )
 
@@ -26,8 +26,15 @@ type Config struct {
 	env        string
 	configDir  string
 	configType string // file type, eg: yaml, json, toml, default is yaml
+	defaults   mapSource
+	remotes    []Source
+	envSource  Source
+	strict     bool // reject the unknown keys
 	val        map[string]*viper.Viper
+	watchers   map[string][]func(v *viper.Viper)
+	stops      []func()
 	mu         sync.Mutex
+	reloadMu   sync.Mutex
 }
 
 // New create a config instance.
@@ -39,7 +46,10 @@ func New(cfgDir string, opts ...Option) *Config {
 	c := Config{
 		configDir:  cfgDir,
 		configType: fileTypeYaml,
+		defaults:   make(mapSource),
+		envSource:  NewEnvSource(DefaultEnvPrefix),
 		val:        make(map[string]*viper.Viper),
+		watchers:   make(map[string][]func(v *viper.Viper)),
 	}
 	for _, opt := range opts {
 		opt(&c)
@@ -53,17 +63,13 @@ func New(cfgDir string, opts ...Option) *Config {
 // Load alias for config func.
 func Load(filename string, val interface{}) error { return conf.Load(filename, val) }
 
-// Load scan data to struct.
+// Load scan data to struct, it's validated by the validate tags, see Validate.
 func (c *Config) Load(filename string, val interface{}) error {
 	v, err := c.LoadWithType(filename, c.configType)
 	if err != nil {
 		return err
 	}
-	err = v.Unmarshal(&val)
-	if err != nil {
-		return err
-	}
-	return nil
+	return decode(filename, v, val, c.strict)
 }
 
 // LoadJson alias for config func.
@@ -75,11 +81,7 @@ func (c *Config) LoadJson(filename string, val interface{}) error {
 	if err != nil {
 		return err
 	}
-	err = v.Unmarshal(&val)
-	if err != nil {
-		return err
-	}
-	return nil
+	return decode(filename, v, val, c.strict)
 }
 
 // LoadToml alias for config func.
@@ -91,11 +93,7 @@ func (c *Config) LoadToml(filename string, val interface{}) error {
 	if err != nil {
 		return err
 	}
-	err = v.Unmarshal(&val)
-	if err != nil {
-		return err
-	}
-	return nil
+	return decode(filename, v, val, c.strict)
 }
 
 // LoadWithType load conf by file type.
@@ -120,36 +118,118 @@ func (c *Config) LoadWithType(filename string, cfgType string) (v *viper.Viper,
 	return v, nil
 }
 
-// Load load file.
+// load merge the configs of the sources, then watch the changes of them.
 func (c *Config) load(filename string, cfgType string) (*viper.Viper, error) {
+	if cfgType == "" {
+		cfgType = c.configType
+	}
+	v, err := c.read(filename, cfgType)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, s := range c.sources(cfgType) {
+		w, ok := s.(Watcher)
+		if !ok {
+			continue
+		}
+		stop, err := w.Watch(filename, func() {
+			c.reload(filename, cfgType)
+		})
+		if err != nil {
+			log.Printf("Config %s watch err: %v", filename, err)
+			continue
+		}
+		c.stops = append(c.stops, stop)
+	}
+
+	return v, nil
+}
+
+// sources the sources by precedence: defaults < file < remote < env
+func (c *Config) sources(cfgType string) []Source {
+	sources := []Source{c.defaults, NewFileSource(c.path(), cfgType)}
+	sources = append(sources, c.remotes...)
+	if c.envSource != nil {
+		sources = append(sources, c.envSource)
+	}
+	return sources
+}
+
+// path the directory of the config files of the env
+func (c *Config) path() string {
 	// application parameters take precedence over environment variables
 	env := GetEnvString("APP_ENV", "")
 	path := filepath.Join(c.configDir, env)
 	if c.env != "" {
 		path = filepath.Join(c.configDir, c.env)
 	}
+	return path
+}
 
-	v := viper.New()
-	v.AddConfigPath(path)
-	v.SetConfigName(filename)
-	v.SetConfigType(c.configType)
-	if cfgType != "" {
-		v.SetConfigType(cfgType)
+// read merge the configs of the file in the sources
+func (c *Config) read(filename string, cfgType string) (*viper.Viper, error) {
+	merged := make(map[string]interface{})
+	found := false
+	for _, s := range c.sources(cfgType) {
+		if env, ok := s.(*envSource); ok {
+			// the keys are matched against the merged configs
+			if env.merge(merged, filename) {
+				found = true
+			}
+			continue
+		}
+		val, err := s.Load(filename)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("config %s: %w", filename, err)
+		}
+		mergeMap(merged, val)
+		found = true
+	}
+	if !found {
+		return nil, fmt.Errorf("config %s: %w", filename, ErrNotFound)
+	}
+	if err := resolve(merged, ""); err != nil {
+		return nil, fmt.Errorf("config %s: %w", filename, err)
 	}
 
-	if err := v.ReadInConfig(); err != nil {
-		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
-			return nil, errors.New("config file not found")
-		}
+	v := viper.New()
+	if err := v.MergeConfigMap(merged); err != nil {
 		return nil, err
 	}
+	return v, nil
+}
 
-	v.WatchConfig()
-	v.OnConfigChange(func(e fsnotify.Event) {
-		log.Printf("Config file changed: %s", e.Name)
-	})
+// reload read the file again after one of the sources is changed, then notify the watchers
+func (c *Config) reload(filename string, cfgType string) {
+	c.reloadMu.Lock()
+	defer c.reloadMu.Unlock()
 
-	return v, nil
+	v, err := c.read(filename, cfgType)
+	if err != nil {
+		log.Printf("Config %s reload err: %v", filename, err)
+		return
+	}
+	c.mu.Lock()
+	c.val[filename] = v
+	c.mu.Unlock()
+
+	c.notify(filename, v)
+}
+
+// Close stop watching the sources
+func (c *Config) Close() {
+	c.mu.Lock()
+	stops := c.stops
+	c.stops = nil
+	c.mu.Unlock()
+
+	for _, stop := range stops {
+		stop()
+	}
 }
 
 // GetEnvString get value from env.
@@ -0,0 +1,117 @@
+// Package consul provides a config source of the consul kv.
+package consul
+
+import (
+	"context"
+	"log"
+	"path"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+
+	"github.com/go-eagle/eagle/pkg/config"
+)
+
+// Option is consul source option.
+type Option func(o *options)
+
+type options struct {
+	prefix   string
+	cfgType  string
+	waitTime time.Duration
+}
+
+// WithPrefix the prefix of the keys, default is eagle/config,
+// eg: eagle/config/prod, the config of app is in eagle/config/prod/app
+func WithPrefix(prefix string) Option {
+	return func(o *options) { o.prefix = prefix }
+}
+
+// WithType the type of the values, eg: yaml, json, toml, default is yaml
+func WithType(cfgType string) Option {
+	return func(o *options) { o.cfgType = cfgType }
+}
+
+// WithWaitTime the max wait time of the blocking queries, default is 55s
+func WithWaitTime(d time.Duration) Option {
+	return func(o *options) { o.waitTime = d }
+}
+
+// Source the configs stored in the consul kv, a key holds the content of a file
+type Source struct {
+	opts   *options
+	client *api.Client
+}
+
+// New create a consul kv source
+func New(client *api.Client, opts ...Option) *Source {
+	o := &options{
+		prefix:   "eagle/config",
+		cfgType:  "yaml",
+		waitTime: 55 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return &Source{opts: o, client: client}
+}
+
+func (s *Source) key(filename string) string {
+	return path.Join(s.opts.prefix, filename)
+}
+
+// Load the config of the file
+func (s *Source) Load(filename string) (map[string]interface{}, error) {
+	pair, _, err := s.client.KV().Get(s.key(filename), nil)
+	if err != nil {
+		return nil, err
+	}
+	if pair == nil {
+		return nil, config.ErrNotFound
+	}
+	return config.Parse(pair.Value, s.opts.cfgType)
+}
+
+// Watch call onChange when the key of the file is changed, it's implemented by the blocking queries
+func (s *Source) Watch(filename string, onChange func()) (func(), error) {
+	key := s.key(filename)
+	_, meta, err := s.client.KV().Get(key, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		index := meta.LastIndex
+		for {
+			opts := &api.QueryOptions{WaitIndex: index, WaitTime: s.opts.waitTime}
+			_, meta, err := s.client.KV().Get(key, opts.WithContext(ctx))
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				log.Printf("[consul] watch config %s err: %v", filename, err)
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(time.Second):
+				}
+				continue
+			}
+			switch {
+			case meta.LastIndex < index:
+				// NOTE: reset the index if it goes backwards, eg: the consul servers are restored
+				index = 0
+			case meta.LastIndex > index:
+				index = meta.LastIndex
+				onChange()
+			}
+		}
+	}()
+	return func() {
+		cancel()
+		<-done
+	}, nil
+}
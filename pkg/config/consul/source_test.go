@@ -0,0 +1,87 @@
+package consul
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/go-eagle/eagle/pkg/config"
+)
+
+// kvServer a fake consul kv, the blocking queries are answered after a short wait
+type kvServer struct {
+	mu    sync.Mutex
+	index uint64
+	kvs   map[string][]byte
+}
+
+func (s *kvServer) put(key, value string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.index++
+	s.kvs[key] = []byte(value)
+}
+
+func (s *kvServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if index := r.URL.Query().Get("index"); index != "" {
+		time.Sleep(20 * time.Millisecond)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := strings.TrimPrefix(r.URL.Path, "/v1/kv/")
+	w.Header().Set("X-Consul-Index", strconv.FormatUint(s.index, 10))
+	value, ok := s.kvs[key]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	_ = json.NewEncoder(w).Encode([]*api.KVPair{{Key: key, Value: value, ModifyIndex: s.index}})
+}
+
+func TestSource(t *testing.T) {
+	kv := &kvServer{index: 1, kvs: make(map[string][]byte)}
+	kv.put("eagle/config/test/app", "Name: eagle\nHTTP:\n  Addr: :8080\n")
+	srv := httptest.NewServer(kv)
+	defer srv.Close()
+
+	client, err := api.NewClient(&api.Config{Address: strings.TrimPrefix(srv.URL, "http://")})
+	require.NoError(t, err)
+	s := New(client, WithPrefix("eagle/config/test"))
+
+	val, err := s.Load("app")
+	require.NoError(t, err)
+	assert.Equal(t, "eagle", val["name"])
+
+	_, err = s.Load("database")
+	assert.ErrorIs(t, err, config.ErrNotFound)
+
+	changed := make(chan struct{}, 1)
+	stop, err := s.Watch("app", func() {
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	})
+	require.NoError(t, err)
+	defer stop()
+
+	kv.put("eagle/config/test/app", "Name: eagle-v2\n")
+	select {
+	case <-changed:
+		val, err := s.Load("app")
+		require.NoError(t, err)
+		assert.Equal(t, "eagle-v2", val["name"])
+	case <-time.After(3 * time.Second):
+		t.Fatal("the change is not watched")
+	}
+}
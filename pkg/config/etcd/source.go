@@ -0,0 +1,102 @@
+// Package etcd provides a config source of the etcd keys.
+package etcd
+
+import (
+	"context"
+	"log"
+	"path"
+	"sync"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"github.com/go-eagle/eagle/pkg/config"
+)
+
+// Option is etcd source option.
+type Option func(o *options)
+
+type options struct {
+	prefix  string
+	cfgType string
+	timeout time.Duration
+}
+
+// WithPrefix the prefix of the keys, default is /eagle/config,
+// eg: /eagle/config/prod, the config of app is in /eagle/config/prod/app
+func WithPrefix(prefix string) Option {
+	return func(o *options) { o.prefix = prefix }
+}
+
+// WithType the type of the values, eg: yaml, json, toml, default is yaml
+func WithType(cfgType string) Option {
+	return func(o *options) { o.cfgType = cfgType }
+}
+
+// WithTimeout the timeout of loading a config, default is 5s
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *options) { o.timeout = timeout }
+}
+
+// Source the configs stored in the etcd keys, a key holds the content of a file
+type Source struct {
+	opts   *options
+	client *clientv3.Client
+}
+
+// New create an etcd source
+func New(client *clientv3.Client, opts ...Option) *Source {
+	o := &options{
+		prefix:  "/eagle/config",
+		cfgType: "yaml",
+		timeout: 5 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return &Source{opts: o, client: client}
+}
+
+func (s *Source) key(filename string) string {
+	return path.Join(s.opts.prefix, filename)
+}
+
+// Load the config of the file
+func (s *Source) Load(filename string) (map[string]interface{}, error) {
+	// NOTE: don't hang the startup if etcd is unreachable
+	ctx, cancel := context.WithTimeout(context.Background(), s.opts.timeout)
+	defer cancel()
+	resp, err := s.client.Get(ctx, s.key(filename))
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Kvs) == 0 {
+		return nil, config.ErrNotFound
+	}
+	return config.Parse(resp.Kvs[0].Value, s.opts.cfgType)
+}
+
+// Watch call onChange when the key of the file is changed
+func (s *Source) Watch(filename string, onChange func()) (func(), error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := s.client.Watch(ctx, s.key(filename))
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for resp := range ch {
+			if err := resp.Err(); err != nil {
+				log.Printf("[etcd] watch config %s err: %v", filename, err)
+				continue
+			}
+			if len(resp.Events) > 0 {
+				onChange()
+			}
+		}
+	}()
+	return func() {
+		cancel()
+		wg.Wait()
+	}, nil
+}
@@ -0,0 +1,130 @@
+package etcd
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"github.com/go-eagle/eagle/pkg/config"
+)
+
+// fakeKV a fake etcd kv, Get blocks until ctx is done if it's unreachable
+type fakeKV struct {
+	clientv3.KV
+	mu          sync.Mutex
+	kvs         map[string]string
+	unreachable bool
+}
+
+func (kv *fakeKV) put(key, value string) {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	kv.kvs[key] = value
+}
+
+func (kv *fakeKV) Get(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.GetResponse, error) {
+	if kv.unreachable {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	resp := &clientv3.GetResponse{}
+	if value, ok := kv.kvs[key]; ok {
+		resp.Kvs = []*mvccpb.KeyValue{{Key: []byte(key), Value: []byte(value)}}
+	}
+	return resp, nil
+}
+
+// fakeWatcher a fake etcd watcher, the events are sent by notify
+type fakeWatcher struct {
+	clientv3.Watcher
+	mu       sync.Mutex
+	watchers map[string]chan clientv3.WatchResponse
+}
+
+func (w *fakeWatcher) Watch(ctx context.Context, key string, opts ...clientv3.OpOption) clientv3.WatchChan {
+	ch := make(chan clientv3.WatchResponse, 1)
+	w.mu.Lock()
+	w.watchers[key] = ch
+	w.mu.Unlock()
+	go func() {
+		<-ctx.Done()
+		w.mu.Lock()
+		delete(w.watchers, key)
+		close(ch)
+		w.mu.Unlock()
+	}()
+	return ch
+}
+
+func (w *fakeWatcher) notify(key string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	ch, ok := w.watchers[key]
+	if ok {
+		ch <- clientv3.WatchResponse{Events: []*clientv3.Event{{Type: clientv3.EventTypePut}}}
+	}
+	return ok
+}
+
+func newTestClient() (*clientv3.Client, *fakeKV, *fakeWatcher) {
+	kv := &fakeKV{kvs: make(map[string]string)}
+	w := &fakeWatcher{watchers: make(map[string]chan clientv3.WatchResponse)}
+	client := clientv3.NewCtxClient(context.Background())
+	client.KV = kv
+	client.Watcher = w
+	return client, kv, w
+}
+
+func TestSource(t *testing.T) {
+	client, kv, w := newTestClient()
+	kv.put("/eagle/config/test/app", "Name: eagle\nHTTP:\n  Addr: :8080\n")
+	s := New(client, WithPrefix("/eagle/config/test"))
+
+	val, err := s.Load("app")
+	require.NoError(t, err)
+	assert.Equal(t, "eagle", val["name"])
+	assert.Equal(t, map[string]interface{}{"addr": ":8080"}, val["http"])
+
+	_, err = s.Load("database")
+	assert.Equal(t, config.ErrNotFound, err)
+
+	// watch the changes
+	changed := make(chan struct{}, 1)
+	stop, err := s.Watch("app", func() {
+		changed <- struct{}{}
+	})
+	require.NoError(t, err)
+	kv.put("/eagle/config/test/app", "Name: eagle2\n")
+	assert.True(t, w.notify("/eagle/config/test/app"))
+	select {
+	case <-changed:
+	case <-time.After(time.Second):
+		t.Fatal("change is not notified")
+	}
+	val, err = s.Load("app")
+	require.NoError(t, err)
+	assert.Equal(t, "eagle2", val["name"])
+
+	// the watch is canceled after stop
+	stop()
+	assert.False(t, w.notify("/eagle/config/test/app"))
+}
+
+func TestSource_Timeout(t *testing.T) {
+	client, kv, _ := newTestClient()
+	kv.unreachable = true
+	s := New(client, WithTimeout(50*time.Millisecond))
+
+	start := time.Now()
+	_, err := s.Load("app")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, int64(time.Since(start)), int64(time.Second))
+}
@@ -16,3 +16,37 @@ func WithEnv(name string) Option {
 		c.env = name
 	}
 }
+
+// WithDefaults the default values of the file, eg: WithDefaults("app", map[string]interface{}{"Mode": "release"})
+func WithDefaults(filename string, defaults map[string]interface{}) Option {
+	return func(c *Config) {
+		c.defaults[filename] = defaults
+	}
+}
+
+// WithSource the remote sources, eg: etcd, consul,
+// they take precedence over the files and the former ones.
+func WithSource(sources ...Source) Option {
+	return func(c *Config) {
+		c.remotes = append(c.remotes, sources...)
+	}
+}
+
+// WithEnvPrefix override the configs by the environment variables with the prefix,
+// eg: EAGLE_DATABASE_PASSWORD for Password of database.yaml, see NewEnvSource.
+// default is DefaultEnvPrefix, empty prefix disables the overrides.
+func WithEnvPrefix(prefix string) Option {
+	return func(c *Config) {
+		c.envSource = nil
+		if prefix != "" {
+			c.envSource = NewEnvSource(prefix)
+		}
+	}
+}
+
+// WithStrict reject the keys which don't match any field of the config, eg: a typo
+func WithStrict() Option {
+	return func(c *Config) {
+		c.strict = true
+	}
+}
@@ -0,0 +1,102 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+)
+
+const (
+	// DefaultEnvPrefix the default prefix of the environment variables which override the configs
+	DefaultEnvPrefix = "EAGLE"
+
+	// SchemeFile the value is read from the file, eg: file:///run/secrets/db_password
+	SchemeFile = "file://"
+	// SchemeEnv the value is read from the environment variable, eg: env://DB_PASSWORD
+	SchemeEnv = "env://"
+)
+
+// varPattern matches ${VAR} and ${VAR:default}
+var varPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(?::([^}]*))?\}`)
+
+// Interpolate replace ${VAR} with the environment variable,
+// ${VAR:default} is replaced with the default value if the variable is not set.
+func Interpolate(s string) string {
+	return varPattern.ReplaceAllStringFunc(s, func(m string) string {
+		sub := varPattern.FindStringSubmatch(m)
+		if v, ok := os.LookupEnv(sub[1]); ok {
+			return v
+		}
+		return sub[2]
+	})
+}
+
+// ResolveSecret read the value of a secret reference, eg: file:///run/secrets/jwt, env://JWT_SECRET,
+// the other values are returned as they are.
+func ResolveSecret(s string) (string, error) {
+	switch {
+	case strings.HasPrefix(s, SchemeFile):
+		data, err := ioutil.ReadFile(strings.TrimPrefix(s, SchemeFile))
+		if err != nil {
+			return "", err
+		}
+		// NOTE: the secret files usually end with a newline
+		return strings.TrimRight(string(data), "\r\n"), nil
+	case strings.HasPrefix(s, SchemeEnv):
+		name := strings.TrimPrefix(s, SchemeEnv)
+		v, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("env %s is not set", name)
+		}
+		return v, nil
+	}
+	return s, nil
+}
+
+// resolve interpolate and resolve the secrets of the string values in place
+func resolve(m map[string]interface{}, path string) error {
+	for k, v := range m {
+		key := k
+		if path != "" {
+			key = path + "." + k
+		}
+		val, err := resolveValue(v, key)
+		if err != nil {
+			return err
+		}
+		m[k] = val
+	}
+	return nil
+}
+
+func resolveValue(v interface{}, key string) (interface{}, error) {
+	if m, ok := toMap(v); ok {
+		// NOTE: copy the nested maps, they may be shared with the source, eg: the defaults
+		cp := make(map[string]interface{}, len(m))
+		for k, v := range m {
+			cp[k] = v
+		}
+		return cp, resolve(cp, key)
+	}
+	switch val := v.(type) {
+	case string:
+		s, err := ResolveSecret(Interpolate(val))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		return s, nil
+	case []interface{}:
+		items := make([]interface{}, len(val))
+		for i, item := range val {
+			item, err := resolveValue(item, fmt.Sprintf("%s[%d]", key, i))
+			if err != nil {
+				return nil, err
+			}
+			items[i] = item
+		}
+		return items, nil
+	}
+	return v, nil
+}
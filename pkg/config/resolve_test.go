@@ -0,0 +1,97 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInterpolate(t *testing.T) {
+	os.Setenv("TEST_DB_HOST", "db")
+	defer os.Unsetenv("TEST_DB_HOST")
+
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"${TEST_DB_HOST}:3306", "db:3306"},
+		{"${TEST_DB_HOST:localhost}:3306", "db:3306"},
+		{"${TEST_DB_PORT:3306}", "3306"},
+		{"${TEST_DB_PORT}", ""},
+		{"${TEST_DB_DSN:user:pass@tcp(db)}", "user:pass@tcp(db)"},
+		{"$TEST_DB_HOST", "$TEST_DB_HOST"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, Interpolate(tt.in), tt.in)
+	}
+}
+
+func TestResolveSecret(t *testing.T) {
+	dir, err := ioutil.TempDir("", "secret")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "password")
+	require.NoError(t, ioutil.WriteFile(file, []byte("from-file\n"), 0600))
+
+	os.Setenv("TEST_SECRET", "from-env")
+	defer os.Unsetenv("TEST_SECRET")
+
+	s, err := ResolveSecret("file://" + file)
+	require.NoError(t, err)
+	assert.Equal(t, "from-file", s)
+
+	s, err = ResolveSecret("env://TEST_SECRET")
+	require.NoError(t, err)
+	assert.Equal(t, "from-env", s)
+
+	s, err = ResolveSecret("plain")
+	require.NoError(t, err)
+	assert.Equal(t, "plain", s)
+
+	_, err = ResolveSecret("env://TEST_SECRET_NOT_SET")
+	assert.Error(t, err)
+	_, err = ResolveSecret("file://" + filepath.Join(dir, "not-exist"))
+	assert.Error(t, err)
+}
+
+func TestLoad_Resolve(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "test"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "test", "database.yaml"),
+		[]byte("Addr: ${TEST_DB_HOST:localhost}:3306\nUserName: root\nPassword: env://TEST_DB_PASSWORD\n"), 0644))
+
+	type dbConfig struct {
+		Addr     string
+		UserName string
+		Password string
+	}
+
+	t.Run("missing secret", func(t *testing.T) {
+		var cfg dbConfig
+		err := New(dir, WithEnv("test")).Load("database", &cfg)
+		assert.Error(t, err)
+	})
+
+	os.Setenv("TEST_DB_PASSWORD", "secret")
+	os.Setenv("EAGLE_DATABASE_USERNAME", "eagle")
+	defer os.Unsetenv("TEST_DB_PASSWORD")
+	defer os.Unsetenv("EAGLE_DATABASE_USERNAME")
+
+	t.Run("env overrides", func(t *testing.T) {
+		var cfg dbConfig
+		require.NoError(t, New(dir, WithEnv("test")).Load("database", &cfg))
+		assert.Equal(t, dbConfig{Addr: "localhost:3306", UserName: "eagle", Password: "secret"}, cfg)
+	})
+
+	t.Run("disable env overrides", func(t *testing.T) {
+		var cfg dbConfig
+		require.NoError(t, New(dir, WithEnv("test"), WithEnvPrefix("")).Load("database", &cfg))
+		assert.Equal(t, "root", cfg.UserName)
+	})
+}
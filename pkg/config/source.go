@@ -0,0 +1,289 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+)
+
+// ErrNotFound the config is not found in the source
+var ErrNotFound = errors.New("config file not found")
+
+// Source provides the configs by the file name, eg: app, database.
+// the configs of the sources are merged by the precedence: defaults < file < remote < env
+type Source interface {
+	// Load returns the config of the file, ErrNotFound is returned if there is none
+	Load(filename string) (map[string]interface{}, error)
+}
+
+// Watcher is implemented by the sources which can be watched, eg: file, etcd
+type Watcher interface {
+	// Watch call onChange every time the config of the file is changed until stop is called
+	Watch(filename string, onChange func()) (stop func(), err error)
+}
+
+// Parse decode the content of a config by the type, eg: yaml, json, toml
+func Parse(data []byte, cfgType string) (map[string]interface{}, error) {
+	v := viper.New()
+	v.SetConfigType(cfgType)
+	if err := v.ReadConfig(bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+	return v.AllSettings(), nil
+}
+
+// ------------------ file ------------------
+
+type fileSource struct {
+	path    string
+	cfgType string
+}
+
+// NewFileSource the config files in the directory, eg: config/local/app.yaml
+func NewFileSource(path, cfgType string) Source {
+	return &fileSource{path: path, cfgType: cfgType}
+}
+
+func (s *fileSource) viper(filename string) *viper.Viper {
+	v := viper.New()
+	v.AddConfigPath(s.path)
+	v.SetConfigName(filename)
+	v.SetConfigType(s.cfgType)
+	return v
+}
+
+func (s *fileSource) Load(filename string) (map[string]interface{}, error) {
+	v := s.viper(filename)
+	if err := v.ReadInConfig(); err != nil {
+		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return v.AllSettings(), nil
+}
+
+// file the path of the config file, it's empty if the file is not found
+func (s *fileSource) file(filename string) string {
+	for _, ext := range viper.SupportedExts {
+		file := filepath.Join(s.path, filename+"."+ext)
+		if info, err := os.Stat(file); err == nil && !info.IsDir() {
+			return file
+		}
+	}
+	return ""
+}
+
+// Watch watch the directory of the file, so that the file can be replaced, eg: k8s configmap
+func (s *fileSource) Watch(filename string, onChange func()) (func(), error) {
+	file := s.file(filename)
+	if file == "" {
+		return func() {}, nil
+	}
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	if err := w.Add(filepath.Dir(file)); err != nil {
+		_ = w.Close()
+		return nil, err
+	}
+
+	done := make(chan struct{})
+	go func() {
+		realFile, _ := filepath.EvalSymlinks(file)
+		for {
+			select {
+			case <-done:
+				return
+			case e, ok := <-w.Events:
+				if !ok {
+					return
+				}
+				// the file is written, or the symlink is changed
+				current, _ := filepath.EvalSymlinks(file)
+				written := filepath.Clean(e.Name) == file && e.Op&(fsnotify.Write|fsnotify.Create) != 0
+				if written || (current != "" && current != realFile) {
+					realFile = current
+					log.Printf("Config file changed: %s", e.Name)
+					onChange()
+				}
+			case err, ok := <-w.Errors:
+				if !ok {
+					return
+				}
+				log.Printf("Config file watch err: %v", err)
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			_ = w.Close()
+		})
+	}, nil
+}
+
+// ------------------ env ------------------
+
+type envSource struct {
+	prefix string
+}
+
+// NewEnvSource the configs in the environment variables named as PREFIX_FILENAME_KEY,
+// the nested keys are separated by _, eg: EAGLE_APP_HTTP_ADDR for HTTP.Addr of app.yaml,
+// the keys are case insensitive.
+// the keys of the other sources are matched with _ removed when the configs are merged,
+// eg: EAGLE_APP_JWT_SECRET for JwtSecret if it's in app.yaml, or else EAGLE_APP_JWTSECRET should be used.
+func NewEnvSource(prefix string) Source {
+	return &envSource{prefix: strings.ToUpper(prefix)}
+}
+
+func (s *envSource) Load(filename string) (map[string]interface{}, error) {
+	vars := s.vars(filename)
+	if len(vars) == 0 {
+		return nil, ErrNotFound
+	}
+	val := make(map[string]interface{})
+	for key, v := range vars {
+		setPath(val, strings.Split(key, "_"), v)
+	}
+	return val, nil
+}
+
+// merge set the variables into the configs of the other sources, return false if there is none
+func (s *envSource) merge(dst map[string]interface{}, filename string) bool {
+	vars := s.vars(filename)
+	// NOTE: set the short keys first, so that the order is stable
+	keys := make([]string, 0, len(vars))
+	for key := range vars {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		setEnvPath(dst, strings.Split(key, "_"), vars[key])
+	}
+	return len(vars) > 0
+}
+
+// vars the variables of the file by the lower case keys without the prefix, eg: http_addr
+func (s *envSource) vars(filename string) map[string]string {
+	prefix := strings.ToUpper(filename) + "_"
+	if s.prefix != "" {
+		prefix = s.prefix + "_" + prefix
+	}
+
+	vars := make(map[string]string)
+	for _, kv := range os.Environ() {
+		i := strings.IndexByte(kv, '=')
+		if i < 0 || !strings.HasPrefix(strings.ToUpper(kv[:i]), prefix) {
+			continue
+		}
+		key := strings.ToLower(kv[len(prefix):i])
+		if key == "" {
+			continue
+		}
+		vars[key] = kv[i+1:]
+	}
+	return vars
+}
+
+// setEnvPath set the value by the parts of an env key, the parts are joined to match the existing keys
+// with _ removed, the longest match wins, eg: [jwt secret] matches jwtsecret, [http addr] matches http.addr.
+// the parts which match nothing are nested as they are.
+func setEnvPath(m map[string]interface{}, parts []string, value interface{}) {
+	for i := len(parts); i > 0; i-- {
+		key, ok := findKey(m, strings.Join(parts[:i], ""))
+		if !ok {
+			continue
+		}
+		if i == len(parts) {
+			m[key] = value
+			return
+		}
+		if next, ok := m[key].(map[string]interface{}); ok {
+			setEnvPath(next, parts[i:], value)
+			return
+		}
+	}
+	setPath(m, parts, value)
+}
+
+// findKey the key of m which equals name with _ removed, case insensitive
+func findKey(m map[string]interface{}, name string) (string, bool) {
+	for k := range m {
+		if strings.EqualFold(strings.ReplaceAll(k, "_", ""), name) {
+			return k, true
+		}
+	}
+	return "", false
+}
+
+// setPath set the value of the nested keys
+func setPath(m map[string]interface{}, path []string, value interface{}) {
+	for _, key := range path[:len(path)-1] {
+		next, ok := m[key].(map[string]interface{})
+		if !ok {
+			next = make(map[string]interface{})
+			m[key] = next
+		}
+		m = next
+	}
+	m[path[len(path)-1]] = value
+}
+
+// ------------------ map ------------------
+
+type mapSource map[string]map[string]interface{}
+
+func (s mapSource) Load(filename string) (map[string]interface{}, error) {
+	val, ok := s[filename]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return val, nil
+}
+
+// mergeMap merge src into dst recursively, the keys are case insensitive,
+// the values of src take precedence even if the types are different, eg: the strings of env.
+func mergeMap(dst, src map[string]interface{}) {
+	for k, v := range src {
+		k = strings.ToLower(k)
+		if nested, ok := toMap(v); ok {
+			sub, ok := dst[k].(map[string]interface{})
+			if !ok {
+				sub = make(map[string]interface{})
+				dst[k] = sub
+			}
+			mergeMap(sub, nested)
+			continue
+		}
+		dst[k] = v
+	}
+}
+
+// toMap the nested config, the maps of yaml v2 are keyed by interface{}
+func toMap(v interface{}) (map[string]interface{}, bool) {
+	switch m := v.(type) {
+	case map[string]interface{}:
+		return m, true
+	case map[interface{}]interface{}:
+		sm := make(map[string]interface{}, len(m))
+		for k, v := range m {
+			sm[fmt.Sprint(k)] = v
+		}
+		return sm, true
+	}
+	return nil, false
+}
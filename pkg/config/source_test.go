@@ -0,0 +1,144 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testSource a remote source
+type testSource struct {
+	mu       sync.Mutex
+	data     string
+	onChange func()
+}
+
+func (s *testSource) Load(filename string) (map[string]interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if filename != "app" {
+		return nil, ErrNotFound
+	}
+	return Parse([]byte(s.data), "yaml")
+}
+
+func (s *testSource) Watch(filename string, onChange func()) (func(), error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onChange = onChange
+	return func() {}, nil
+}
+
+func (s *testSource) set(data string) {
+	s.mu.Lock()
+	s.data = data
+	onChange := s.onChange
+	s.mu.Unlock()
+	onChange()
+}
+
+type sourceConfig struct {
+	Name    string
+	Mode    string
+	Timeout time.Duration
+	HTTP    struct {
+		Addr string
+		Port int
+	}
+}
+
+func TestSources(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "test"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "test", "app.yaml"),
+		[]byte("Name: file\nMode: debug\nHTTP:\n  Addr: :8080\n"), 0644))
+
+	os.Setenv("TEST_APP_HTTP_PORT", "9090")
+	defer os.Unsetenv("TEST_APP_HTTP_PORT")
+
+	remote := &testSource{data: "Mode: release\n"}
+	c := New(dir, WithEnv("test"),
+		WithDefaults("app", map[string]interface{}{"Timeout": "3s", "HTTP": map[string]interface{}{"Port": 80}}),
+		WithSource(remote),
+		WithEnvPrefix("test"),
+	)
+	defer c.Close()
+
+	var cfg sourceConfig
+	value, err := c.Watch("app", &cfg, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "file", cfg.Name)
+	assert.Equal(t, "release", cfg.Mode)
+	assert.Equal(t, 3*time.Second, cfg.Timeout)
+	assert.Equal(t, ":8080", cfg.HTTP.Addr)
+	assert.Equal(t, 9090, cfg.HTTP.Port)
+
+	t.Run("reload the remote source", func(t *testing.T) {
+		remote.set("Name: remote\nHTTP:\n  Port: 7070\n")
+
+		next := value.Load().(*sourceConfig)
+		assert.Equal(t, "remote", next.Name)
+		assert.Equal(t, "debug", next.Mode)
+		assert.Equal(t, 3*time.Second, next.Timeout)
+		assert.Equal(t, 9090, next.HTTP.Port)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		_, err := c.LoadWithType("database", "")
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+}
+
+func TestEnvSource(t *testing.T) {
+	os.Setenv("EAGLE_DATABASE_PASSWORD", "secret")
+	os.Setenv("EAGLE_DATABASE_MASTER_ADDR", "db:3306")
+	defer os.Unsetenv("EAGLE_DATABASE_PASSWORD")
+	defer os.Unsetenv("EAGLE_DATABASE_MASTER_ADDR")
+
+	val, err := NewEnvSource("eagle").Load("database")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"password": "secret",
+		"master":   map[string]interface{}{"addr": "db:3306"},
+	}, val)
+
+	_, err = NewEnvSource("eagle").Load("app")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestEnvSource_Keys(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "app.yaml"),
+		[]byte("JwtSecret: file\nHTTP:\n  ReadTimeout: 1s\n"), 0644))
+
+	os.Setenv("TEST_APP_JWT_SECRET", "env")
+	os.Setenv("TEST_APP_HTTP_READ_TIMEOUT", "3s")
+	os.Setenv("TEST_APP_HTTP_WRITETIMEOUT", "5s")
+	defer os.Unsetenv("TEST_APP_JWT_SECRET")
+	defer os.Unsetenv("TEST_APP_HTTP_READ_TIMEOUT")
+	defer os.Unsetenv("TEST_APP_HTTP_WRITETIMEOUT")
+
+	var cfg struct {
+		JwtSecret string
+		HTTP      struct {
+			ReadTimeout  time.Duration
+			WriteTimeout time.Duration
+		}
+	}
+	c := New(dir, WithEnvPrefix("test"))
+	require.NoError(t, c.Load("app", &cfg))
+	assert.Equal(t, "env", cfg.JwtSecret)
+	assert.Equal(t, 3*time.Second, cfg.HTTP.ReadTimeout)
+	// the keys not in the files are set without _
+	assert.Equal(t, 5*time.Second, cfg.HTTP.WriteTimeout)
+}
@@ -0,0 +1,217 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Validator is implemented by the configs which check themselves before they are published
+type Validator interface {
+	Validate() error
+}
+
+// FieldError an invalid field of a config
+type FieldError struct {
+	// Path the path of the field, eg: HTTP.Addr, Rules[0].Limit
+	Path string
+	// Message the reason, eg: is required
+	Message string
+}
+
+func (e *FieldError) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return e.Path + " " + e.Message
+}
+
+// ValidationError all the invalid fields of a config file
+type ValidationError struct {
+	Filename string
+	Fields   []*FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		msgs = append(msgs, f.Error())
+	}
+	return fmt.Sprintf("config %s is invalid: %s", e.Filename, strings.Join(msgs, "; "))
+}
+
+var (
+	validate     *validator.Validate
+	validateOnce sync.Once
+)
+
+// Validate check the config by the validate tags, eg: `validate:"required"`, `validate:"gt=0"`,
+// `validate:"oneof=debug release test"`, then by Validate() error if it's implemented.
+// all the invalid fields are returned by a *ValidationError.
+func Validate(filename string, val interface{}) error {
+	if fields := validateFields(val); len(fields) > 0 {
+		return &ValidationError{Filename: filename, Fields: fields}
+	}
+	return nil
+}
+
+func validateFields(val interface{}) []*FieldError {
+	var fields []*FieldError
+
+	rv := reflect.Indirect(reflect.ValueOf(val))
+	switch rv.Kind() {
+	case reflect.Map:
+		// eg: the redis clients by name
+		keys := rv.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j]) })
+		for _, k := range keys {
+			for _, f := range validateFields(rv.MapIndex(k).Interface()) {
+				f.Path = join(fmt.Sprint(k), f.Path)
+				fields = append(fields, f)
+			}
+		}
+	case reflect.Struct:
+		validateOnce.Do(func() {
+			validate = validator.New()
+		})
+		var errs validator.ValidationErrors
+		if err := validate.Struct(val); errors.As(err, &errs) {
+			for _, e := range errs {
+				fields = append(fields, &FieldError{Path: fieldPath(e.StructNamespace()), Message: message(e)})
+			}
+		}
+	}
+
+	if v, ok := val.(Validator); ok {
+		if err := v.Validate(); err != nil {
+			fields = append(fields, &FieldError{Message: err.Error()})
+		}
+	}
+	return fields
+}
+
+// fieldPath the struct path without the name of the struct, eg: Config.HTTP.Addr -> HTTP.Addr,
+// the names of the go fields are used rather than the tags, so that it matches the yaml keys
+func fieldPath(ns string) string {
+	if i := strings.IndexByte(ns, '.'); i >= 0 {
+		return ns[i+1:]
+	}
+	return ns
+}
+
+// message the readable message of the validate tags
+func message(e validator.FieldError) string {
+	size := ""
+	switch e.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		size = "length "
+	}
+	switch e.Tag() {
+	case "required":
+		return "is required"
+	case "oneof":
+		return fmt.Sprintf("must be one of [%s], got %v", e.Param(), e.Value())
+	case "min", "gte":
+		return fmt.Sprintf("%smust be greater than or equal to %s, got %v", size, e.Param(), e.Value())
+	case "max", "lte":
+		return fmt.Sprintf("%smust be less than or equal to %s, got %v", size, e.Param(), e.Value())
+	case "gt":
+		return fmt.Sprintf("%smust be greater than %s, got %v", size, e.Param(), e.Value())
+	case "lt":
+		return fmt.Sprintf("%smust be less than %s, got %v", size, e.Param(), e.Value())
+	}
+	if e.Param() != "" {
+		return fmt.Sprintf("failed on %s=%s, got %v", e.Tag(), e.Param(), e.Value())
+	}
+	return fmt.Sprintf("failed on %s, got %v", e.Tag(), e.Value())
+}
+
+// unknownFields the keys of the config which don't match any field of the type,
+// the keys are case insensitive, the mapstructure tags are respected.
+func unknownFields(m map[string]interface{}, t reflect.Type, path string) []*FieldError {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	var fields []*FieldError
+	switch t.Kind() {
+	case reflect.Struct:
+		known := make(map[string]reflect.StructField)
+		structFields(t, known)
+		for _, k := range sortedKeys(m) {
+			f, ok := known[strings.ToLower(k)]
+			if !ok {
+				fields = append(fields, &FieldError{Path: join(path, strings.ToLower(k)), Message: "is unknown"})
+				continue
+			}
+			fields = append(fields, unknownValue(m[k], f.Type, join(path, f.Name))...)
+		}
+	case reflect.Map:
+		for _, k := range sortedKeys(m) {
+			fields = append(fields, unknownValue(m[k], t.Elem(), join(path, k))...)
+		}
+	}
+	return fields
+}
+
+func unknownValue(v interface{}, t reflect.Type, path string) []*FieldError {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if m, ok := toMap(v); ok {
+		return unknownFields(m, t, path)
+	}
+	if items, ok := v.([]interface{}); ok && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+		var fields []*FieldError
+		for i, item := range items {
+			fields = append(fields, unknownValue(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i))...)
+		}
+		return fields
+	}
+	return nil
+}
+
+// structFields the fields of the struct by the lower case names, the squashed structs are flattened
+func structFields(t reflect.Type, known map[string]reflect.StructField) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		name := f.Name
+		tag := strings.Split(f.Tag.Get("mapstructure"), ",")
+		if tag[0] == "-" {
+			continue
+		}
+		if tag[0] != "" {
+			name = tag[0]
+		}
+		if len(tag) > 1 && tag[1] == "squash" && f.Type.Kind() == reflect.Struct {
+			structFields(f.Type, known)
+			continue
+		}
+		f.Name = name
+		known[strings.ToLower(name)] = f
+	}
+}
+
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
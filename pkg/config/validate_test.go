@@ -0,0 +1,104 @@
+package config
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type validateServer struct {
+	Addr    string `validate:"required"`
+	Timeout int    `validate:"gte=0,lte=60"`
+}
+
+type validateConfig struct {
+	Name    string `validate:"required"`
+	Mode    string `validate:"oneof=debug release"`
+	Timeout int    `validate:"gt=0"`
+	HTTP    validateServer
+	Servers []validateServer `validate:"dive"`
+}
+
+func newTestDir(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "config")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "test"), 0755))
+	for name, content := range files {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "test", name), []byte(content), 0644))
+	}
+	return dir
+}
+
+func TestValidate(t *testing.T) {
+	err := Validate("app", &validateConfig{
+		Mode:    "prod",
+		HTTP:    validateServer{Timeout: 100},
+		Servers: []validateServer{{Addr: ":8080"}, {}},
+	})
+
+	var verr *ValidationError
+	require.True(t, errors.As(err, &verr))
+	assert.Equal(t, "app", verr.Filename)
+	paths := make([]string, 0, len(verr.Fields))
+	for _, f := range verr.Fields {
+		paths = append(paths, f.Path)
+	}
+	assert.Equal(t, []string{"Name", "Mode", "Timeout", "HTTP.Addr", "HTTP.Timeout", "Servers[1].Addr"}, paths)
+	assert.Contains(t, err.Error(), "Mode must be one of [debug release], got prod")
+	assert.Contains(t, err.Error(), "HTTP.Timeout must be less than or equal to 60, got 100")
+
+	t.Run("map", func(t *testing.T) {
+		err := Validate("redis", map[string]validateServer{"default": {}})
+		assert.EqualError(t, err, "config redis is invalid: default.Addr is required")
+	})
+
+	t.Run("struct path", func(t *testing.T) {
+		err := Validate("app", &struct {
+			JwtSecret string `mapstructure:"jwt_secret" validate:"required"`
+		}{})
+		assert.EqualError(t, err, "config app is invalid: JwtSecret is required")
+	})
+
+	t.Run("valid", func(t *testing.T) {
+		assert.NoError(t, Validate("app", &validateConfig{Name: "eagle", Mode: "debug", Timeout: 1,
+			HTTP: validateServer{Addr: ":8080"}}))
+	})
+}
+
+func TestLoad_Strict(t *testing.T) {
+	dir := newTestDir(t, map[string]string{
+		"app.yaml": "Name: eagle\nMode: debug\nTimeout: 3\nTimout: 5\nHTTP:\n  Addr: :8080\n  Adr: :9090\n" +
+			"Servers:\n  - Addr: :7070\n    Port: 7070\n",
+	})
+
+	var cfg validateConfig
+	require.NoError(t, New(dir, WithEnv("test")).Load("app", &cfg))
+
+	err := New(dir, WithEnv("test"), WithStrict()).Load("app", &cfg)
+	assert.EqualError(t, err, "config app is invalid: HTTP.adr is unknown; Servers[0].port is unknown; timout is unknown")
+}
+
+func TestCheck(t *testing.T) {
+	dir := newTestDir(t, map[string]string{
+		"app.yaml":      "Name: eagle\nMode: prod\nTimeout: 3\nHTTP:\n  Addr: :8080\n",
+		"database.yaml": "Addr: [localhost\n",
+		"logger.yaml":   "Level: info\n",
+		"README.md":     "# config",
+	})
+
+	errs := New(dir, WithEnv("test")).Check(map[string]interface{}{
+		"app":   validateConfig{},
+		"redis": map[string]validateServer{},
+	})
+	require.Len(t, errs, 3)
+	assert.EqualError(t, errs[0], "config app is invalid: Mode must be one of [debug release], got prod")
+	assert.Contains(t, errs[1].Error(), "config database:")
+	assert.ErrorIs(t, errs[2], ErrNotFound)
+}
@@ -0,0 +1,102 @@
+package config
+
+import (
+	"errors"
+	"log"
+	"reflect"
+	"sync/atomic"
+
+	"github.com/spf13/viper"
+)
+
+// ChangeFunc is called with the old and the new config after the new one is published,
+// both of them are pointers of the same type as the watched value.
+type ChangeFunc func(old, new interface{})
+
+// Value holds the latest config of a watched file, it's safe for concurrent use
+type Value struct {
+	v atomic.Value
+}
+
+// Load returns the latest config, it's a pointer of the same type as the watched value
+func (v *Value) Load() interface{} {
+	return v.v.Load()
+}
+
+// Watch alias for config func.
+func Watch(filename string, val interface{}, onChange ChangeFunc) (*Value, error) {
+	return conf.Watch(filename, val, onChange)
+}
+
+// Watch load the file into val, then reload it every time the file is changed.
+// val must be a pointer, eg: &AppConfig{}, it's not modified after the first load,
+// the reloaded config is unmarshalled into a new value of the same type,
+// it's discarded if it's invalid, see Validate, or else it's published by the returned Value and passed to onChange.
+func (c *Config) Watch(filename string, val interface{}, onChange ChangeFunc) (*Value, error) {
+	rv := reflect.ValueOf(val)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return nil, errors.New("config: watch value must be a non-nil pointer")
+	}
+	typ := rv.Elem().Type()
+
+	v, err := c.LoadWithType(filename, c.configType)
+	if err != nil {
+		return nil, err
+	}
+	if err := decode(filename, v, val, c.strict); err != nil {
+		return nil, err
+	}
+
+	value := &Value{}
+	value.v.Store(val)
+	c.subscribe(filename, func(v *viper.Viper) {
+		next := reflect.New(typ).Interface()
+		if err := decode(filename, v, next, c.strict); err != nil {
+			log.Printf("Config file %s is not reloaded: %v", filename, err)
+			return
+		}
+		old := value.Load()
+		value.v.Store(next)
+		if onChange != nil {
+			onChange(old, next)
+		}
+	})
+	return value, nil
+}
+
+// decode unmarshal the config into val and validate it,
+// the unknown keys are rejected in strict mode.
+func decode(filename string, v *viper.Viper, val interface{}, strict bool) error {
+	if err := v.Unmarshal(val); err != nil {
+		return err
+	}
+
+	var fields []*FieldError
+	if strict {
+		fields = append(fields, unknownFields(v.AllSettings(), reflect.TypeOf(val), "")...)
+	}
+	fields = append(fields, validateFields(val)...)
+	if len(fields) > 0 {
+		return &ValidationError{Filename: filename, Fields: fields}
+	}
+	return nil
+}
+
+// subscribe add a watcher of the file
+func (c *Config) subscribe(filename string, fn func(v *viper.Viper)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.watchers[filename] = append(c.watchers[filename], fn)
+}
+
+// notify call the watchers of the file after it's reloaded
+func (c *Config) notify(filename string, v *viper.Viper) {
+	c.mu.Lock()
+	watchers := make([]func(v *viper.Viper), len(c.watchers[filename]))
+	copy(watchers, c.watchers[filename])
+	c.mu.Unlock()
+
+	for _, fn := range watchers {
+		fn(v)
+	}
+}
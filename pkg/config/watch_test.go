@@ -0,0 +1,70 @@
+package config
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type watchConfig struct {
+	Name  string
+	Level string
+}
+
+func (c *watchConfig) Validate() error {
+	if c.Level == "" {
+		return errors.New("level is required")
+	}
+	return nil
+}
+
+func writeConfig(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+}
+
+func TestWatch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "test"), 0755))
+	path := filepath.Join(dir, "test", "app.yaml")
+	writeConfig(t, path, "name: eagle\nlevel: info\n")
+
+	c := New(dir, WithEnv("test"))
+
+	t.Run("invalid pointer", func(t *testing.T) {
+		_, err := c.Watch("app", watchConfig{}, nil)
+		assert.Error(t, err)
+	})
+
+	changes := make(chan [2]*watchConfig, 10)
+	var cfg watchConfig
+	value, err := c.Watch("app", &cfg, func(old, new interface{}) {
+		changes <- [2]*watchConfig{old.(*watchConfig), new.(*watchConfig)}
+	})
+	require.NoError(t, err)
+	assert.Equal(t, watchConfig{Name: "eagle", Level: "info"}, cfg)
+	assert.Equal(t, &cfg, value.Load())
+
+	// the invalid config is discarded
+	writeConfig(t, path, "name: eagle\n")
+	time.Sleep(200 * time.Millisecond)
+	writeConfig(t, path, "name: eagle\nlevel: debug\n")
+
+	select {
+	case change := <-changes:
+		assert.Equal(t, "info", change[0].Level)
+		assert.Equal(t, "debug", change[1].Level)
+		assert.Equal(t, change[1], value.Load())
+		assert.Equal(t, "info", cfg.Level)
+	case <-time.After(5 * time.Second):
+		t.Fatal("config is not reloaded")
+	}
+}
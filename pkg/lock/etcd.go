@@ -35,6 +35,18 @@ func (l *EtcdLock) Lock(ctx context.Context, timeout time.Duration) (b bool, err
 	return true, l.mu.Lock(ctx)
 }
 
+// Acquire block until the lock is acquired or ctx is done,
+// the lease of session is kept alive by etcd client, so the lock is renewed while it is held.
+func (l *EtcdLock) Acquire(ctx context.Context) error {
+	if err := l.mu.Lock(ctx); err != nil {
+		if ctx.Err() != nil {
+			return ErrNotAcquired
+		}
+		return err
+	}
+	return nil
+}
+
 // Unlock release a lock.
 func (l *EtcdLock) Unlock(ctx context.Context) (b bool, err error) {
 	err = l.mu.Unlock(ctx)
@@ -0,0 +1,26 @@
+package lock
+
+import (
+	"context"
+
+	"github.com/go-eagle/eagle/pkg/redis"
+)
+
+// TryLockFunc try to acquire the redis lock of key once and run fn while holding it,
+// the lock is renewed until fn returns, ErrNotAcquired is returned if the lock is held by others.
+// NOTE: it uses the default redis client: redis.RedisClient
+func TryLockFunc(ctx context.Context, key string, fn func(ctx context.Context) error, opts ...Option) error {
+	l := NewRedisLock(redis.RedisClient, key, append(opts, WithAutoRenew())...)
+	ok, err := l.Lock(ctx, l.opts.ttl)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNotAcquired
+	}
+	defer func() {
+		_, _ = l.Unlock(context.Background())
+	}()
+
+	return fn(ctx)
+}
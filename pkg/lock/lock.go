@@ -2,6 +2,7 @@ package lock
 
 import (
 	"context"
+	"errors"
 	"time"
 )
 
@@ -12,8 +13,56 @@ const (
 	EtcdLockKey = "/eagle/lock/%s"
 )
 
+var (
+	// ErrNotAcquired is returned when the lock is held by others
+	ErrNotAcquired = errors.New("lock: not acquired")
+)
+
 // Lock define common func
 type Lock interface {
 	Lock(ctx context.Context, timeout time.Duration) (bool, error)
 	Unlock(ctx context.Context) (bool, error)
+	// Acquire block until the lock is acquired or ctx is done
+	Acquire(ctx context.Context) error
+}
+
+// FencingLock is a lock that issues fencing tokens
+type FencingLock interface {
+	Lock
+	// LockWithToken acquires the lock and returns a monotonically increasing token, zero means not acquired
+	LockWithToken(ctx context.Context, timeout time.Duration) (int64, error)
+	// FencingToken return the token of the last acquisition
+	FencingToken() int64
+}
+
+var (
+	_ FencingLock = (*RedisLock)(nil)
+	_ FencingLock = (*Redlock)(nil)
+)
+
+// acquire call try with exponential backoff until it returns true or ctx is done
+func acquire(ctx context.Context, o options, try func() (bool, error)) error {
+	interval := o.minRetryInterval
+	for {
+		ok, err := try()
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ErrNotAcquired
+		case <-timer.C:
+		}
+
+		interval *= 2
+		if interval > o.maxRetryInterval {
+			interval = o.maxRetryInterval
+		}
+	}
 }
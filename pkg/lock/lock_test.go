@@ -5,6 +5,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/alicebob/miniredis/v2"
+	goredis "github.com/go-redis/redis/v8"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/go-eagle/eagle/pkg/redis"
@@ -62,3 +64,95 @@ func TestLockWithTimeout(t *testing.T) {
 		assert.False(t, ok)
 	})
 }
+
+func TestRedisLock_AutoRenew(t *testing.T) {
+	mr, err := miniredis.Run()
+	assert.NoError(t, err)
+	defer mr.Close()
+	rdb := goredis.NewClient(&goredis.Options{Addr: mr.Addr()})
+	ctx := context.Background()
+
+	lock := NewRedisLock(rdb, "renew-lock", WithAutoRenew())
+	ok, err := lock.Lock(ctx, 300*time.Millisecond)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	// the ttl is extended by watchdog every 100ms
+	mr.FastForward(200 * time.Millisecond)
+	assert.Eventually(t, func() bool {
+		return mr.TTL(getRedisKey("renew-lock")) > 200*time.Millisecond
+	}, time.Second, 10*time.Millisecond)
+
+	ok, err = lock.Unlock(ctx)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestRedisLock_Acquire(t *testing.T) {
+	redis.InitTestRedis()
+
+	lock1 := NewRedisLock(redis.RedisClient, "acquire-lock", WithTTL(time.Second))
+	lock2 := NewRedisLock(redis.RedisClient, "acquire-lock", WithTTL(time.Second))
+	assert.NoError(t, lock1.Acquire(context.Background()))
+
+	t.Run("should timeout", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		assert.Equal(t, ErrNotAcquired, lock2.Acquire(ctx))
+	})
+
+	t.Run("should acquire after unlock", func(t *testing.T) {
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			_, _ = lock1.Unlock(context.Background())
+		}()
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		assert.NoError(t, lock2.Acquire(ctx))
+	})
+}
+
+func TestRedisLock_Reentrant(t *testing.T) {
+	redis.InitTestRedis()
+	ctx := context.Background()
+
+	lock1 := NewRedisLock(redis.RedisClient, "reentrant-lock", WithReentrant("owner-1"))
+	lock2 := NewRedisLock(redis.RedisClient, "reentrant-lock", WithReentrant("owner-1"))
+	other := NewRedisLock(redis.RedisClient, "reentrant-lock", WithReentrant("owner-2"))
+
+	ok, _ := lock1.Lock(ctx, time.Second)
+	assert.True(t, ok)
+	ok, _ = lock2.Lock(ctx, time.Second)
+	assert.True(t, ok)
+	ok, _ = other.Lock(ctx, time.Second)
+	assert.False(t, ok)
+
+	// released after the same times of unlock
+	ok, _ = lock2.Unlock(ctx)
+	assert.True(t, ok)
+	ok, _ = other.Lock(ctx, time.Second)
+	assert.False(t, ok)
+	ok, _ = lock1.Unlock(ctx)
+	assert.True(t, ok)
+	ok, _ = other.Lock(ctx, time.Second)
+	assert.True(t, ok)
+}
+
+func TestTryLockFunc(t *testing.T) {
+	redis.InitTestRedis()
+	ctx := context.Background()
+
+	called := false
+	err := TryLockFunc(ctx, "func-lock", func(ctx context.Context) error {
+		called = true
+		// the lock is held by fn
+		err := TryLockFunc(ctx, "func-lock", func(ctx context.Context) error { return nil })
+		assert.Equal(t, ErrNotAcquired, err)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.True(t, called)
+
+	// the lock is released after fn
+	assert.NoError(t, TryLockFunc(ctx, "func-lock", func(ctx context.Context) error { return nil }))
+}
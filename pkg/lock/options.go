@@ -0,0 +1,69 @@
+package lock
+
+import "time"
+
+const (
+	// DefaultTTL default expiration of a lock
+	DefaultTTL = 10 * time.Second
+	// DefaultMinRetryInterval the first wait time of Acquire
+	DefaultMinRetryInterval = 10 * time.Millisecond
+	// DefaultMaxRetryInterval the max wait time of Acquire
+	DefaultMaxRetryInterval = 500 * time.Millisecond
+)
+
+// Option is lock option
+type Option func(*options)
+
+type options struct {
+	ttl              time.Duration
+	token            string
+	reentrant        bool
+	autoRenew        bool
+	minRetryInterval time.Duration
+	maxRetryInterval time.Duration
+}
+
+func newOptions(opts ...Option) options {
+	o := options{
+		ttl:              DefaultTTL,
+		token:            genToken(),
+		minRetryInterval: DefaultMinRetryInterval,
+		maxRetryInterval: DefaultMaxRetryInterval,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithTTL with the expiration used by Acquire and the watchdog
+func WithTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		o.ttl = ttl
+	}
+}
+
+// WithAutoRenew extend the expiration by a watchdog every ttl/3 while the lock is held,
+// so that a long job will not lose the lock.
+func WithAutoRenew() Option {
+	return func(o *options) {
+		o.autoRenew = true
+	}
+}
+
+// WithReentrant make the lock reentrant by owner,
+// the locks with the same owner can acquire it again, and it is released after the same times of unlock.
+func WithReentrant(owner string) Option {
+	return func(o *options) {
+		o.token = owner
+		o.reentrant = true
+	}
+}
+
+// WithRetryInterval with the backoff of Acquire, it starts from min and doubles until max
+func WithRetryInterval(min, max time.Duration) Option {
+	return func(o *options) {
+		o.minRetryInterval = min
+		o.maxRetryInterval = max
+	}
+}
@@ -3,6 +3,7 @@ package lock
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/go-eagle/eagle/pkg/log"
@@ -10,41 +11,211 @@ import (
 	"github.com/go-redis/redis/v8"
 )
 
+var (
+	// set the lock and issue a fencing token by the counter of the lock
+	lockScript = redis.NewScript(`
+if redis.call('SET', KEYS[1], ARGV[1], 'NX', 'PX', ARGV[2]) then
+	return redis.call('INCR', KEYS[2])
+end
+return 0`)
+
+	// release the lock only if the token is consistent
+	unlockScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0`)
+
+	// extend the expiration only if the token is consistent
+	refreshScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 0`)
+
+	// reentrant lock is a hash of owner => count, the fencing token is kept in the field __fence
+	reentrantLockScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	local fence = redis.call('INCR', KEYS[2])
+	redis.call('HSET', KEYS[1], ARGV[1], 1, '__fence', fence)
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+	return fence
+end
+if redis.call('HEXISTS', KEYS[1], ARGV[1]) == 1 then
+	redis.call('HINCRBY', KEYS[1], ARGV[1], 1)
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+	return tonumber(redis.call('HGET', KEYS[1], '__fence'))
+end
+return 0`)
+
+	reentrantUnlockScript = redis.NewScript(`
+if redis.call('HEXISTS', KEYS[1], ARGV[1]) == 0 then
+	return 0
+end
+if redis.call('HINCRBY', KEYS[1], ARGV[1], -1) <= 0 then
+	redis.call('DEL', KEYS[1])
+end
+return 1`)
+
+	reentrantRefreshScript = redis.NewScript(`
+if redis.call('HEXISTS', KEYS[1], ARGV[1]) == 1 then
+	return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 0`)
+)
+
 // RedisLock is a redis lock.
 type RedisLock struct {
 	key         string
 	redisClient *redis.Client
 	token       string
+	opts        options
+
+	mu         sync.Mutex
+	stopRenew  chan struct{}
+	renewCount int
+	fence      int64
 }
 
 // NewRedisLock new a redis lock instance
 // nolint
-func NewRedisLock(rdb *redis.Client, key string) *RedisLock {
+func NewRedisLock(rdb *redis.Client, key string, opts ...Option) *RedisLock {
+	o := newOptions(opts...)
 	opt := &RedisLock{
 		key:         getRedisKey(key),
 		redisClient: rdb,
-		token:       genToken(),
+		token:       o.token,
+		opts:        o,
 	}
 	return opt
 }
 
 // Lock acquires the lock.
 func (l *RedisLock) Lock(ctx context.Context, timeout time.Duration) (bool, error) {
-	isSet, err := l.redisClient.SetNX(ctx, l.key, l.token, timeout).Result()
+	fence, err := l.LockWithToken(ctx, timeout)
+	return fence > 0, err
+}
+
+// LockWithToken acquires the lock and returns a fencing token, zero means the lock is not acquired.
+// the token is larger than any token issued before for the same key, so the downstream can reject
+// the writes of a stale holder whose lock has expired, eg:
+//
+//	UPDATE t SET ..., fence = ? WHERE id = ? AND fence < ?
+func (l *RedisLock) LockWithToken(ctx context.Context, timeout time.Duration) (int64, error) {
+	script := lockScript
+	if l.opts.reentrant {
+		script = reentrantLockScript
+	}
+	fence, err := script.Run(ctx, l.redisClient, []string{l.key, getFenceKey(l.key)}, l.token, timeout.Milliseconds()).Int64()
 	if err == redis.Nil {
-		return false, nil
+		return 0, nil
 	} else if err != nil {
 		log.Errorf("acquires the lock err, key: %s, err: %s", l.key, err.Error())
-		return false, err
+		return 0, err
+	}
+	if fence > 0 {
+		l.mu.Lock()
+		l.fence = fence
+		l.mu.Unlock()
+		if l.opts.autoRenew {
+			l.startRenew(timeout)
+		}
 	}
-	return isSet, nil
+	return fence, nil
+}
+
+// FencingToken return the fencing token of the last acquisition
+func (l *RedisLock) FencingToken() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.fence
+}
+
+// Acquire block until the lock is acquired with the ttl of options or ctx is done.
+func (l *RedisLock) Acquire(ctx context.Context) error {
+	return acquire(ctx, l.opts, func() (bool, error) {
+		return l.Lock(ctx, l.opts.ttl)
+	})
+}
+
+// Refresh extend the expiration of the lock if it is still held.
+func (l *RedisLock) Refresh(ctx context.Context, ttl time.Duration) (bool, error) {
+	script := refreshScript
+	if l.opts.reentrant {
+		script = reentrantRefreshScript
+	}
+	return runScript(ctx, l.redisClient, script, l.key, l.token, ttl.Milliseconds())
 }
 
 // Unlock del the lock.
 // NOTE: token The deletion will be executed only if it is consistent to avoid accidental deletion. Here, the lua script is used for transaction processing.
 func (l *RedisLock) Unlock(ctx context.Context) (bool, error) {
-	luaScript := "if redis.call('GET',KEYS[1]) == ARGV[1] then return redis.call('DEL',KEYS[1]) else return 0 end"
-	ret, err := l.redisClient.Eval(ctx, luaScript, []string{l.key}, l.token).Result()
+	l.stopRenewal()
+
+	script := unlockScript
+	if l.opts.reentrant {
+		script = reentrantUnlockScript
+	}
+	return runScript(ctx, l.redisClient, script, l.key, l.token)
+}
+
+// startRenew start a watchdog which extends the expiration every ttl/3 until unlock,
+// a reentrant lock shares one watchdog.
+func (l *RedisLock) startRenew(ttl time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.renewCount++
+	if l.stopRenew != nil {
+		return
+	}
+
+	stop := make(chan struct{})
+	l.stopRenew = stop
+	go func() {
+		ticker := time.NewTicker(ttl / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				ok, err := l.Refresh(context.Background(), ttl)
+				if err != nil {
+					// try again in next tick, the lock is still valid for 2/3 ttl
+					continue
+				}
+				if !ok {
+					// the lock is lost
+					l.mu.Lock()
+					if l.stopRenew == stop {
+						l.stopRenew = nil
+						l.renewCount = 0
+					}
+					l.mu.Unlock()
+					return
+				}
+			}
+		}
+	}()
+}
+
+func (l *RedisLock) stopRenewal() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.stopRenew == nil {
+		return
+	}
+	l.renewCount--
+	if l.renewCount <= 0 {
+		close(l.stopRenew)
+		l.stopRenew = nil
+		l.renewCount = 0
+	}
+}
+
+// runScript run a script which returns 0 or 1
+func runScript(ctx context.Context, rdb redis.Scripter, script *redis.Script, key string, args ...interface{}) (bool, error) {
+	ret, err := script.Run(ctx, rdb, []string{key}, args...).Result()
 	if err != nil {
 		return false, err
 	}
@@ -59,3 +230,8 @@ func (l *RedisLock) Unlock(ctx context.Context) (bool, error) {
 func getRedisKey(key string) string {
 	return fmt.Sprintf(RedisLockKey, key)
 }
+
+// getFenceKey get the key of fencing token counter
+func getFenceKey(lockKey string) string {
+	return lockKey + ":fence"
+}
@@ -0,0 +1,212 @@
+package lock
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// raise the fencing token counter to the max token of quorum,
+// so that the next token issued by any quorum is larger than it.
+var raiseFenceScript = redis.NewScript(`
+local fence = tonumber(redis.call('GET', KEYS[1]) or '0')
+if fence < tonumber(ARGV[1]) then
+	redis.call('SET', KEYS[1], ARGV[1])
+end
+return 1`)
+
+// clockDriftFactor the clock drift of redis nodes is ttl * factor + 2ms
+const clockDriftFactor = 0.01
+
+// Redlock is a lock over N independent redis nodes, it is acquired only if
+// the majority of nodes are locked within the ttl, so that a failover of one node
+// will not grant the lock twice.
+// see: https://redis.io/topics/distlock
+// NOTE: WithReentrant and WithAutoRenew are not supported.
+type Redlock struct {
+	clients []*redis.Client
+	key     string
+	token   string
+	opts    options
+	quorum  int
+
+	mu         sync.Mutex
+	fence      int64
+	validUntil time.Time
+}
+
+// NewRedlock create a redlock, the clients should be independent masters
+func NewRedlock(clients []*redis.Client, key string, opts ...Option) *Redlock {
+	o := newOptions(opts...)
+	return &Redlock{
+		clients: clients,
+		key:     getRedisKey(key),
+		token:   o.token,
+		opts:    o,
+		quorum:  len(clients)/2 + 1,
+	}
+}
+
+// Lock acquires the lock.
+func (r *Redlock) Lock(ctx context.Context, timeout time.Duration) (bool, error) {
+	fence, err := r.LockWithToken(ctx, timeout)
+	return fence > 0, err
+}
+
+// LockWithToken acquires the lock on the majority of nodes and returns a fencing token,
+// zero means the lock is not acquired.
+func (r *Redlock) LockWithToken(ctx context.Context, timeout time.Duration) (int64, error) {
+	start := time.Now()
+	fences, errs := r.eachNode(ctx, timeout, func(ctx context.Context, client *redis.Client) (int64, error) {
+		return lockScript.Run(ctx, client, []string{r.key, getFenceKey(r.key)}, r.token, timeout.Milliseconds()).Int64()
+	})
+
+	var (
+		maxFence int64
+		locked   = make([]*redis.Client, 0, len(r.clients))
+	)
+	for i, fence := range fences {
+		if fence > 0 {
+			locked = append(locked, r.clients[i])
+			if fence > maxFence {
+				maxFence = fence
+			}
+		}
+	}
+
+	drift := time.Duration(float64(timeout)*clockDriftFactor) + 2*time.Millisecond
+	validity := timeout - time.Since(start) - drift
+	if len(locked) >= r.quorum && validity > 0 && r.raiseFence(ctx, timeout, locked, maxFence) {
+		r.mu.Lock()
+		r.fence = maxFence
+		r.validUntil = start.Add(validity)
+		r.mu.Unlock()
+		return maxFence, nil
+	}
+
+	// release the locked nodes
+	_, _ = r.Unlock(ctx)
+	if len(errs) == len(r.clients) {
+		return 0, errs[0]
+	}
+	return 0, nil
+}
+
+// raiseFence raise the fencing token counter of locked nodes, it needs the majority to succeed.
+func (r *Redlock) raiseFence(ctx context.Context, timeout time.Duration, clients []*redis.Client, fence int64) bool {
+	n := 0
+	for _, client := range clients {
+		nodeCtx, cancel := context.WithTimeout(ctx, nodeTimeout(timeout))
+		err := raiseFenceScript.Run(nodeCtx, client, []string{getFenceKey(r.key)}, fence).Err()
+		cancel()
+		if err == nil {
+			n++
+		}
+	}
+	return n >= r.quorum
+}
+
+// Acquire block until the lock is acquired with the ttl of options or ctx is done.
+func (r *Redlock) Acquire(ctx context.Context) error {
+	return acquire(ctx, r.opts, func() (bool, error) {
+		return r.Lock(ctx, r.opts.ttl)
+	})
+}
+
+// Refresh extend the expiration of the lock, it returns true if the majority are extended.
+func (r *Redlock) Refresh(ctx context.Context, ttl time.Duration) (bool, error) {
+	start := time.Now()
+	results, errs := r.eachNode(ctx, ttl, func(ctx context.Context, client *redis.Client) (int64, error) {
+		return refreshScript.Run(ctx, client, []string{r.key}, r.token, ttl.Milliseconds()).Int64()
+	})
+	if len(errs) == len(r.clients) {
+		return false, errs[0]
+	}
+
+	drift := time.Duration(float64(ttl)*clockDriftFactor) + 2*time.Millisecond
+	validity := ttl - time.Since(start) - drift
+	if countOK(results) < r.quorum || validity <= 0 {
+		return false, nil
+	}
+	r.mu.Lock()
+	r.validUntil = start.Add(validity)
+	r.mu.Unlock()
+	return true, nil
+}
+
+// Unlock release the lock on all nodes, it returns true if the majority are released.
+func (r *Redlock) Unlock(ctx context.Context) (bool, error) {
+	results, errs := r.eachNode(ctx, r.opts.ttl, func(ctx context.Context, client *redis.Client) (int64, error) {
+		return unlockScript.Run(ctx, client, []string{r.key}, r.token).Int64()
+	})
+	if len(errs) == len(r.clients) {
+		return false, errs[0]
+	}
+	return countOK(results) >= r.quorum, nil
+}
+
+// FencingToken return the fencing token of the last acquisition
+func (r *Redlock) FencingToken() int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.fence
+}
+
+// ValidUntil return the time the lock is valid until, considering the clock drift
+func (r *Redlock) ValidUntil() time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.validUntil
+}
+
+// eachNode call fn on all nodes concurrently with a small timeout,
+// so that a down node will not block the acquisition.
+func (r *Redlock) eachNode(ctx context.Context, ttl time.Duration,
+	fn func(ctx context.Context, client *redis.Client) (int64, error)) ([]int64, []error) {
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		results = make([]int64, len(r.clients))
+		errs    = make([]error, 0)
+	)
+	for i, client := range r.clients {
+		wg.Add(1)
+		go func(i int, client *redis.Client) {
+			defer wg.Done()
+			nodeCtx, cancel := context.WithTimeout(ctx, nodeTimeout(ttl))
+			defer cancel()
+
+			ret, err := fn(nodeCtx, client)
+			if err != nil && err != redis.Nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+				return
+			}
+			results[i] = ret
+		}(i, client)
+	}
+	wg.Wait()
+	return results, errs
+}
+
+// nodeTimeout the timeout of a single node should be small compared to ttl
+func nodeTimeout(ttl time.Duration) time.Duration {
+	timeout := ttl / 10
+	if timeout < 5*time.Millisecond {
+		timeout = 5 * time.Millisecond
+	}
+	return timeout
+}
+
+func countOK(results []int64) int {
+	n := 0
+	for _, ret := range results {
+		if ret > 0 {
+			n++
+		}
+	}
+	return n
+}
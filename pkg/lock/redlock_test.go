@@ -0,0 +1,112 @@
+package lock
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	goredis "github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRedisNodes(t *testing.T, n int) ([]*miniredis.Miniredis, []*goredis.Client) {
+	servers := make([]*miniredis.Miniredis, 0, n)
+	clients := make([]*goredis.Client, 0, n)
+	for i := 0; i < n; i++ {
+		mr, err := miniredis.Run()
+		require.NoError(t, err)
+		t.Cleanup(mr.Close)
+		servers = append(servers, mr)
+		clients = append(clients, goredis.NewClient(&goredis.Options{Addr: mr.Addr(), MaxRetries: -1}))
+	}
+	return servers, clients
+}
+
+func TestRedlock(t *testing.T) {
+	ctx := context.Background()
+	_, clients := newRedisNodes(t, 5)
+
+	lock1 := NewRedlock(clients, "redlock")
+	lock2 := NewRedlock(clients, "redlock")
+
+	fence1, err := lock1.LockWithToken(ctx, time.Second)
+	assert.NoError(t, err)
+	assert.True(t, fence1 > 0)
+	assert.True(t, lock1.ValidUntil().After(time.Now()))
+
+	ok, err := lock2.Lock(ctx, time.Second)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = lock1.Refresh(ctx, time.Second)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = lock1.Unlock(ctx)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	fence2, err := lock2.LockWithToken(ctx, time.Second)
+	assert.NoError(t, err)
+	assert.True(t, fence2 > fence1)
+	assert.Equal(t, fence2, lock2.FencingToken())
+}
+
+func TestRedlock_Quorum(t *testing.T) {
+	ctx := context.Background()
+	servers, clients := newRedisNodes(t, 5)
+
+	// 2 of 5 nodes are down, the lock still works
+	servers[0].Close()
+	servers[1].Close()
+	lock := NewRedlock(clients, "quorum-lock")
+	ok, err := lock.Lock(ctx, time.Second)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	_, _ = lock.Unlock(ctx)
+
+	// 3 of 5 nodes are down, the lock can not be acquired
+	servers[2].Close()
+	ok, _ = lock.Lock(ctx, time.Second)
+	assert.False(t, ok)
+}
+
+func TestRedlock_FencingTokenMonotonic(t *testing.T) {
+	ctx := context.Background()
+	servers, clients := newRedisNodes(t, 3)
+
+	// make the counter of node 0 much larger than the others
+	servers[0].Set(getFenceKey(getRedisKey("fence-lock")), "100")
+
+	lock := NewRedlock(clients, "fence-lock")
+	fence1, _ := lock.LockWithToken(ctx, time.Second)
+	assert.Equal(t, int64(101), fence1)
+	_, _ = lock.Unlock(ctx)
+
+	// node 0 is down, the token issued by the other quorum is still larger
+	servers[0].Close()
+	fence2, _ := lock.LockWithToken(ctx, time.Second)
+	assert.True(t, fence2 > fence1)
+}
+
+func TestRedisLock_FencingToken(t *testing.T) {
+	ctx := context.Background()
+	_, clients := newRedisNodes(t, 1)
+
+	lock := NewRedisLock(clients[0], "fence-lock")
+	fence1, err := lock.LockWithToken(ctx, time.Second)
+	assert.NoError(t, err)
+	_, _ = lock.Unlock(ctx)
+	fence2, err := lock.LockWithToken(ctx, time.Second)
+	assert.NoError(t, err)
+	assert.True(t, fence2 > fence1)
+
+	// reentrant lock keeps the token
+	reentrant := NewRedisLock(clients[0], "reentrant-fence-lock", WithReentrant("owner"))
+	fence3, _ := reentrant.LockWithToken(ctx, time.Second)
+	fence4, _ := reentrant.LockWithToken(ctx, time.Second)
+	assert.True(t, fence3 > 0)
+	assert.Equal(t, fence3, fence4)
+}
@@ -15,4 +15,21 @@ type Config struct {
 	LogFormatText     bool
 	LogRollingPolicy  string
 	LogBackupCount    uint
+	// Sampling limit the repetitive messages, it's disabled if Initial is 0
+	Sampling SamplingConfig
+	// PackageLevels override the level of the logs by the package
+	PackageLevels []PackageLevel
+}
+
+// SamplingConfig the first Initial entries with the same level and message are logged per second,
+// then every Thereafter-th of them, 0 drops all of them.
+type SamplingConfig struct {
+	Initial    int
+	Thereafter int
+}
+
+// PackageLevel the level of a package and its sub packages, eg: github.com/go-eagle/eagle/pkg/redis
+type PackageLevel struct {
+	Package string
+	Level   string
 }
@@ -0,0 +1,73 @@
+package log
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+const (
+	// ContextRequestIDKey the key of the request id in gin.Context
+	ContextRequestIDKey = "request_id"
+	// ContextUIDKey the key of the uid in gin.Context
+	ContextUIDKey = "uid"
+)
+
+type (
+	requestIDKey struct{}
+	uidKey       struct{}
+)
+
+// NewRequestIDContext returns a new context with the request id, it's added to the structured logs
+func NewRequestIDContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// NewUIDContext returns a new context with the uid, it's added to the structured logs
+func NewUIDContext(ctx context.Context, uid uint64) context.Context {
+	return context.WithValue(ctx, uidKey{}, uid)
+}
+
+// contextFields the trace id, span id, request id and uid in the context
+func contextFields(ctx context.Context) []Field {
+	if ctx == nil {
+		return nil
+	}
+	// NOTE: gin.Context doesn't fallback to the context of the request
+	reqCtx := ctx
+	if req, ok := ctx.Value(0).(*http.Request); ok && req != nil {
+		reqCtx = req.Context()
+	}
+
+	var fields []Field
+	spanCtx := trace.SpanContextFromContext(ctx)
+	if !spanCtx.IsValid() {
+		spanCtx = trace.SpanContextFromContext(reqCtx)
+	}
+	if spanCtx.IsValid() {
+		fields = append(fields,
+			zap.String("trace_id", spanCtx.TraceID().String()),
+			zap.String("span_id", spanCtx.SpanID().String()),
+		)
+	}
+	if id := value(ctx, reqCtx, requestIDKey{}, ContextRequestIDKey); id != nil {
+		fields = append(fields, zap.Any("request_id", id))
+	}
+	if uid := value(ctx, reqCtx, uidKey{}, ContextUIDKey); uid != nil {
+		fields = append(fields, zap.Any("uid", uid))
+	}
+	return fields
+}
+
+// value find the value by the typed key, then the string key of gin.Context
+func value(ctx, reqCtx context.Context, key interface{}, ginKey string) interface{} {
+	if v := ctx.Value(key); v != nil {
+		return v
+	}
+	if v := reqCtx.Value(key); v != nil {
+		return v
+	}
+	return ctx.Value(ginKey)
+}
@@ -0,0 +1,60 @@
+package log
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Field is a key value of the structured logs, eg: log.Info(ctx, "msg", log.String("k", "v"))
+type Field = zap.Field
+
+// String a string field
+func String(key, val string) Field {
+	return zap.String(key, val)
+}
+
+// Int an int field
+func Int(key string, val int) Field {
+	return zap.Int(key, val)
+}
+
+// Int64 an int64 field
+func Int64(key string, val int64) Field {
+	return zap.Int64(key, val)
+}
+
+// Uint64 an uint64 field
+func Uint64(key string, val uint64) Field {
+	return zap.Uint64(key, val)
+}
+
+// Float64 a float64 field
+func Float64(key string, val float64) Field {
+	return zap.Float64(key, val)
+}
+
+// Bool a bool field
+func Bool(key string, val bool) Field {
+	return zap.Bool(key, val)
+}
+
+// Duration a duration field
+func Duration(key string, val time.Duration) Field {
+	return zap.Duration(key, val)
+}
+
+// Time a time field
+func Time(key string, val time.Time) Field {
+	return zap.Time(key, val)
+}
+
+// Any a field of any value, it's marshaled by the type
+func Any(key string, val interface{}) Field {
+	return zap.Any(key, val)
+}
+
+// Err the error field, the key is error
+func Err(err error) Field {
+	return zap.Error(err)
+}
@@ -36,10 +36,16 @@ type Logger interface {
 	WithFields(keyValues Fields) Logger
 }
 
-// loadConf load logger config
+// loadConf load logger config, the levels are changed at runtime when the file is changed
 func loadConf() (ret *Config, err error) {
 	var cfg Config
-	if err := config.Load("logger", &cfg); err != nil {
+	_, err = config.Watch("logger", &cfg, func(old, new interface{}) {
+		if o, n := old.(*Config), new.(*Config); o.Level != n.Level {
+			SetLevel(n.Level)
+		}
+		setPackageLevels(new.(*Config).PackageLevels)
+	})
+	if err != nil {
 		return nil, err
 	}
 	return &cfg, nil
@@ -71,6 +77,9 @@ func Init() Logger {
 		_ = fmt.Errorf("init logger err: %v", err)
 	}
 
+	ctxLogger.Store(newContextLogger(zl))
+	setPackageLevels(cfg.PackageLevels)
+
 	return log
 }
 
@@ -97,23 +106,43 @@ func WithContext(ctx context.Context) Logger {
 	return GetLogger()
 }
 
-// Debug logger
+// Debug logger, it supports the structured logs, see Info
 func Debug(args ...interface{}) {
+	if ctx, msg, fields, ok := structured(args); ok {
+		logContext(ctx, zapcore.DebugLevel, msg, fields)
+		return
+	}
 	log.Debug(args...)
 }
 
-// Info logger
+// Info logger, the structured logs are written if the first arg is a context, eg:
+//
+//	log.Info(ctx, "user created", log.Uint64("uid", uid), log.Err(err))
+//
+// the trace id, span id, request id and uid in the context are added to the fields.
 func Info(args ...interface{}) {
+	if ctx, msg, fields, ok := structured(args); ok {
+		logContext(ctx, zapcore.InfoLevel, msg, fields)
+		return
+	}
 	log.Info(args...)
 }
 
-// Warn logger
+// Warn logger, it supports the structured logs, see Info
 func Warn(args ...interface{}) {
+	if ctx, msg, fields, ok := structured(args); ok {
+		logContext(ctx, zapcore.WarnLevel, msg, fields)
+		return
+	}
 	log.Warn(args...)
 }
 
-// Error logger
+// Error logger, it supports the structured logs, see Info
 func Error(args ...interface{}) {
+	if ctx, msg, fields, ok := structured(args); ok {
+		logContext(ctx, zapcore.ErrorLevel, msg, fields)
+		return
+	}
 	log.Error(args...)
 }
 
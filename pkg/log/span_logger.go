@@ -22,35 +22,45 @@ func (sl spanLogger) Debug(args ...interface{}) {
 	msg := fmt.Sprint(args...)
 	var fields []zap.Field
 	sl.logToSpan("debug", msg)
-	sl.logger.Debug(msg, append(sl.spanFields, fields...)...)
+	if sl.enabled(zapcore.DebugLevel) {
+		sl.logger.Debug(msg, append(sl.spanFields, fields...)...)
+	}
 }
 
 func (sl spanLogger) Debugf(format string, args ...interface{}) {
 	msg := fmt.Sprintf(format, args...)
 	var fields []zap.Field
 	sl.logToSpan("Debugf", msg)
-	sl.logger.Debug(msg, append(sl.spanFields, fields...)...)
+	if sl.enabled(zapcore.DebugLevel) {
+		sl.logger.Debug(msg, append(sl.spanFields, fields...)...)
+	}
 }
 
 func (sl spanLogger) Info(args ...interface{}) {
 	msg := fmt.Sprint(args...)
 	var fields []zap.Field
 	sl.logToSpan("info", msg)
-	sl.logger.Info(msg, append(sl.spanFields, fields...)...)
+	if sl.enabled(zapcore.InfoLevel) {
+		sl.logger.Info(msg, append(sl.spanFields, fields...)...)
+	}
 }
 
 func (sl spanLogger) Infof(format string, args ...interface{}) {
 	msg := fmt.Sprint(format, args)
 	var fields []zap.Field
 	sl.logToSpan("Infof", msg)
-	sl.logger.Info(msg, append(sl.spanFields, fields...)...)
+	if sl.enabled(zapcore.InfoLevel) {
+		sl.logger.Info(msg, append(sl.spanFields, fields...)...)
+	}
 }
 
 func (sl spanLogger) Warn(args ...interface{}) {
 	msg := fmt.Sprint(args...)
 	var fields []zap.Field
 	sl.logToSpan("warn", msg)
-	sl.logger.Warn(msg, append(sl.spanFields, fields...)...)
+	if sl.enabled(zapcore.WarnLevel) {
+		sl.logger.Warn(msg, append(sl.spanFields, fields...)...)
+	}
 }
 
 func (sl spanLogger) Warnf(format string, args ...interface{}) {
@@ -58,7 +68,9 @@ func (sl spanLogger) Warnf(format string, args ...interface{}) {
 	var fields []zap.Field
 	sl.logToSpan("Warnf", msg)
 	sl.span.RecordError(errors.New(msg))
-	sl.logger.Warn(msg, append(sl.spanFields, fields...)...)
+	if sl.enabled(zapcore.WarnLevel) {
+		sl.logger.Warn(msg, append(sl.spanFields, fields...)...)
+	}
 }
 
 func (sl spanLogger) Error(args ...interface{}) {
@@ -66,7 +78,9 @@ func (sl spanLogger) Error(args ...interface{}) {
 	var fields []zap.Field
 	sl.logToSpan("error", msg)
 	sl.span.RecordError(errors.New(msg))
-	sl.logger.Error(msg, append(sl.spanFields, fields...)...)
+	if sl.enabled(zapcore.ErrorLevel) {
+		sl.logger.Error(msg, append(sl.spanFields, fields...)...)
+	}
 }
 
 func (sl spanLogger) Errorf(format string, args ...interface{}) {
@@ -74,13 +88,20 @@ func (sl spanLogger) Errorf(format string, args ...interface{}) {
 	var fields []zap.Field
 	sl.logToSpan("Errorf", msg)
 	sl.span.RecordError(errors.New(msg))
-	sl.logger.Error(msg, append(sl.spanFields, fields...)...)
+	if sl.enabled(zapcore.ErrorLevel) {
+		sl.logger.Error(msg, append(sl.spanFields, fields...)...)
+	}
 }
 
 func (sl spanLogger) WithFields(keyValues Fields) Logger {
 	panic("implement me")
 }
 
+// enabled check the level by the package of the caller of the logger
+func (sl spanLogger) enabled(lvl zapcore.Level) bool {
+	return enabled(lvl, 2)
+}
+
 func (sl spanLogger) logToSpan(level string, msg string) {
 	sl.span.SetAttributes(
 		attribute.String("event", level),
@@ -0,0 +1,150 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// ctxSkip the structured logs are written by logContext, which is called by Debug, Info, etc.
+const ctxSkip = 2
+
+// ctxLogger the logger of the structured logs, the level is checked by enabled with the package levels.
+var ctxLogger atomic.Value
+
+// packageLevels the levels by the package, the longest package is matched first
+var packageLevels atomic.Value
+
+// callerPackages the package of the callers by pc
+var callerPackages sync.Map
+
+type packageLevel struct {
+	pkg   string
+	level zapcore.Level
+}
+
+// coreLevel the level of the console output, it's lowered by the package levels,
+// so the loggers check the level by enabled before the logs are written.
+var coreLevel = zap.LevelEnablerFunc(func(lvl zapcore.Level) bool {
+	if level.Enabled(lvl) {
+		return true
+	}
+	items, _ := packageLevels.Load().([]packageLevel)
+	for _, item := range items {
+		if lvl >= item.level {
+			return true
+		}
+	}
+	return false
+})
+
+// newContextLogger the logger of the structured logs, the cores of l are shared
+func newContextLogger(l *zap.Logger) *zap.Logger {
+	return l.WithOptions(zap.AddCallerSkip(ctxSkip - defaultSkip))
+}
+
+// SetPackageLevels override the level of the logs by the package, eg:
+//
+//	log.SetPackageLevels(map[string]string{"github.com/go-eagle/eagle/pkg/redis": "warn"})
+//
+// the sub packages are included, the others use the level of the logger.
+func SetPackageLevels(levels map[string]string) {
+	items := make([]packageLevel, 0, len(levels))
+	for pkg, l := range levels {
+		items = append(items, packageLevel{pkg: pkg, level: getLoggerLevel(&Config{Level: l})})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return len(items[i].pkg) > len(items[j].pkg)
+	})
+	packageLevels.Store(items)
+}
+
+func setPackageLevels(levels []PackageLevel) {
+	m := make(map[string]string, len(levels))
+	for _, l := range levels {
+		m[l.Package] = l.Level
+	}
+	SetPackageLevels(m)
+}
+
+// enabled check the level by the package of the caller, skip is the frames above enabled
+func enabled(lvl zapcore.Level, skip int) bool {
+	items, _ := packageLevels.Load().([]packageLevel)
+	if len(items) > 0 {
+		if pkg := callerPackage(skip + 1); pkg != "" {
+			for _, item := range items {
+				if pkg == item.pkg || strings.HasPrefix(pkg, item.pkg+"/") {
+					return lvl >= item.level
+				}
+			}
+		}
+	}
+	return level.Enabled(lvl)
+}
+
+// callerPackage the package of the caller, eg: github.com/go-eagle/eagle/pkg/redis
+func callerPackage(skip int) string {
+	pc, _, _, ok := runtime.Caller(skip + 1)
+	if !ok {
+		return ""
+	}
+	if pkg, ok := callerPackages.Load(pc); ok {
+		return pkg.(string)
+	}
+	fn := runtime.FuncForPC(pc)
+	if fn == nil {
+		return ""
+	}
+	// eg: github.com/go-eagle/eagle/pkg/redis.(*Client).Get
+	name := fn.Name()
+	slash := strings.LastIndexByte(name, '/')
+	pkg := name
+	if i := strings.IndexByte(name[slash+1:], '.'); i >= 0 {
+		pkg = name[:slash+1+i]
+	}
+	callerPackages.Store(pc, pkg)
+	return pkg
+}
+
+// structured check if the args are in the form of (ctx, msg, fields...)
+func structured(args []interface{}) (context.Context, string, []Field, bool) {
+	if len(args) < 2 {
+		return nil, "", nil, false
+	}
+	ctx, ok := args[0].(context.Context)
+	if !ok {
+		return nil, "", nil, false
+	}
+	msg, ok := args[1].(string)
+	if !ok {
+		return nil, "", nil, false
+	}
+	fields := make([]Field, 0, len(args)-2)
+	for i, arg := range args[2:] {
+		if f, ok := arg.(Field); ok {
+			fields = append(fields, f)
+			continue
+		}
+		fields = append(fields, zap.Any(fmt.Sprintf("arg%d", i), arg))
+	}
+	return ctx, msg, fields, true
+}
+
+// logContext write a structured log with the fields of the context,
+// it must be called by Debug, Info, etc. directly for the caller.
+func logContext(ctx context.Context, lvl zapcore.Level, msg string, fields []Field) {
+	l, _ := ctxLogger.Load().(*zap.Logger)
+	if l == nil || !enabled(lvl, 2) {
+		return
+	}
+	if ce := l.Check(lvl, msg); ce != nil {
+		ce.Write(append(contextFields(ctx), fields...)...)
+	}
+}
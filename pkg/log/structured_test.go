@@ -0,0 +1,151 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func observe(t *testing.T) *observer.ObservedLogs {
+	core, logs := observer.New(zapcore.DebugLevel)
+	ctxLogger.Store(zap.New(core))
+	SetLevel("info")
+	t.Cleanup(func() {
+		ctxLogger.Store((*zap.Logger)(nil))
+		SetPackageLevels(nil)
+	})
+	return logs
+}
+
+func TestInfo_Context(t *testing.T) {
+	logs := observe(t)
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	}))
+	ctx = NewRequestIDContext(ctx, "req-1")
+	ctx = NewUIDContext(ctx, 10)
+
+	Info(ctx, "user created", String("name", "eagle"), Err(errors.New("oops")), 1)
+	Debug(ctx, "dropped by the level")
+
+	require.Equal(t, 1, logs.Len())
+	entry := logs.All()[0]
+	assert.Equal(t, "user created", entry.Message)
+	assert.Equal(t, zapcore.InfoLevel, entry.Level)
+	assert.Equal(t, map[string]interface{}{
+		"trace_id":   "4bf92f3577b34da6a3ce929d0e0e4736",
+		"span_id":    "00f067aa0ba902b7",
+		"request_id": "req-1",
+		"uid":        uint64(10),
+		"name":       "eagle",
+		"error":      "oops",
+		"arg2":       int64(1),
+	}, entry.ContextMap())
+}
+
+func TestInfo_GinContext(t *testing.T) {
+	logs := observe(t)
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/", nil)
+	c.Request = c.Request.WithContext(NewUIDContext(c.Request.Context(), 10))
+	c.Set(ContextRequestIDKey, "req-1")
+
+	Warn(c, "slow request")
+
+	require.Equal(t, 1, logs.Len())
+	assert.Equal(t, map[string]interface{}{
+		"request_id": "req-1",
+		"uid":        uint64(10),
+	}, logs.All()[0].ContextMap())
+}
+
+func TestSetPackageLevels(t *testing.T) {
+	logs := observe(t)
+
+	SetPackageLevels(map[string]string{"github.com/go-eagle/eagle/pkg/log": "error"})
+	Warn(context.Background(), "dropped by the package level")
+	Error(context.Background(), "logged")
+	assert.Equal(t, 1, logs.Len())
+
+	// the longest package is matched first
+	SetPackageLevels(map[string]string{
+		"github.com/go-eagle/eagle/pkg":     "error",
+		"github.com/go-eagle/eagle/pkg/log": "debug",
+	})
+	Debug(context.Background(), "logged by the package level")
+	assert.Equal(t, 2, logs.Len())
+
+	// the other packages
+	SetPackageLevels(map[string]string{"github.com/go-eagle/eagle/pkg/redis": "debug"})
+	Debug(context.Background(), "dropped by the level")
+	assert.Equal(t, 2, logs.Len())
+}
+
+func TestSetPackageLevels_Logger(t *testing.T) {
+	core, logs := observer.New(coreLevel)
+	oldLog, oldLogger := log, logger
+	log = &zapLogger{sugarLogger: zap.New(core).Sugar(), skip: 1}
+	logger = &zapLogger{sugarLogger: zap.New(core).Sugar()}
+	SetLevel("info")
+	defer func() {
+		log, logger = oldLog, oldLogger
+		SetPackageLevels(nil)
+	}()
+
+	SetPackageLevels(map[string]string{"github.com/go-eagle/eagle/pkg/log": "debug"})
+	Debugf("logged by the package level %d", 1)
+	WithFields(Fields{"key": "value"}).Debug("logged by the package level")
+	assert.Equal(t, 2, logs.Len())
+
+	SetPackageLevels(map[string]string{"github.com/go-eagle/eagle/pkg/log": "error"})
+	Infof("dropped by the package level")
+	WithFields(Fields{"key": "value"}).Warn("dropped by the package level")
+	assert.Equal(t, 2, logs.Len())
+
+	// the other packages
+	SetPackageLevels(map[string]string{"github.com/go-eagle/eagle/pkg/redis": "debug"})
+	Debugf("dropped by the level")
+	Infof("logged")
+	assert.Equal(t, 3, logs.Len())
+}
+
+func TestSampling(t *testing.T) {
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	stdout := os.Stdout
+	os.Stdout = w
+	l := newContextLogger(buildLogger(&Config{Writers: WriterConsole, Sampling: SamplingConfig{Initial: 2, Thereafter: 3}}, defaultSkip))
+	os.Stdout = stdout
+	ctxLogger.Store(l)
+	SetLevel("info")
+	defer ctxLogger.Store((*zap.Logger)(nil))
+
+	for i := 0; i < 8; i++ {
+		Info(context.Background(), "repetitive message")
+	}
+	Info(context.Background(), "another message")
+	_ = w.Close()
+
+	out, err := ioutil.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, 4, strings.Count(string(out), "repetitive message"))
+	assert.Equal(t, 1, strings.Count(string(out), "another message"))
+}
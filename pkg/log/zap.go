@@ -41,6 +41,9 @@ var loggerLevelMap = map[string]zapcore.Level{
 	"fatal":  zapcore.FatalLevel,
 }
 
+// level the level of the loggers, it can be changed at runtime by SetLevel
+var level = zap.NewAtomicLevel()
+
 // Prevent data race from occurring during zap.AddStacktrace
 var zapStacktraceMutex sync.Mutex
 
@@ -53,9 +56,16 @@ func getLoggerLevel(cfg *Config) zapcore.Level {
 	return level
 }
 
+// SetLevel change the level of the loggers, eg: debug, info, warn, error
+func SetLevel(l string) {
+	level.SetLevel(getLoggerLevel(&Config{Level: l}))
+}
+
 // zapLogger logger struct
 type zapLogger struct {
 	sugarLogger *zap.SugaredLogger
+	// skip the frames above the caller, it's used to find the package of the caller
+	skip int
 }
 
 // newZapLogger new zap logger
@@ -65,7 +75,7 @@ func newZapLogger(cfg *Config) (*zap.Logger, error) {
 
 // newLoggerWithCallerSkip new logger with caller skip
 func newLoggerWithCallerSkip(cfg *Config, skip int) (Logger, error) {
-	return &zapLogger{sugarLogger: buildLogger(cfg, defaultSkip+skip).Sugar()}, nil
+	return &zapLogger{sugarLogger: buildLogger(cfg, defaultSkip+skip).Sugar(), skip: skip}, nil
 }
 
 // newLogger new logger
@@ -74,6 +84,8 @@ func newLogger(cfg *Config) (Logger, error) {
 }
 
 func buildLogger(cfg *Config, skip int) *zap.Logger {
+	level.SetLevel(getLoggerLevel(cfg))
+
 	var encoderCfg zapcore.EncoderConfig
 	if cfg.Development {
 		encoderCfg = zap.NewDevelopmentEncoderConfig()
@@ -104,7 +116,7 @@ func buildLogger(cfg *Config, skip int) *zap.Logger {
 	for _, w := range writers {
 		switch w {
 		case WriterConsole:
-			cores = append(cores, zapcore.NewCore(encoder, zapcore.AddSync(os.Stdout), getLoggerLevel(cfg)))
+			cores = append(cores, zapcore.NewCore(encoder, zapcore.AddSync(os.Stdout), coreLevel))
 		case WriterFile:
 			// info
 			cores = append(cores, getInfoCore(encoder, cfg))
@@ -124,13 +136,16 @@ func buildLogger(cfg *Config, skip int) *zap.Logger {
 			}
 		default:
 			// console
-			cores = append(cores, zapcore.NewCore(encoder, zapcore.AddSync(os.Stdout), getLoggerLevel(cfg)))
+			cores = append(cores, zapcore.NewCore(encoder, zapcore.AddSync(os.Stdout), coreLevel))
 			// file
 			cores = append(cores, getAllCore(encoder, cfg))
 		}
 	}
 
 	combinedCore := zapcore.NewTee(cores...)
+	if cfg.Sampling.Initial > 0 {
+		combinedCore = zapcore.NewSamplerWithOptions(combinedCore, time.Second, cfg.Sampling.Initial, cfg.Sampling.Thereafter)
+	}
 
 	// Open development mode, stack trace
 	if !cfg.DisableCaller {
@@ -218,21 +233,33 @@ func getLogWriterWithTime(cfg *Config, filename string) io.Writer {
 
 // Debug logger
 func (l *zapLogger) Debug(args ...interface{}) {
+	if !l.enabled(zapcore.DebugLevel) {
+		return
+	}
 	l.sugarLogger.Debug(args...)
 }
 
 // Info logger
 func (l *zapLogger) Info(args ...interface{}) {
+	if !l.enabled(zapcore.InfoLevel) {
+		return
+	}
 	l.sugarLogger.Info(args...)
 }
 
 // Warn logger
 func (l *zapLogger) Warn(args ...interface{}) {
+	if !l.enabled(zapcore.WarnLevel) {
+		return
+	}
 	l.sugarLogger.Warn(args...)
 }
 
 // Error logger
 func (l *zapLogger) Error(args ...interface{}) {
+	if !l.enabled(zapcore.ErrorLevel) {
+		return
+	}
 	l.sugarLogger.Error(args...)
 }
 
@@ -241,18 +268,30 @@ func (l *zapLogger) Fatal(args ...interface{}) {
 }
 
 func (l *zapLogger) Debugf(format string, args ...interface{}) {
+	if !l.enabled(zapcore.DebugLevel) {
+		return
+	}
 	l.sugarLogger.Debugf(format, args...)
 }
 
 func (l *zapLogger) Infof(format string, args ...interface{}) {
+	if !l.enabled(zapcore.InfoLevel) {
+		return
+	}
 	l.sugarLogger.Infof(format, args...)
 }
 
 func (l *zapLogger) Warnf(format string, args ...interface{}) {
+	if !l.enabled(zapcore.WarnLevel) {
+		return
+	}
 	l.sugarLogger.Warnf(format, args...)
 }
 
 func (l *zapLogger) Errorf(format string, args ...interface{}) {
+	if !l.enabled(zapcore.ErrorLevel) {
+		return
+	}
 	l.sugarLogger.Errorf(format, args...)
 }
 
@@ -271,5 +310,10 @@ func (l *zapLogger) WithFields(fields Fields) Logger {
 		f = append(f, v)
 	}
 	newLogger := l.sugarLogger.With(f...)
-	return &zapLogger{newLogger}
+	return &zapLogger{sugarLogger: newLogger, skip: l.skip}
+}
+
+// enabled check the level by the package of the caller of the logger
+func (l *zapLogger) enabled(lvl zapcore.Level) bool {
+	return enabled(lvl, 2+l.skip)
 }
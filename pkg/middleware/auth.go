@@ -5,6 +5,7 @@ import (
 
 	"github.com/go-eagle/eagle/pkg/app"
 	"github.com/go-eagle/eagle/pkg/errcode"
+	"github.com/go-eagle/eagle/pkg/log"
 )
 
 // Auth authorize user
@@ -20,6 +21,7 @@ func Auth() gin.HandlerFunc {
 
 		// set uid to context
 		c.Set("uid", ctx.UserID)
+		c.Request = c.Request.WithContext(log.NewUIDContext(c.Request.Context(), ctx.UserID))
 
 		c.Next()
 	}
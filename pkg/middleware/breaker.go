@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -10,11 +11,29 @@ import (
 
 	"github.com/go-eagle/eagle/pkg/app"
 	"github.com/go-eagle/eagle/pkg/container/group"
+	"github.com/go-eagle/eagle/pkg/errcode"
+	"github.com/go-eagle/eagle/pkg/metric"
 )
 
 // ErrNotAllowed is request failed due to circuit breaker triggered.
 var ErrNotAllowed = errors.New("[BREAKER] request failed due to circuit breaker is open")
 
+const (
+	// breakerClosed the requests are allowed
+	breakerClosed = 0
+	// breakerOpen the requests are rejected by the breaker
+	breakerOpen = 1
+)
+
+// nolint
+var _metricBreakerState = metric.NewGaugeVec(&metric.GaugeVecOpts{
+	Namespace: namespace,
+	Subsystem: "http_server",
+	Name:      "breaker_state",
+	Help:      "http server circuit breaker state, 0: closed, 1: open.",
+	Labels:    []string{"route"},
+})
+
 // BreakerOption is circuit breaker option.
 type BreakerOption func(*options)
 
@@ -26,40 +45,92 @@ func WithGroup(g *group.Group) BreakerOption {
 	}
 }
 
+// WithFailureCodes mark the requests failed with the errcode codes as failures,
+// besides the 5xx status and the default codes.
+func WithFailureCodes(codes ...int) BreakerOption {
+	return func(o *options) {
+		for _, code := range codes {
+			o.failureCodes[code] = struct{}{}
+		}
+	}
+}
+
+// WithFallback with the response of the rejected requests,
+// default is errcode.ErrServiceUnavailable.
+func WithFallback(fallback gin.HandlerFunc) BreakerOption {
+	return func(o *options) {
+		o.fallback = fallback
+	}
+}
+
 type options struct {
-	group *group.Group
+	group        *group.Group
+	failureCodes map[int]struct{}
+	fallback     gin.HandlerFunc
 }
 
-// Breaker a circuit breaker middleware
+// Breaker a circuit breaker middleware, the result is observed after the handler
 func Breaker(opts ...BreakerOption) gin.HandlerFunc {
 	opt := &options{
 		group: group.NewGroup(func() interface{} {
 			return sre.NewBreaker()
 		}),
+		failureCodes: map[int]struct{}{
+			errcode.ErrInternalServer.Code():     {},
+			errcode.ErrDeadlineExceeded.Code():   {},
+			errcode.ErrDatabase.Code():           {},
+			errcode.ErrServiceUnavailable.Code(): {},
+		},
+		fallback: func(c *gin.Context) {
+			app.NewResponse().Error(c, errcode.ErrServiceUnavailable)
+		},
 	}
 	for _, o := range opts {
 		o(opt)
 	}
 	return func(c *gin.Context) {
-		breaker := opt.group.Get(c.FullPath()).(circuitbreaker.CircuitBreaker)
+		route := c.FullPath()
+		breaker := opt.group.Get(route).(circuitbreaker.CircuitBreaker)
 		if err := breaker.Allow(); err != nil {
 			// rejected
 			// NOTE: when client reject requets locally,
 			// continue add counter let the drop ratio higher.
 			breaker.MarkFailed()
-			app.NewResponse().Error(c, ErrNotAllowed)
+			_metricBreakerState.Set(breakerOpen, route)
+			_ = c.Error(ErrNotAllowed)
+			opt.fallback(c)
 			c.Abort()
 			return
 		}
-		// allowed
-		code := c.Writer.Status()
-		// NOTE: need to check internal and service unavailable error
-		if code == http.StatusInternalServerError || code == http.StatusServiceUnavailable || code == http.StatusGatewayTimeout {
+		_metricBreakerState.Set(breakerClosed, route)
+
+		c.Next()
+
+		// allowed, mark the result of the handler
+		if isFailure(c, opt.failureCodes) {
 			breaker.MarkFailed()
 		} else {
 			breaker.MarkSuccess()
 		}
+	}
+}
 
-		c.Next()
+// isFailure check the result of the handler, the errcode takes precedence over the status,
+// so the business errors are not failures even if they are responded with 500.
+func isFailure(c *gin.Context, failureCodes map[int]struct{}) bool {
+	for i := len(c.Errors) - 1; i >= 0; i-- {
+		var ecode *errcode.Error
+		if errors.As(c.Errors[i].Err, &ecode) {
+			_, ok := failureCodes[ecode.Code()]
+			return ok
+		}
+	}
+	if errors.Is(c.Request.Context().Err(), context.DeadlineExceeded) {
+		return true
+	}
+	switch c.Writer.Status() {
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
 	}
+	return false
 }
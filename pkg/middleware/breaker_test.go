@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-kratos/aegis/circuitbreaker/sre"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/go-eagle/eagle/pkg/app"
+	"github.com/go-eagle/eagle/pkg/container/group"
+	"github.com/go-eagle/eagle/pkg/errcode"
+)
+
+func newBreakerRouter(opts ...BreakerOption) *gin.Engine {
+	opts = append([]BreakerOption{WithGroup(group.NewGroup(func() interface{} {
+		return sre.NewBreaker(sre.WithRequest(5))
+	}))}, opts...)
+
+	router := gin.New()
+	router.Use(Breaker(opts...))
+	router.GET("/status/:code", func(c *gin.Context) {
+		switch c.Param("code") {
+		case "500":
+			c.Status(http.StatusInternalServerError)
+		case "database":
+			app.Error(c, errcode.ErrDatabase)
+		case "notfound":
+			app.Error(c, errcode.ErrNotFound)
+		case "validation":
+			app.Error(c, errcode.ErrValidation)
+		default:
+			c.Status(http.StatusOK)
+		}
+	})
+	return router
+}
+
+// tripped send the requests until the breaker is open
+func tripped(router *gin.Engine, path string) *httptest.ResponseRecorder {
+	for i := 0; i < 100; i++ {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code == http.StatusServiceUnavailable {
+			return w
+		}
+	}
+	return nil
+}
+
+func TestBreaker(t *testing.T) {
+	// the failures observed after the handler open the breaker
+	assert.NotNil(t, tripped(newBreakerRouter(), "/status/500"))
+	assert.NotNil(t, tripped(newBreakerRouter(), "/status/database"))
+	// the business errors are not failures
+	assert.Nil(t, tripped(newBreakerRouter(), "/status/notfound"))
+	assert.Nil(t, tripped(newBreakerRouter(), "/status/200"))
+}
+
+func TestBreaker_WithFailureCodes(t *testing.T) {
+	router := newBreakerRouter(WithFailureCodes(errcode.ErrValidation.Code()))
+	assert.NotNil(t, tripped(router, "/status/validation"))
+}
+
+func TestBreaker_WithFallback(t *testing.T) {
+	router := newBreakerRouter(WithFallback(func(c *gin.Context) {
+		c.String(http.StatusServiceUnavailable, "fallback")
+	}))
+
+	w := tripped(router, "/status/500")
+	if assert.NotNil(t, w) {
+		assert.Equal(t, "fallback", w.Body.String())
+	}
+}
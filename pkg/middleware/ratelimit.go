@@ -7,6 +7,7 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/go-eagle/eagle/pkg/app"
+	"github.com/go-eagle/eagle/pkg/errcode"
 )
 
 // ErrLimitExceed is service unavailable due to rate limit exceeded.
@@ -23,14 +24,26 @@ func WithLimiter(limiter ratelimit.Limiter) LimiterOption {
 	}
 }
 
+// WithLimitFallback with the response of the rejected requests,
+// default is errcode.ErrTooManyRequests.
+func WithLimitFallback(fallback gin.HandlerFunc) LimiterOption {
+	return func(o *limiterOptions) {
+		o.fallback = fallback
+	}
+}
+
 type limiterOptions struct {
-	limiter ratelimit.Limiter
+	limiter  ratelimit.Limiter
+	fallback gin.HandlerFunc
 }
 
-// Ratelimit a circuit breaker middleware
+// Ratelimit a adaptive rate limit middleware, the result is reported after the handler
 func Ratelimit(opts ...LimiterOption) gin.HandlerFunc {
 	options := &limiterOptions{
 		limiter: bbr.NewLimiter(),
+		fallback: func(c *gin.Context) {
+			app.NewResponse().Error(c, errcode.ErrTooManyRequests)
+		},
 	}
 	for _, o := range opts {
 		o(options)
@@ -39,13 +52,21 @@ func Ratelimit(opts ...LimiterOption) gin.HandlerFunc {
 		done, e := options.limiter.Allow()
 		if e != nil {
 			// rejected
-			app.NewResponse().Error(c, ErrLimitExceed)
+			_ = c.Error(ErrLimitExceed)
+			options.fallback(c)
 			c.Abort()
 			return
 		}
-		// allowed
-		done(ratelimit.DoneInfo{Err: c.Request.Context().Err()})
 
 		c.Next()
+
+		// allowed, report the result of the handler
+		var err error
+		if len(c.Errors) > 0 {
+			err = c.Errors.Last().Err
+		} else if c.Request.Context().Err() != nil {
+			err = c.Request.Context().Err()
+		}
+		done(ratelimit.DoneInfo{Err: err})
 	}
 }
@@ -0,0 +1,123 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+
+	"github.com/go-eagle/eagle/pkg/app"
+	"github.com/go-eagle/eagle/pkg/errcode"
+	"github.com/go-eagle/eagle/pkg/ratelimit"
+)
+
+const (
+	// HeaderRateLimitLimit the quota of the rule
+	HeaderRateLimitLimit = "X-RateLimit-Limit"
+	// HeaderRateLimitRemaining the remaining quota
+	HeaderRateLimitRemaining = "X-RateLimit-Remaining"
+	// HeaderRateLimitReset the seconds until the quota is fully restored
+	HeaderRateLimitReset = "X-RateLimit-Reset"
+	// HeaderRetryAfter the seconds to wait if the request is rejected
+	HeaderRetryAfter = "Retry-After"
+)
+
+type redisRule struct {
+	ratelimit.Rule
+	limiter ratelimit.Limiter
+}
+
+// redisRules the rules built from a config
+type redisRules struct {
+	prefix string
+	rules  []redisRule
+}
+
+func newRedisRules(client *redis.Client, conf *ratelimit.Config) *redisRules {
+	rs := &redisRules{prefix: conf.Prefix, rules: make([]redisRule, 0, len(conf.Rules))}
+	if rs.prefix == "" {
+		rs.prefix = ratelimit.DefaultPrefix
+	}
+	for _, r := range conf.Rules {
+		rs.rules = append(rs.rules, redisRule{Rule: r, limiter: ratelimit.NewLimiter(client, r)})
+	}
+	return rs
+}
+
+// RedisRatelimit limit the requests by the rules of the config,
+// the quota is shared across the replicas by redis.
+// NOTE: the requests are allowed if redis is unavailable.
+func RedisRatelimit(client *redis.Client, conf *ratelimit.Config) gin.HandlerFunc {
+	rs := newRedisRules(client, conf)
+	return redisRatelimit(func() *redisRules { return rs })
+}
+
+// WatchRedisRatelimit same as RedisRatelimit with the ratelimit config file,
+// the rules are replaced when the file is changed.
+func WatchRedisRatelimit(client *redis.Client) (gin.HandlerFunc, error) {
+	var rules atomic.Value
+	conf, err := ratelimit.WatchConf(func(c *ratelimit.Config) {
+		rules.Store(newRedisRules(client, c))
+	})
+	if err != nil {
+		return nil, err
+	}
+	rules.Store(newRedisRules(client, conf))
+	return redisRatelimit(func() *redisRules { return rules.Load().(*redisRules) }), nil
+}
+
+func redisRatelimit(load func() *redisRules) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		path := c.FullPath()
+		if path == "" {
+			c.Next()
+			return
+		}
+		rs := load()
+		for _, r := range rs.rules {
+			if !r.Match(c.Request.Method, path) {
+				continue
+			}
+			key := fmt.Sprintf("%s:%s:%s:%s", rs.prefix, c.Request.Method, path, limitKey(c, r.KeyBy))
+			res, err := r.limiter.Allow(c.Request.Context(), key)
+			if err != nil {
+				_ = c.Error(err)
+				break
+			}
+
+			c.Header(HeaderRateLimitLimit, strconv.FormatInt(res.Limit, 10))
+			c.Header(HeaderRateLimitRemaining, strconv.FormatInt(res.Remaining, 10))
+			c.Header(HeaderRateLimitReset, seconds(res.ResetAfter))
+			if !res.Allowed {
+				c.Header(HeaderRetryAfter, seconds(res.RetryAfter))
+				app.NewResponse().Error(c, errcode.ErrTooManyRequests)
+				c.Abort()
+				return
+			}
+			break
+		}
+		c.Next()
+	}
+}
+
+// limitKey the key of the request by the rule
+func limitKey(c *gin.Context, keyBy string) string {
+	switch keyBy {
+	case ratelimit.KeyByRoute:
+		return "route"
+	case ratelimit.KeyByUID:
+		if uid, ok := c.Get("uid"); ok {
+			return fmt.Sprintf("uid:%v", uid)
+		}
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// seconds round up the duration to seconds
+func seconds(d time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10)
+}
@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/go-eagle/eagle/pkg/ratelimit"
+)
+
+func TestRedisRatelimit(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	defer mr.Close()
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		if uid := c.GetHeader("uid"); uid != "" {
+			c.Set("uid", uid)
+		}
+	}, RedisRatelimit(client, &ratelimit.Config{
+		Rules: []ratelimit.Rule{
+			{Path: "/users/:id", Method: http.MethodGet, KeyBy: ratelimit.KeyByUID, Limit: 1, Period: time.Minute},
+			{Path: "*", Algorithm: ratelimit.AlgorithmSlidingWindow, KeyBy: ratelimit.KeyByRoute, Limit: 2, Period: time.Minute},
+		},
+	}))
+	router.GET("/users/:id", func(c *gin.Context) {})
+	router.GET("/ping", func(c *gin.Context) {})
+
+	send := func(path, uid string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		r.Header.Set("uid", uid)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w
+	}
+
+	// keyed by uid, the route path is shared by the ids
+	w := send("/users/1", "1")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "1", w.Header().Get(HeaderRateLimitLimit))
+	assert.Equal(t, "0", w.Header().Get(HeaderRateLimitRemaining))
+	w = send("/users/2", "1")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "60", w.Header().Get(HeaderRetryAfter))
+	assert.Equal(t, http.StatusOK, send("/users/1", "2").Code)
+
+	// the default rule keyed by route
+	assert.Equal(t, http.StatusOK, send("/ping", "1").Code)
+	assert.Equal(t, http.StatusOK, send("/ping", "2").Code)
+	w = send("/ping", "3")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.NotEmpty(t, w.Header().Get(HeaderRetryAfter))
+
+	// not found routes are not limited
+	assert.Equal(t, http.StatusNotFound, send("/none", "1").Code)
+
+	// allowed if redis is unavailable
+	mr.Close()
+	assert.Equal(t, http.StatusOK, send("/users/1", "3").Code)
+}
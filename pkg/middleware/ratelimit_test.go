@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-kratos/aegis/ratelimit"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/go-eagle/eagle/pkg/app"
+	"github.com/go-eagle/eagle/pkg/errcode"
+)
+
+type testLimiter struct {
+	reject bool
+	done   []ratelimit.DoneInfo
+}
+
+func (l *testLimiter) Allow() (ratelimit.DoneFunc, error) {
+	if l.reject {
+		return nil, ratelimit.ErrLimitExceed
+	}
+	return func(info ratelimit.DoneInfo) {
+		l.done = append(l.done, info)
+	}, nil
+}
+
+func TestRatelimit(t *testing.T) {
+	limiter := &testLimiter{}
+	router := gin.New()
+	router.Use(Ratelimit(WithLimiter(limiter)))
+	router.GET("/ok", func(c *gin.Context) {
+		// the done func is called after the handler
+		assert.Empty(t, limiter.done)
+	})
+	router.GET("/err", func(c *gin.Context) {
+		app.Error(c, errcode.ErrInternalServer)
+	})
+
+	send := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, send("/ok").Code)
+	assert.Equal(t, http.StatusInternalServerError, send("/err").Code)
+	if assert.Len(t, limiter.done, 2) {
+		assert.NoError(t, limiter.done[0].Err)
+		assert.Equal(t, errcode.ErrInternalServer, limiter.done[1].Err)
+	}
+
+	limiter.reject = true
+	assert.Equal(t, http.StatusTooManyRequests, send("/ok").Code)
+}
+
+func TestRatelimit_WithLimitFallback(t *testing.T) {
+	router := gin.New()
+	router.Use(Ratelimit(WithLimiter(&testLimiter{reject: true}), WithLimitFallback(func(c *gin.Context) {
+		c.String(http.StatusServiceUnavailable, "fallback")
+	})))
+	router.GET("/ok", func(c *gin.Context) {})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ok", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "fallback", w.Body.String())
+}
@@ -3,6 +3,8 @@ package middleware
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+
+	"github.com/go-eagle/eagle/pkg/log"
 )
 
 const (
@@ -23,9 +25,10 @@ func RequestID() gin.HandlerFunc {
 		if requestID == "" {
 			requestID = generateID()
 			c.Request.Header.Set(HeaderXRequestIDKey, requestID)
-			// Expose it for use in the application
-			c.Set(ContextRequestIDKey, requestID)
 		}
+		// Expose it for use in the application and the structured logs
+		c.Set(ContextRequestIDKey, requestID)
+		c.Request = c.Request.WithContext(log.NewRequestIDContext(c.Request.Context(), requestID))
 
 		// Set X-Request-ID header
 		c.Writer.Header().Set(HeaderXRequestIDKey, requestID)
@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/go-eagle/eagle/pkg/registry"
+)
+
+// Routing is a middleware that injects the routing hints of 'x-eagle-color' and 'x-eagle-zone'
+// into the request context, so that the clients can route the downstream requests by them.
+func Routing() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r := registry.Route{
+			Color: c.Request.Header.Get(registry.HeaderColor),
+			Zone:  c.Request.Header.Get(registry.HeaderZone),
+		}
+		if !r.IsEmpty() {
+			c.Request = c.Request.WithContext(registry.NewRouteContext(c.Request.Context(), r))
+		}
+		c.Next()
+	}
+}
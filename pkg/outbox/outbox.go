@@ -0,0 +1,88 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/go-eagle/eagle/pkg/queue"
+)
+
+const (
+	// StatusPending the message is waiting to be published
+	StatusPending = 0
+	// StatusSent the message has been published
+	StatusSent = 1
+	// StatusDead the message has failed too many times, it's not published any more
+	StatusDead = 2
+)
+
+// Message a row of outbox table
+type Message struct {
+	ID          uint64     `gorm:"primary_key;AUTO_INCREMENT;column:id"`
+	MessageID   string     `gorm:"column:message_id"`
+	Topic       string     `gorm:"column:topic"`
+	Key         string     `gorm:"column:msg_key"`
+	Headers     string     `gorm:"column:headers"`
+	Payload     []byte     `gorm:"column:payload"`
+	Status      int        `gorm:"column:status"`
+	Attempts    int        `gorm:"column:attempts"`
+	LastError   string     `gorm:"column:last_error"`
+	LockedBy    string     `gorm:"column:locked_by"`
+	LockedUntil *time.Time `gorm:"column:locked_until"`
+	SentAt      *time.Time `gorm:"column:sent_at"`
+	CreatedAt   time.Time  `gorm:"column:created_at"`
+	UpdatedAt   time.Time  `gorm:"column:updated_at"`
+}
+
+// TableName sets the insert table name for this struct type
+func (m *Message) TableName() string {
+	return "outbox"
+}
+
+// Publish write a message to outbox table, tx must be the transaction
+// which changes the business data, so that the message is saved only if it is committed.
+func Publish(ctx context.Context, tx *gorm.DB, topic string, msg *queue.Message) error {
+	headers, err := json.Marshal(msg.Headers)
+	if err != nil {
+		return err
+	}
+
+	return tx.WithContext(ctx).Create(&Message{
+		MessageID: msg.ID,
+		Topic:     topic,
+		Key:       msg.Key,
+		Headers:   string(headers),
+		Payload:   msg.Body,
+		Status:    StatusPending,
+		CreatedAt: msg.Timestamp,
+		UpdatedAt: time.Now(),
+	}).Error
+}
+
+// PublishJSON write a message with a json encoded payload to outbox table
+func PublishJSON(ctx context.Context, tx *gorm.DB, topic, key string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	msg := queue.NewMessage(body).WithKey(key).WithHeader("content-type", "application/json")
+	return Publish(ctx, tx, topic, msg)
+}
+
+// toQueueMessage convert a row to queue message
+func (m *Message) toQueueMessage() *queue.Message {
+	msg := &queue.Message{
+		ID:        m.MessageID,
+		Key:       m.Key,
+		Body:      m.Payload,
+		Headers:   make(map[string]string),
+		Timestamp: m.CreatedAt,
+	}
+	if m.Headers != "" {
+		_ = json.Unmarshal([]byte(m.Headers), &msg.Headers)
+	}
+	return msg
+}
@@ -0,0 +1,108 @@
+package outbox
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+
+	"github.com/go-eagle/eagle/pkg/queue"
+)
+
+func newMockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	gdb, err := gorm.Open(mysql.New(mysql.Config{Conn: db, SkipInitializeWithVersion: true}), &gorm.Config{})
+	require.NoError(t, err)
+	return gdb, mock
+}
+
+func TestPublish(t *testing.T) {
+	gdb, mock := newMockDB(t)
+
+	msg := queue.NewMessage([]byte(`{"user_id":1}`)).WithKey("1").WithHeader("foo", "bar")
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `outbox`")).
+		WithArgs(msg.ID, "user-followed", "1", `{"foo":"bar"}`, msg.Body, StatusPending,
+			0, "", "", nil, nil, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	err := gdb.Transaction(func(tx *gorm.DB) error {
+		return Publish(context.Background(), tx, "user-followed", msg)
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMessage_toQueueMessage(t *testing.T) {
+	m := &Message{MessageID: "m1", Key: "k1", Headers: `{"foo":"bar"}`, Payload: []byte("hello")}
+	msg := m.toQueueMessage()
+	assert.Equal(t, "m1", msg.ID)
+	assert.Equal(t, "k1", msg.Key)
+	assert.Equal(t, "bar", msg.Header("foo"))
+	assert.Equal(t, "hello", string(msg.Body))
+}
+
+func TestStore_Claim(t *testing.T) {
+	gdb, mock := newMockDB(t)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `outbox` SET `locked_by`=?,`locked_until`=?,`updated_at`=? "+
+		"WHERE status = ? AND (locked_until IS NULL OR locked_until < ?) ORDER BY id LIMIT 10")).
+		WithArgs("owner-1", sqlmock.AnyArg(), sqlmock.AnyArg(), StatusPending, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `outbox` WHERE locked_by = ? AND status = ? ORDER BY id")).
+		WithArgs("owner-1", StatusPending).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "message_id", "topic"}).
+			AddRow(1, "m1", "user-followed").
+			AddRow(2, "m2", "user-followed"))
+
+	messages, err := NewStore(gdb).Claim(context.Background(), "owner-1", 10, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, messages, 2)
+	assert.Equal(t, "m1", messages[0].MessageID)
+	assert.Equal(t, "m2", messages[1].MessageID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStore_MarkFailed(t *testing.T) {
+	gdb, mock := newMockDB(t)
+
+	// the reason is cut to the length of last_error
+	reason := strings.Repeat("错", maxErrorLen+10)
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `outbox` SET")).
+		WithArgs(strings.Repeat("错", maxErrorLen), "", sqlmock.AnyArg(), sqlmock.AnyArg(), uint64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err := NewStore(gdb).MarkFailed(context.Background(), 1, reason, time.Now())
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStore_Cleanup(t *testing.T) {
+	gdb, mock := newMockDB(t)
+
+	before := time.Now().Add(-time.Hour)
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM outbox WHERE status = ? AND sent_at < ? LIMIT ?")).
+		WithArgs(StatusSent, before, 100).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+
+	n, err := NewStore(gdb).Cleanup(context.Background(), before, 100)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), n)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,204 @@
+package outbox
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/go-eagle/eagle/pkg/queue"
+	"github.com/go-eagle/eagle/pkg/transport"
+)
+
+var _ transport.Server = (*Relay)(nil)
+
+// RelayOption is relay option
+type RelayOption func(*Relay)
+
+// WithInterval with poll interval.
+func WithInterval(interval time.Duration) RelayOption {
+	return func(r *Relay) {
+		r.interval = interval
+	}
+}
+
+// WithBatchSize with the max number of messages claimed once.
+func WithBatchSize(size int) RelayOption {
+	return func(r *Relay) {
+		r.batchSize = size
+	}
+}
+
+// WithLockTimeout with the time the claimed messages are locked,
+// they are claimed by others after timeout if the relay is crashed.
+func WithLockTimeout(timeout time.Duration) RelayOption {
+	return func(r *Relay) {
+		r.lockTimeout = timeout
+	}
+}
+
+// WithRetryInterval with the wait time before a failed message is published again.
+func WithRetryInterval(interval time.Duration) RelayOption {
+	return func(r *Relay) {
+		r.retryInterval = interval
+	}
+}
+
+// WithMaxAttempts with the max number of times a message is published,
+// the message is marked as dead after that, zero means retry forever.
+func WithMaxAttempts(attempts int) RelayOption {
+	return func(r *Relay) {
+		r.maxAttempts = attempts
+	}
+}
+
+// WithRetention with the time the published messages are kept, zero means never cleanup.
+func WithRetention(retention time.Duration) RelayOption {
+	return func(r *Relay) {
+		r.retention = retention
+	}
+}
+
+// Relay poll the outbox table and publish the messages by a producer,
+// a message is published at least once, so consumers should be idempotent.
+// NOTE: a message is marked as sent once it's accepted by the producer,
+// so the producer must be durable, eg: not the memory queue which drops the messages without subscribers.
+type Relay struct {
+	store         Store
+	producer      queue.Producer
+	interval      time.Duration
+	batchSize     int
+	lockTimeout   time.Duration
+	retryInterval time.Duration
+	maxAttempts   int
+	retention     time.Duration
+
+	quit chan struct{}
+	once sync.Once
+	wg   sync.WaitGroup
+}
+
+// NewRelay create a relay
+func NewRelay(db *gorm.DB, producer queue.Producer, opts ...RelayOption) *Relay {
+	return newRelay(NewStore(db), producer, opts...)
+}
+
+func newRelay(store Store, producer queue.Producer, opts ...RelayOption) *Relay {
+	r := &Relay{
+		store:         store,
+		producer:      producer,
+		interval:      time.Second,
+		batchSize:     100,
+		lockTimeout:   30 * time.Second,
+		retryInterval: 10 * time.Second,
+		maxAttempts:   20,
+		retention:     7 * 24 * time.Hour,
+		quit:          make(chan struct{}),
+	}
+	for _, o := range opts {
+		o(r)
+	}
+	return r
+}
+
+// Start poll the outbox table until ctx is done or the relay is stopped
+func (r *Relay) Start(ctx context.Context) error {
+	r.wg.Add(1)
+	defer r.wg.Done()
+
+	log.Printf("[outbox] relay is running")
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	lastCleanup := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-r.quit:
+			return nil
+		case <-ticker.C:
+			// publish until there is no more pending messages or the relay is stopped
+			for !r.stopped(ctx) {
+				n, err := r.relay(ctx)
+				if err != nil {
+					log.Printf("[outbox] relay err: %v", err)
+				}
+				if err != nil || n < r.batchSize {
+					break
+				}
+			}
+
+			if r.retention > 0 && time.Since(lastCleanup) > time.Minute {
+				lastCleanup = time.Now()
+				if _, err := r.store.Cleanup(ctx, time.Now().Add(-r.retention), r.batchSize*10); err != nil {
+					log.Printf("[outbox] cleanup err: %v", err)
+				}
+			}
+		}
+	}
+}
+
+// Stop stop polling and wait for the running batch until ctx is done
+func (r *Relay) Stop(ctx context.Context) error {
+	log.Printf("[outbox] relay is stopping")
+	r.once.Do(func() {
+		close(r.quit)
+	})
+
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// stopped check if the relay is stopped or ctx is done
+func (r *Relay) stopped(ctx context.Context) bool {
+	select {
+	case <-ctx.Done():
+		return true
+	case <-r.quit:
+		return true
+	default:
+		return false
+	}
+}
+
+// relay claim a batch of messages and publish them, return the number of claimed messages
+func (r *Relay) relay(ctx context.Context) (int, error) {
+	messages, err := r.store.Claim(ctx, uuid.New().String(), r.batchSize, r.lockTimeout)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, m := range messages {
+		if err := r.producer.Publish(ctx, m.Topic, m.toQueueMessage()); err != nil {
+			log.Printf("[outbox] publish message %d to %s err: %v", m.ID, m.Topic, err)
+			if r.maxAttempts > 0 && m.Attempts+1 >= r.maxAttempts {
+				log.Printf("[outbox] message %d is dead after %d attempts", m.ID, m.Attempts+1)
+				if err := r.store.MarkDead(ctx, m.ID, err.Error()); err != nil {
+					return len(messages), err
+				}
+				continue
+			}
+			if err := r.store.MarkFailed(ctx, m.ID, err.Error(), time.Now().Add(r.retryInterval)); err != nil {
+				return len(messages), err
+			}
+			continue
+		}
+		// NOTE: if it fails here, the message will be published again after lock timeout
+		if err := r.store.MarkSent(ctx, m.ID); err != nil {
+			return len(messages), err
+		}
+	}
+	return len(messages), nil
+}
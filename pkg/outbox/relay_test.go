@@ -0,0 +1,210 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/go-eagle/eagle/pkg/queue"
+	"github.com/go-eagle/eagle/pkg/queue/memory"
+)
+
+type fakeStore struct {
+	mu       sync.Mutex
+	messages []*Message
+}
+
+func (s *fakeStore) Claim(ctx context.Context, owner string, limit int, lockTimeout time.Duration) ([]*Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	claimed := make([]*Message, 0)
+	for _, m := range s.messages {
+		if len(claimed) == limit {
+			break
+		}
+		if m.Status == StatusPending && (m.LockedUntil == nil || m.LockedUntil.Before(now)) {
+			until := now.Add(lockTimeout)
+			m.LockedBy, m.LockedUntil = owner, &until
+			claimed = append(claimed, m)
+		}
+	}
+	return claimed, nil
+}
+
+func (s *fakeStore) MarkSent(ctx context.Context, id uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, m := range s.messages {
+		if m.ID == id {
+			now := time.Now()
+			m.Status, m.SentAt = StatusSent, &now
+		}
+	}
+	return nil
+}
+
+func (s *fakeStore) MarkFailed(ctx context.Context, id uint64, reason string, retryAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, m := range s.messages {
+		if m.ID == id {
+			m.Attempts++
+			m.LastError, m.LockedUntil = reason, &retryAt
+		}
+	}
+	return nil
+}
+
+func (s *fakeStore) MarkDead(ctx context.Context, id uint64, reason string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, m := range s.messages {
+		if m.ID == id {
+			m.Attempts++
+			m.Status, m.LastError, m.LockedUntil = StatusDead, reason, nil
+		}
+	}
+	return nil
+}
+
+func (s *fakeStore) Cleanup(ctx context.Context, before time.Time, limit int) (int64, error) {
+	return 0, nil
+}
+
+func (s *fakeStore) status(id uint64) (int, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, m := range s.messages {
+		if m.ID == id {
+			return m.Status, m.Attempts
+		}
+	}
+	return -1, 0
+}
+
+type failProducer struct {
+	queue.Producer
+	failures int
+}
+
+func (p *failProducer) Publish(ctx context.Context, topic string, msg *queue.Message) error {
+	if p.failures > 0 {
+		p.failures--
+		return errors.New("broker is down")
+	}
+	return p.Producer.Publish(ctx, topic, msg)
+}
+
+func TestRelay(t *testing.T) {
+	broker := memory.NewBroker()
+	defer broker.Close()
+
+	received := make(chan *queue.Message, 2)
+	consumer := broker.NewConsumer("")
+	_ = consumer.Subscribe(context.Background(), "user-followed", func(ctx context.Context, msg *queue.Message) error {
+		received <- msg
+		return nil
+	})
+
+	store := &fakeStore{messages: []*Message{
+		{ID: 1, MessageID: "m1", Topic: "user-followed", Key: "1", Headers: `{"foo":"bar"}`, Payload: []byte("a")},
+		{ID: 2, MessageID: "m2", Topic: "user-followed", Key: "2", Payload: []byte("b")},
+	}}
+	producer := &failProducer{Producer: broker, failures: 1}
+	relay := newRelay(store, producer, WithInterval(10*time.Millisecond), WithRetryInterval(10*time.Millisecond))
+
+	go func() {
+		_ = relay.Start(context.Background())
+	}()
+	defer relay.Stop(context.Background())
+
+	got := make(map[string]*queue.Message)
+	for i := 0; i < 2; i++ {
+		select {
+		case msg := <-received:
+			got[msg.ID] = msg
+		case <-time.After(time.Second):
+			t.Fatal("message is not relayed")
+		}
+	}
+	assert.Equal(t, "bar", got["m1"].Header("foo"))
+	assert.Equal(t, "b", string(got["m2"].Body))
+
+	assert.Eventually(t, func() bool {
+		status1, attempts1 := store.status(1)
+		status2, _ := store.status(2)
+		return status1 == StatusSent && attempts1 == 1 && status2 == StatusSent
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestRelay_MaxAttempts(t *testing.T) {
+	broker := memory.NewBroker()
+	defer broker.Close()
+
+	store := &fakeStore{messages: []*Message{
+		{ID: 1, MessageID: "m1", Topic: "user-followed", Payload: []byte("a")},
+	}}
+	producer := &failProducer{Producer: broker, failures: 10}
+	relay := newRelay(store, producer, WithInterval(10*time.Millisecond),
+		WithRetryInterval(10*time.Millisecond), WithMaxAttempts(3))
+
+	go func() {
+		_ = relay.Start(context.Background())
+	}()
+	defer relay.Stop(context.Background())
+
+	assert.Eventually(t, func() bool {
+		status, attempts := store.status(1)
+		return status == StatusDead && attempts == 3
+	}, time.Second, 10*time.Millisecond)
+
+	// the dead message is not published any more
+	time.Sleep(50 * time.Millisecond)
+	_, attempts := store.status(1)
+	assert.Equal(t, 3, attempts)
+}
+
+// blockProducer block publishing until it's released
+type blockProducer struct {
+	queue.Producer
+	published chan struct{}
+	release   chan struct{}
+}
+
+func (p *blockProducer) Publish(ctx context.Context, topic string, msg *queue.Message) error {
+	p.published <- struct{}{}
+	<-p.release
+	return nil
+}
+
+func TestRelay_Stop(t *testing.T) {
+	messages := make([]*Message, 0, 10)
+	for i := 1; i <= 10; i++ {
+		messages = append(messages, &Message{ID: uint64(i), MessageID: fmt.Sprintf("m%d", i), Topic: "user-followed"})
+	}
+	store := &fakeStore{messages: messages}
+	producer := &blockProducer{published: make(chan struct{}), release: make(chan struct{})}
+	relay := newRelay(store, producer, WithInterval(10*time.Millisecond), WithBatchSize(1))
+
+	go func() {
+		_ = relay.Start(context.Background())
+	}()
+	<-producer.published
+
+	// the running batch is not waited after ctx is done
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, relay.Stop(ctx), context.DeadlineExceeded)
+
+	// the backlog is not published after stop
+	close(producer.release)
+	assert.NoError(t, relay.Stop(context.Background()))
+	status, _ := store.status(2)
+	assert.Equal(t, StatusPending, status)
+}
@@ -0,0 +1,115 @@
+package outbox
+
+import (
+	"context"
+	"time"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+)
+
+// maxErrorLen the length of the last_error column
+const maxErrorLen = 512
+
+// Store is the storage of outbox messages used by relay
+type Store interface {
+	// Claim lock at most limit pending messages for a while and return them
+	Claim(ctx context.Context, owner string, limit int, lockTimeout time.Duration) ([]*Message, error)
+	// MarkSent mark a message as published
+	MarkSent(ctx context.Context, id uint64) error
+	// MarkFailed record the error and keep the message locked until retryAt
+	MarkFailed(ctx context.Context, id uint64, reason string, retryAt time.Time) error
+	// MarkDead record the error and stop publishing the message
+	MarkDead(ctx context.Context, id uint64, reason string) error
+	// Cleanup delete at most limit published messages sent before the time
+	Cleanup(ctx context.Context, before time.Time, limit int) (int64, error)
+}
+
+type gormStore struct {
+	db *gorm.DB
+}
+
+// NewStore create a store by gorm
+func NewStore(db *gorm.DB) Store {
+	return &gormStore{db: db}
+}
+
+// Claim works without `SKIP LOCKED`, so that it is available on mysql 5.7,
+// the rows are locked by an update and then selected by the owner.
+func (s *gormStore) Claim(ctx context.Context, owner string, limit int, lockTimeout time.Duration) ([]*Message, error) {
+	now := time.Now()
+	err := s.db.WithContext(ctx).Model(&Message{}).
+		Where("status = ? AND (locked_until IS NULL OR locked_until < ?)", StatusPending, now).
+		Order("id").
+		Limit(limit).
+		Updates(map[string]interface{}{
+			"locked_by":    owner,
+			"locked_until": now.Add(lockTimeout),
+		}).Error
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make([]*Message, 0, limit)
+	err = s.db.WithContext(ctx).
+		Where("locked_by = ? AND status = ?", owner, StatusPending).
+		Order("id").
+		Find(&messages).Error
+	if err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+func (s *gormStore) MarkSent(ctx context.Context, id uint64) error {
+	now := time.Now()
+	return s.db.WithContext(ctx).Model(&Message{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":       StatusSent,
+			"sent_at":      now,
+			"locked_by":    "",
+			"locked_until": nil,
+			"updated_at":   now,
+		}).Error
+}
+
+func (s *gormStore) MarkFailed(ctx context.Context, id uint64, reason string, retryAt time.Time) error {
+	return s.db.WithContext(ctx).Model(&Message{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"attempts":     gorm.Expr("attempts + 1"),
+			"last_error":   truncate(reason, maxErrorLen),
+			"locked_by":    "",
+			"locked_until": retryAt,
+			"updated_at":   time.Now(),
+		}).Error
+}
+
+func (s *gormStore) MarkDead(ctx context.Context, id uint64, reason string) error {
+	return s.db.WithContext(ctx).Model(&Message{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":       StatusDead,
+			"attempts":     gorm.Expr("attempts + 1"),
+			"last_error":   truncate(reason, maxErrorLen),
+			"locked_by":    "",
+			"locked_until": nil,
+			"updated_at":   time.Now(),
+		}).Error
+}
+
+func (s *gormStore) Cleanup(ctx context.Context, before time.Time, limit int) (int64, error) {
+	// gorm does not support limit in delete
+	result := s.db.WithContext(ctx).
+		Exec("DELETE FROM outbox WHERE status = ? AND sent_at < ? LIMIT ?", StatusSent, before, limit)
+	return result.RowsAffected, result.Error
+}
+
+// truncate cut s to at most n runes
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
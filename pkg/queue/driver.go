@@ -0,0 +1,99 @@
+package queue
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+var (
+	driversMu sync.RWMutex
+	drivers   = make(map[string]Driver)
+)
+
+// Config queue config
+type Config struct {
+	// Driver name of a registered driver, eg: kafka, rabbitmq, nats, memory
+	Driver string `validate:"required"`
+	// Addrs broker addresses
+	Addrs []string
+	// Group consumer group, consumers in the same group share the messages of a topic
+	Group string
+	// Exchange is only used by rabbitmq
+	Exchange string
+	// Retry retry policy of consumers, nil means a nacked message is handled by the broker
+	Retry *RetryPolicy
+}
+
+// Driver create producers and consumers of a broker
+type Driver interface {
+	NewProducer(cfg *Config) (Producer, error)
+	NewConsumer(cfg *Config) (Consumer, error)
+}
+
+// Register make a driver available by the provided name.
+// it is usually called in the init function of the driver package, eg:
+//
+//	import _ "github.com/go-eagle/eagle/pkg/queue/kafka"
+func Register(name string, driver Driver) {
+	driversMu.Lock()
+	defer driversMu.Unlock()
+	if driver == nil {
+		panic("queue: register driver is nil")
+	}
+	if _, dup := drivers[name]; dup {
+		panic("queue: register called twice for driver " + name)
+	}
+	drivers[name] = driver
+}
+
+// Drivers return a sorted list of the names of the registered drivers
+func Drivers() []string {
+	driversMu.RLock()
+	defer driversMu.RUnlock()
+	list := make([]string, 0, len(drivers))
+	for name := range drivers {
+		list = append(list, name)
+	}
+	sort.Strings(list)
+	return list
+}
+
+func getDriver(name string) (Driver, error) {
+	driversMu.RLock()
+	defer driversMu.RUnlock()
+	driver, ok := drivers[name]
+	if !ok {
+		return nil, fmt.Errorf("queue: unknown driver %q (forgotten import?)", name)
+	}
+	return driver, nil
+}
+
+// NewProducer create a producer by config
+func NewProducer(cfg *Config) (Producer, error) {
+	driver, err := getDriver(cfg.Driver)
+	if err != nil {
+		return nil, err
+	}
+	return driver.NewProducer(cfg)
+}
+
+// NewConsumer create a consumer by config,
+// it is wrapped by NewRetryConsumer if the retry policy is set.
+func NewConsumer(cfg *Config) (Consumer, error) {
+	driver, err := getDriver(cfg.Driver)
+	if err != nil {
+		return nil, err
+	}
+	consumer, err := driver.NewConsumer(cfg)
+	if err != nil || cfg.Retry == nil {
+		return consumer, err
+	}
+
+	producer, err := driver.NewProducer(cfg)
+	if err != nil {
+		_ = consumer.Close()
+		return nil, err
+	}
+	return NewRetryConsumer(consumer, producer, cfg.Group, cfg.Retry), nil
+}
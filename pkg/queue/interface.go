@@ -1,11 +1,25 @@
 package queue
 
+import "context"
+
+// Handler handle a message delivered by a consumer.
+// Returning nil acks the message, returning an error nacks it and
+// the broker redelivers it if it is able to.
+type Handler func(ctx context.Context, msg *Message) error
+
 // Producer queue producer
 type Producer interface {
-	Publish(message string) error
+	// Publish push a message to the given topic
+	Publish(ctx context.Context, topic string, msg *Message) error
+	// Close release the underlying connection
+	Close() error
 }
 
 // Consumer queue consumer
 type Consumer interface {
-	Consume() error
+	// Subscribe register a handler for the given topic, it does not block,
+	// messages are delivered in the background until ctx is done or the consumer is closed.
+	Subscribe(ctx context.Context, topic string, handler Handler) error
+	// Close stop all subscriptions and release the underlying connection
+	Close() error
 }
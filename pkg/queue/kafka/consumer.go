@@ -2,83 +2,139 @@ package kafka
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"log"
-	"os"
+	"sync"
+	"time"
 
 	"github.com/Shopify/sarama"
 
-	logger "github.com/go-eagle/eagle/pkg/log"
+	"github.com/go-eagle/eagle/pkg/queue"
 )
 
-// Consumer kafka consumer
+// ErrClosed is returned when subscribe on a closed consumer
+var ErrClosed = errors.New("kafka: consumer closed")
+
+// consumeRetryInterval the wait time before joining the group again after the consume failed
+const consumeRetryInterval = time.Second
+
+// Consumer kafka consumer, every subscription joins the consumer group on its own
 type Consumer struct {
-	group   sarama.ConsumerGroup
-	topics  []string
+	client  sarama.Client
 	groupID string
-	handler sarama.ConsumerGroupHandler
 
+	mu     sync.Mutex
+	groups []sarama.ConsumerGroup
+	wg     sync.WaitGroup
 	ctx    context.Context
 	cancel context.CancelFunc
 }
 
 // NewConsumer create a consumer
-// nolint
-func NewConsumer(config *sarama.Config, logger *log.Logger, topic string, groupID string, brokers []string, handler *ConsumerGroupHandler) *Consumer {
-	// Init config, specify appropriate version
-	sarama.Logger = log.New(os.Stderr, "[sarama_logger]", log.LstdFlags)
-	sarama.Logger = logger
-	config.Version = sarama.V2_0_0_0 // V2_4_0_0
-
-	// Start with a client
-	client, err := sarama.NewClient(brokers, config)
-	if err != nil {
-		panic(err)
+func NewConsumer(config *sarama.Config, logger *log.Logger, groupID string, brokers []string) (*Consumer, error) {
+	if logger != nil {
+		sarama.Logger = logger
 	}
+	config.Consumer.Return.Errors = true
 
-	// Start a new consumer group
-	group, err := sarama.NewConsumerGroupFromClient(groupID, client)
+	client, err := sarama.NewClient(brokers, config)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 	return &Consumer{
-		group:   group,
-		topics:  []string{topic},
+		client:  client,
 		groupID: groupID,
-		handler: handler,
 		ctx:     ctx,
 		cancel:  cancel,
-	}
+	}, nil
 }
 
-// Consume consume data
-func (c *Consumer) Consume() {
+// Subscribe consume the topic in background
+func (c *Consumer) Subscribe(ctx context.Context, topic string, handler queue.Handler) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.ctx.Err() != nil {
+		return ErrClosed
+	}
+
+	group, err := sarama.NewConsumerGroupFromClient(c.groupID, c.client)
+	if err != nil {
+		return err
+	}
+	c.groups = append(c.groups, group)
+
 	// Track errors
+	c.wg.Add(1)
 	go func() {
-		for err := range c.group.Errors() {
-			fmt.Println("ERROR", err)
+		defer c.wg.Done()
+		for err := range group.Errors() {
+			log.Printf("kafka consumer - group %s topic %s err: %v", c.groupID, topic, err)
 		}
 	}()
 
-	// Iterate over consumer sessions.
-	ctx := context.Background()
-	for {
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		c.consume(ctx, group, topic, handler)
+	}()
+
+	return nil
+}
+
+func (c *Consumer) consume(ctx context.Context, group sarama.ConsumerGroup, topic string, handler queue.Handler) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
 		select {
 		case <-c.ctx.Done():
-			_ = c.group.Close()
-			logger.Info("[Kafka] Consume ctx done")
-			return
-		default:
-			if err := c.group.Consume(ctx, c.topics, c.handler); err != nil {
-				logger.Errorf("[Kafka] Consume err: %s", err.Error())
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	// Iterate over consumer sessions, a new session is created after rebalance.
+	h := consumerGroupHandler{handler: handler}
+	for {
+		if err := group.Consume(ctx, []string{topic}, h); err != nil {
+			if err == sarama.ErrClosedConsumerGroup {
+				return
+			}
+			log.Printf("kafka consumer - consume topic %s err: %v", topic, err)
+			// eg: the brokers are down, don't spin
+			timer := time.NewTimer(consumeRetryInterval)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
 			}
 		}
+		if ctx.Err() != nil {
+			return
+		}
 	}
 }
 
-// Stop close conn
-func (c Consumer) Stop() {
+// Close leave all consumer groups and close the client
+func (c *Consumer) Close() error {
+	c.mu.Lock()
 	c.cancel()
+	groups := c.groups
+	c.groups = nil
+	c.mu.Unlock()
+
+	var err error
+	for _, group := range groups {
+		if e := group.Close(); e != nil {
+			err = e
+		}
+	}
+	c.wg.Wait()
+
+	if e := c.client.Close(); e != nil {
+		err = e
+	}
+	return err
 }
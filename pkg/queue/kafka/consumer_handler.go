@@ -1,37 +1,68 @@
 package kafka
 
 import (
-	"fmt"
 	"log"
+	"time"
 
 	"github.com/Shopify/sarama"
+
+	"github.com/go-eagle/eagle/pkg/queue"
 )
 
-// ConsumerGroupHandler represents the sarama consumer group
-type ConsumerGroupHandler struct{}
+// redeliverInterval the wait time before a nacked message is handled again
+const redeliverInterval = time.Second
+
+// maxAttempts the max number of times a message is handled, then it's dropped so the partition is not blocked,
+// the requeue errors are not counted, eg: queue.ErrServerStopped
+const maxAttempts = 5
+
+// consumerGroupHandler represents the sarama consumer group
+type consumerGroupHandler struct {
+	handler queue.Handler
+}
 
 // Setup is run before consumer start consuming, is normally used to setup things such as database connections
-func (ConsumerGroupHandler) Setup(_ sarama.ConsumerGroupSession) error {
+func (consumerGroupHandler) Setup(_ sarama.ConsumerGroupSession) error {
 	return nil
 }
 
 // Cleanup is run at the end of a session, once all ConsumeClaim goroutines have exited
-func (ConsumerGroupHandler) Cleanup(_ sarama.ConsumerGroupSession) error {
+func (consumerGroupHandler) Cleanup(_ sarama.ConsumerGroupSession) error {
 	return nil
 }
 
-// ConsumeClaim must start a consumer loop of ConsumerGroupClaim's Messages(), here is supposed to be what you want to
-// do with the message. In this example the message will be logged with the topic name, partition and message value.
-func (h ConsumerGroupHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
-	for msg := range claim.Messages() {
-		fmt.Printf(
-			"Message topic:%q partition:%d offset:%d message: %v\n",
-			msg.Topic, msg.Partition, msg.Offset, string(msg.Value),
-		)
-
-		session.MarkMessage(msg, "")
+// ConsumeClaim must start a consumer loop of ConsumerGroupClaim's Messages().
+// kafka can not requeue a single message, so a nacked message is handled again
+// after a while, the partition is blocked until it is acked to keep the order.
+// a poison message is dropped after maxAttempts, use queue.RetryPolicy to keep it in the dead letter topic.
+func (h consumerGroupHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	ctx := session.Context()
+	for cm := range claim.Messages() {
+		msg := toMessage(cm)
+		for failures := 0; ; {
+			err := h.handler(ctx, msg)
+			if err == nil {
+				break
+			}
+			if !queue.IsRequeue(err) {
+				failures++
+			}
+			if failures >= maxAttempts {
+				log.Printf("kafka consumer - drop message after %d attempts, topic: %s partition: %d offset: %d err: %v",
+					failures, cm.Topic, cm.Partition, cm.Offset, err)
+				break
+			}
+			log.Printf("kafka consumer - handle message err, topic: %s partition: %d offset: %d err: %v",
+				cm.Topic, cm.Partition, cm.Offset, err)
 
-		log.Printf(" [*] Waiting for messages. To exit press CTRL+C")
+			select {
+			case <-ctx.Done():
+				// the offset is not marked, the message will be delivered in next session
+				return nil
+			case <-time.After(redeliverInterval):
+			}
+		}
+		session.MarkMessage(cm, "")
 	}
 
 	return nil
@@ -0,0 +1,28 @@
+package kafka
+
+import (
+	"github.com/Shopify/sarama"
+
+	"github.com/go-eagle/eagle/pkg/queue"
+)
+
+func init() {
+	queue.Register("kafka", driver{})
+}
+
+type driver struct{}
+
+func newConfig() *sarama.Config {
+	config := sarama.NewConfig()
+	// headers need kafka 0.11+
+	config.Version = sarama.V2_0_0_0
+	return config
+}
+
+func (driver) NewProducer(cfg *queue.Config) (queue.Producer, error) {
+	return NewProducer(newConfig(), nil, cfg.Addrs)
+}
+
+func (driver) NewConsumer(cfg *queue.Config) (queue.Consumer, error) {
+	return NewConsumer(newConfig(), nil, cfg.Group, cfg.Addrs)
+}
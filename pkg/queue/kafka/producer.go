@@ -1,73 +1,86 @@
 package kafka
 
 import (
+	"context"
 	"log"
-	"os"
-	"os/signal"
-	"time"
 
 	"github.com/Shopify/sarama"
-	logger "github.com/go-eagle/eagle/pkg/log"
+
+	"github.com/go-eagle/eagle/pkg/queue"
 )
 
 // Producer kafka producer
 type Producer struct {
-	asyncProducer sarama.AsyncProducer
-	topic         string
-	enqueued      int
+	producer sarama.SyncProducer
 }
 
 // NewProducer create producer
-// nolint
-func NewProducer(config *sarama.Config, logger *log.Logger, topic string, brokers []string) *Producer {
-	sarama.Logger = logger
+func NewProducer(config *sarama.Config, logger *log.Logger, brokers []string) (*Producer, error) {
+	if logger != nil {
+		sarama.Logger = logger
+	}
+	// required by sync producer
+	config.Producer.Return.Successes = true
+	config.Producer.Return.Errors = true
 
-	// Start a new async producer
-	producer, err := sarama.NewAsyncProducer(brokers, config)
+	producer, err := sarama.NewSyncProducer(brokers, config)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
-	log.Println("Kafka AsyncProducer up and running!")
+	return &Producer{producer: producer}, nil
+}
 
-	p := &Producer{
-		asyncProducer: producer,
-		topic:         topic,
+// Publish push a message to kafka, the message key decides the partition
+func (p *Producer) Publish(ctx context.Context, topic string, msg *queue.Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
 	}
 
-	go p.asyncDealMessage()
+	pm := &sarama.ProducerMessage{
+		Topic:     topic,
+		Value:     sarama.ByteEncoder(msg.Body),
+		Headers:   toRecordHeaders(msg),
+		Timestamp: msg.Timestamp,
+	}
+	if msg.Key != "" {
+		pm.Key = sarama.StringEncoder(msg.Key)
+	}
 
-	return p
+	_, _, err := p.producer.SendMessage(pm)
+	return err
 }
 
-func (p *Producer) asyncDealMessage() {
-	for {
-		select {
-		case res := <-p.asyncProducer.Successes():
-			logger.Info("push msg success", "topic is", res.Topic, "partition is ", res.Partition, "offset is ", res.Offset)
-		case err := <-p.asyncProducer.Errors():
-			logger.Info("push msg failed", "err is ", err.Error())
-		}
-	}
+// Close close producer
+func (p *Producer) Close() error {
+	return p.producer.Close()
 }
 
-// Publish push data to queue
-func (p *Producer) Publish(message string) {
-	signals := make(chan os.Signal, 1)
-	signal.Notify(signals, os.Interrupt)
-
-	for {
-		time.Sleep(5 * time.Second)
-		message := &sarama.ProducerMessage{Topic: p.topic, Value: sarama.StringEncoder(message)}
+func toRecordHeaders(msg *queue.Message) []sarama.RecordHeader {
+	headers := make([]sarama.RecordHeader, 0, len(msg.Headers)+1)
+	if msg.ID != "" {
+		headers = append(headers, sarama.RecordHeader{Key: []byte(queue.HeaderMessageID), Value: []byte(msg.ID)})
+	}
+	for k, v := range msg.Headers {
+		headers = append(headers, sarama.RecordHeader{Key: []byte(k), Value: []byte(v)})
+	}
+	return headers
+}
 
-		select {
-		case p.asyncProducer.Input() <- message:
-			p.enqueued++
-			logger.Infof("New message publish:  %s", message.Value)
-		case <-signals:
-			p.asyncProducer.AsyncClose() // Trigger a shutdown of the producer.
-			logger.Infof("Kafka AsyncProducer finished with %d messages produced.", p.enqueued)
-			return
+func toMessage(cm *sarama.ConsumerMessage) *queue.Message {
+	msg := &queue.Message{
+		Key:       string(cm.Key),
+		Topic:     cm.Topic,
+		Body:      cm.Value,
+		Headers:   make(map[string]string, len(cm.Headers)),
+		Timestamp: cm.Timestamp,
+	}
+	for _, h := range cm.Headers {
+		if string(h.Key) == queue.HeaderMessageID {
+			msg.ID = string(h.Value)
+			continue
 		}
+		msg.Headers[string(h.Key)] = string(h.Value)
 	}
+	return msg
 }
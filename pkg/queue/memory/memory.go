@@ -0,0 +1,231 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/go-eagle/eagle/pkg/queue"
+)
+
+const defaultBufferSize = 1024
+
+// redeliverInterval the wait time before a nacked message is put back to the group
+const redeliverInterval = 100 * time.Millisecond
+
+var (
+	// ErrClosed is returned when the broker or consumer is closed
+	ErrClosed = errors.New("memory: queue closed")
+
+	// defaultBroker is shared by the producers and consumers created by config,
+	// so that they can talk to each other in the same process
+	defaultBroker = NewBroker()
+)
+
+func init() {
+	queue.Register("memory", driver{})
+}
+
+type driver struct{}
+
+func (driver) NewProducer(cfg *queue.Config) (queue.Producer, error) {
+	return producer{defaultBroker}, nil
+}
+
+func (driver) NewConsumer(cfg *queue.Config) (queue.Consumer, error) {
+	return defaultBroker.NewConsumer(cfg.Group), nil
+}
+
+// producer publish to the default broker, closing it does not close the broker
+type producer struct {
+	*Broker
+}
+
+func (producer) Close() error {
+	return nil
+}
+
+// group is a set of subscribers that share one message channel
+type group struct {
+	ch chan *queue.Message
+}
+
+// Broker is an in-process broker, it is mainly used in tests
+type Broker struct {
+	mu     sync.RWMutex
+	topics map[string]map[string]*group
+	quit   chan struct{}
+	closed bool
+}
+
+// NewBroker create a memory broker
+func NewBroker() *Broker {
+	return &Broker{
+		topics: make(map[string]map[string]*group),
+		quit:   make(chan struct{}),
+	}
+}
+
+// Publish push a message to every group subscribed the topic
+func (b *Broker) Publish(ctx context.Context, topic string, msg *queue.Message) error {
+	b.mu.RLock()
+	if b.closed {
+		b.mu.RUnlock()
+		return ErrClosed
+	}
+	groups := make([]*group, 0, len(b.topics[topic]))
+	for _, g := range b.topics[topic] {
+		groups = append(groups, g)
+	}
+	b.mu.RUnlock()
+
+	for _, g := range groups {
+		select {
+		case g.ch <- copyMessage(topic, msg):
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-b.quit:
+			return ErrClosed
+		}
+	}
+	return nil
+}
+
+// Close close the broker, the pending messages are dropped
+func (b *Broker) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.closed {
+		b.closed = true
+		close(b.quit)
+	}
+	return nil
+}
+
+// NewConsumer create a consumer of the group,
+// an empty group means every subscription receives all messages of the topic.
+func (b *Broker) NewConsumer(group string) *Consumer {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Consumer{
+		broker: b,
+		group:  group,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+func (b *Broker) join(topic, name string) (*group, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil, ErrClosed
+	}
+	groups, ok := b.topics[topic]
+	if !ok {
+		groups = make(map[string]*group)
+		b.topics[topic] = groups
+	}
+	g, ok := groups[name]
+	if !ok {
+		g = &group{ch: make(chan *queue.Message, defaultBufferSize)}
+		groups[name] = g
+	}
+	return g, nil
+}
+
+// Consumer consume messages from a memory broker
+type Consumer struct {
+	broker *Broker
+	group  string
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// Subscribe register a handler for the topic
+func (c *Consumer) Subscribe(ctx context.Context, topic string, handler queue.Handler) error {
+	if c.ctx.Err() != nil {
+		return ErrClosed
+	}
+	name := c.group
+	if name == "" {
+		name = uuid.New().String()
+	}
+	g, err := c.broker.join(topic, name)
+	if err != nil {
+		return err
+	}
+
+	go c.consume(ctx, g, handler)
+	return nil
+}
+
+func (c *Consumer) consume(ctx context.Context, g *group, handler queue.Handler) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-c.ctx.Done():
+			return
+		case <-c.broker.quit:
+			return
+		case msg := <-g.ch:
+			if err := handler(ctx, msg); err != nil {
+				// nack, put it back to the group to redeliver after a while,
+				// the subscription is paused meanwhile so a failing handler doesn't spin
+				stopped := !c.wait(ctx)
+				c.requeue(g, msg)
+				if stopped {
+					return
+				}
+			}
+		}
+	}
+}
+
+// wait the redeliver interval, return false if the subscription is stopped
+func (c *Consumer) wait(ctx context.Context) bool {
+	timer := time.NewTimer(redeliverInterval)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+	case <-c.ctx.Done():
+	case <-c.broker.quit:
+	}
+	return false
+}
+
+func (c *Consumer) requeue(g *group, msg *queue.Message) {
+	select {
+	case g.ch <- msg:
+		return
+	default:
+	}
+	// the group is full, wait for the room in background
+	go func() {
+		select {
+		case g.ch <- msg:
+		case <-c.broker.quit:
+		}
+	}()
+}
+
+// Close stop all subscriptions, the running handlers are not waited
+func (c *Consumer) Close() error {
+	c.cancel()
+	return nil
+}
+
+func copyMessage(topic string, msg *queue.Message) *queue.Message {
+	m := *msg
+	m.Topic = topic
+	m.Headers = make(map[string]string, len(msg.Headers))
+	for k, v := range msg.Headers {
+		m.Headers[k] = v
+	}
+	return &m
+}
@@ -0,0 +1,126 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/go-eagle/eagle/pkg/queue"
+)
+
+func TestBroker_PublishSubscribe(t *testing.T) {
+	broker := NewBroker()
+	defer broker.Close()
+
+	consumer := broker.NewConsumer("")
+	defer consumer.Close()
+
+	received := make(chan *queue.Message, 1)
+	err := consumer.Subscribe(context.Background(), "test-topic", func(ctx context.Context, msg *queue.Message) error {
+		received <- msg
+		return nil
+	})
+	assert.NoError(t, err)
+
+	msg := queue.NewMessage([]byte("hello")).WithKey("k1").WithHeader("foo", "bar")
+	err = broker.Publish(context.Background(), "test-topic", msg)
+	assert.NoError(t, err)
+
+	select {
+	case got := <-received:
+		assert.Equal(t, msg.ID, got.ID)
+		assert.Equal(t, "k1", got.Key)
+		assert.Equal(t, "test-topic", got.Topic)
+		assert.Equal(t, "hello", string(got.Body))
+		assert.Equal(t, "bar", got.Header("foo"))
+	case <-time.After(time.Second):
+		t.Fatal("message is not received")
+	}
+}
+
+func TestBroker_Group(t *testing.T) {
+	broker := NewBroker()
+	defer broker.Close()
+
+	var groupA, groupB int32
+	for i := 0; i < 2; i++ {
+		c := broker.NewConsumer("group-a")
+		defer c.Close()
+		_ = c.Subscribe(context.Background(), "test-topic", func(ctx context.Context, msg *queue.Message) error {
+			atomic.AddInt32(&groupA, 1)
+			return nil
+		})
+	}
+	c := broker.NewConsumer("group-b")
+	defer c.Close()
+	_ = c.Subscribe(context.Background(), "test-topic", func(ctx context.Context, msg *queue.Message) error {
+		atomic.AddInt32(&groupB, 1)
+		return nil
+	})
+
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, broker.Publish(context.Background(), "test-topic", queue.NewMessage([]byte("hello"))))
+	}
+
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(&groupA) == 10 && atomic.LoadInt32(&groupB) == 10
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestBroker_Nack(t *testing.T) {
+	broker := NewBroker()
+	defer broker.Close()
+
+	consumer := broker.NewConsumer("")
+	defer consumer.Close()
+
+	var attempts int32
+	done := make(chan struct{})
+	_ = consumer.Subscribe(context.Background(), "test-topic", func(ctx context.Context, msg *queue.Message) error {
+		if atomic.AddInt32(&attempts, 1) < 3 {
+			return errors.New("try again")
+		}
+		close(done)
+		return nil
+	})
+	begin := time.Now()
+	assert.NoError(t, broker.Publish(context.Background(), "test-topic", queue.NewMessage([]byte("hello"))))
+
+	select {
+	case <-done:
+		assert.Equal(t, int32(3), atomic.LoadInt32(&attempts))
+		// the nacked message is redelivered after a while
+		assert.GreaterOrEqual(t, int64(time.Since(begin)), int64(2*redeliverInterval))
+	case <-time.After(time.Second):
+		t.Fatal("message is not redelivered")
+	}
+}
+
+func TestNewByConfig(t *testing.T) {
+	cfg := &queue.Config{Driver: "memory", Group: "test-group"}
+	producer, err := queue.NewProducer(cfg)
+	assert.NoError(t, err)
+	consumer, err := queue.NewConsumer(cfg)
+	assert.NoError(t, err)
+	defer consumer.Close()
+
+	received := make(chan struct{})
+	_ = consumer.Subscribe(context.Background(), "config-topic", func(ctx context.Context, msg *queue.Message) error {
+		close(received)
+		return nil
+	})
+	assert.NoError(t, producer.Publish(context.Background(), "config-topic", queue.NewMessage([]byte("hello"))))
+
+	select {
+	case <-received:
+	case <-time.After(time.Second):
+		t.Fatal("message is not received")
+	}
+
+	_, err = queue.NewProducer(&queue.Config{Driver: "unknown"})
+	assert.Error(t, err)
+}
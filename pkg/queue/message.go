@@ -0,0 +1,70 @@
+package queue
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// HeaderMessageID carries Message.ID for brokers without a native message id
+	HeaderMessageID = "x-message-id"
+	// HeaderMessageKey carries Message.Key for brokers without a native message key
+	HeaderMessageKey = "x-message-key"
+)
+
+// Message is a broker-neutral message
+type Message struct {
+	// ID unique id of the message
+	ID string
+	// Key is used by partitioned brokers to keep ordering, eg: kafka
+	Key string
+	// Topic the message is delivered from, it is filled by consumers
+	Topic string
+	// Body payload of the message
+	Body []byte
+	// Headers user defined metadata
+	Headers map[string]string
+	// Timestamp the time the message is created
+	Timestamp time.Time
+}
+
+// NewMessage create a message with a random id
+func NewMessage(body []byte) *Message {
+	return &Message{
+		ID:        uuid.New().String(),
+		Body:      body,
+		Headers:   make(map[string]string),
+		Timestamp: time.Now(),
+	}
+}
+
+// WithKey set the message key
+func (m *Message) WithKey(key string) *Message {
+	m.Key = key
+	return m
+}
+
+// WithHeader set a message header
+func (m *Message) WithHeader(key, value string) *Message {
+	if m.Headers == nil {
+		m.Headers = make(map[string]string)
+	}
+	m.Headers[key] = value
+	return m
+}
+
+// clone copy the message, the headers are not shared
+func (m *Message) clone() *Message {
+	c := *m
+	c.Headers = make(map[string]string, len(m.Headers))
+	for k, v := range m.Headers {
+		c.Headers[k] = v
+	}
+	return &c
+}
+
+// Header return the value of a message header
+func (m *Message) Header(key string) string {
+	return m.Headers[key]
+}
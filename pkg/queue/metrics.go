@@ -0,0 +1,25 @@
+package queue
+
+import (
+	"github.com/go-eagle/eagle/pkg/metric"
+)
+
+const namespace = "queue_consumer"
+
+// nolint
+var (
+	_metricRetry = metric.NewCounterVec(&metric.CounterVecOpts{
+		Namespace: namespace,
+		Subsystem: "messages",
+		Name:      "retry_total",
+		Help:      "queue consumer retried messages count.",
+		Labels:    []string{"topic", "group"},
+	})
+	_metricDeadLetter = metric.NewCounterVec(&metric.CounterVecOpts{
+		Namespace: namespace,
+		Subsystem: "messages",
+		Name:      "dead_letter_total",
+		Help:      "queue consumer dead lettered messages count.",
+		Labels:    []string{"topic", "group"},
+	})
+)
@@ -1,25 +1,42 @@
 package nats
 
 import (
+	"context"
 	"log"
+	"sync"
 	"time"
 
 	"github.com/nats-io/nats.go"
+
+	"github.com/go-eagle/eagle/pkg/queue"
 )
 
+// subscription a subscribed subject
+type subscription struct {
+	ctx     context.Context
+	topic   string
+	handler queue.Handler
+	sub     *nats.Subscription
+}
+
 // Consumer define a nats consumer
 type Consumer struct {
 	addr      string
+	group     string
+	mu        sync.RWMutex
 	conn      *nats.Conn
-	subscribe *nats.Subscription
+	subs      []*subscription
 	connClose chan bool
 	quit      chan struct{}
+	once      sync.Once
 }
 
-// NewConsumer create consumer
-func NewConsumer(addr string) *Consumer {
+// NewConsumer create consumer,
+// the consumers in the same group are a nats queue group and share the messages of a subject.
+func NewConsumer(addr, group string) *Consumer {
 	c := &Consumer{
 		addr:      addr,
+		group:     group,
 		connClose: make(chan bool),
 		quit:      make(chan struct{}),
 	}
@@ -41,23 +58,32 @@ func (c *Consumer) Start() error {
 	return nil
 }
 
-// Stop .
-func (c *Consumer) Stop() {
-	close(c.quit)
-	if !c.conn.IsClosed() {
-		_ = c.subscribe.Unsubscribe()
-		c.conn.Close()
+// Close .
+func (c *Consumer) Close() error {
+	c.once.Do(func() {
+		close(c.quit)
+	})
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.conn == nil || c.conn.IsClosed() {
+		return nil
 	}
+	// drain unsubscribes all subscriptions after the pending messages are handled
+	return c.conn.Drain()
 }
 
-// Run .
+// Run connect to nats and subscribe all subjects again
 func (c *Consumer) Run() error {
-	var err error
 	opts := nats.Options{
+		Url:          c.addr,
 		MaxReconnect: -1,
 		ClosedCB: func(conn *nats.Conn) {
-			c.connClose <- true
 			log.Println("nats consumer - connection closed cb")
+			select {
+			case c.connClose <- true:
+			case <-c.quit:
+			}
 		},
 		DisconnectedErrCB: func(conn *nats.Conn, err error) {
 			log.Println("nats consumer - connection disconnected err cb")
@@ -69,8 +95,24 @@ func (c *Consumer) Run() error {
 			log.Println("nats consumer - connection async err cb")
 		},
 	}
-	c.conn, err = opts.Connect()
-	return err
+	conn, err := opts.Connect()
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, s := range c.subs {
+		if s.ctx.Err() != nil {
+			continue
+		}
+		if err := c.subscribe(conn, s); err != nil {
+			conn.Close()
+			return err
+		}
+	}
+	c.conn = conn
+	return nil
 }
 
 // ReConnect .
@@ -85,10 +127,6 @@ func (c *Consumer) ReConnect() {
 			return
 		}
 
-		if !c.conn.IsClosed() {
-			c.conn.Close()
-		}
-
 	quit:
 		for {
 			select {
@@ -111,23 +149,49 @@ func (c *Consumer) ReConnect() {
 	}
 }
 
-// Consume consume data from nats queue
-func (c *Consumer) Consume(topic string, handler interface{}) error {
-	encodeConn, err := nats.NewEncodedConn(c.conn, nats.JSON_ENCODER)
-	if err != nil {
+// Subscribe consume data from the nats subject.
+// NOTE: core nats is at-most-once, a nacked message is only logged.
+func (c *Consumer) Subscribe(ctx context.Context, topic string, handler queue.Handler) error {
+	s := &subscription{ctx: ctx, topic: topic, handler: handler}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn == nil {
+		return nats.ErrConnectionClosed
+	}
+	if err := c.subscribe(c.conn, s); err != nil {
 		return err
 	}
+	c.subs = append(c.subs, s)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			c.mu.RLock()
+			_ = s.sub.Unsubscribe()
+			c.mu.RUnlock()
+		case <-c.quit:
+		}
+	}()
 
-	if c.subscribe != nil {
-		_ = c.subscribe.Unsubscribe()
-		c.subscribe = nil
+	return nil
+}
+
+func (c *Consumer) subscribe(conn *nats.Conn, s *subscription) error {
+	cb := func(nm *nats.Msg) {
+		if err := s.handler(s.ctx, toMessage(nm)); err != nil {
+			log.Printf("nats consumer - handle message of %s err: %v", nm.Subject, err)
+		}
 	}
 
-	c.subscribe, err = encodeConn.Subscribe(topic, handler)
+	var err error
+	if c.group != "" {
+		s.sub, err = conn.QueueSubscribe(s.topic, c.group, cb)
+	} else {
+		s.sub, err = conn.Subscribe(s.topic, cb)
+	}
 	if err != nil {
 		return err
 	}
-	_ = encodeConn.Flush()
-
-	return nil
+	return conn.Flush()
 }
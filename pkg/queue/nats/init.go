@@ -1,5 +1,14 @@
 package nats
 
+import (
+	"errors"
+
+	"github.com/go-eagle/eagle/pkg/queue"
+)
+
+// headerTimestamp carries Message.Timestamp
+const headerTimestamp = "x-message-timestamp"
+
 var (
 	// Queue nats queue
 	Queue *Producer
@@ -14,3 +23,40 @@ type Config struct {
 func Init(cfg *Config) {
 	Queue = NewProducer(cfg.Addr)
 }
+
+func init() {
+	queue.Register("nats", driver{})
+}
+
+type driver struct{}
+
+func (driver) NewProducer(cfg *queue.Config) (queue.Producer, error) {
+	if len(cfg.Addrs) == 0 {
+		return nil, errors.New("nats: addrs is empty")
+	}
+	p := &Producer{
+		addr:      cfg.Addrs[0],
+		connClose: make(chan bool),
+		quit:      make(chan struct{}),
+	}
+	if err := p.Start(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (driver) NewConsumer(cfg *queue.Config) (queue.Consumer, error) {
+	if len(cfg.Addrs) == 0 {
+		return nil, errors.New("nats: addrs is empty")
+	}
+	c := &Consumer{
+		addr:      cfg.Addrs[0],
+		group:     cfg.Group,
+		connClose: make(chan bool),
+		quit:      make(chan struct{}),
+	}
+	if err := c.Start(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
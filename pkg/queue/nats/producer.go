@@ -1,18 +1,24 @@
 package nats
 
 import (
+	"context"
 	"log"
+	"sync"
 	"time"
 
 	"github.com/nats-io/nats.go"
+
+	"github.com/go-eagle/eagle/pkg/queue"
 )
 
 // Producer define a nats producer
 type Producer struct {
 	addr      string
+	mu        sync.RWMutex
 	conn      *nats.Conn
 	connClose chan bool
 	quit      chan struct{}
+	once      sync.Once
 }
 
 // NewProducer create a producer
@@ -40,22 +46,31 @@ func (p *Producer) Start() error {
 	return nil
 }
 
-// Stop .
-func (p *Producer) Stop() {
-	close(p.quit)
-	if !p.conn.IsClosed() {
+// Close .
+func (p *Producer) Close() error {
+	p.once.Do(func() {
+		close(p.quit)
+	})
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.conn != nil && !p.conn.IsClosed() {
 		p.conn.Close()
 	}
+	return nil
 }
 
 // Run .
 func (p *Producer) Run() error {
-	var err error
 	opts := nats.Options{
+		Url:          p.addr,
 		MaxReconnect: -1,
 		ClosedCB: func(conn *nats.Conn) {
-			p.connClose <- true
 			log.Println("nats producer - connection closed cb")
+			select {
+			case p.connClose <- true:
+			case <-p.quit:
+			}
 		},
 		DisconnectedErrCB: func(conn *nats.Conn, err error) {
 			log.Println("nats producer - connection disconnected err cb")
@@ -67,8 +82,15 @@ func (p *Producer) Run() error {
 			log.Println("nats producer - connection async err cb")
 		},
 	}
-	p.conn, err = opts.Connect()
-	return err
+	conn, err := opts.Connect()
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	p.conn = conn
+	p.mu.Unlock()
+	return nil
 }
 
 // ReConnect .
@@ -83,10 +105,6 @@ func (p *Producer) ReConnect() {
 			return
 		}
 
-		if !p.conn.IsClosed() {
-			p.conn.Close()
-		}
-
 	quit:
 		for {
 			select {
@@ -109,11 +127,58 @@ func (p *Producer) ReConnect() {
 	}
 }
 
-// Publish push data to queue
-func (p *Producer) Publish(topic string, data interface{}) error {
-	encodeConn, err := nats.NewEncodedConn(p.conn, nats.JSON_ENCODER)
-	if err != nil {
+// Publish push data to the subject
+func (p *Producer) Publish(ctx context.Context, topic string, msg *queue.Message) error {
+	if err := ctx.Err(); err != nil {
 		return err
 	}
-	return encodeConn.Publish(topic, data)
+
+	p.mu.RLock()
+	conn := p.conn
+	p.mu.RUnlock()
+	if conn == nil {
+		return nats.ErrConnectionClosed
+	}
+
+	return conn.PublishMsg(toNatsMsg(topic, msg))
+}
+
+func toNatsMsg(topic string, msg *queue.Message) *nats.Msg {
+	nm := nats.NewMsg(topic)
+	nm.Data = msg.Body
+	for k, v := range msg.Headers {
+		nm.Header.Set(k, v)
+	}
+	if msg.ID != "" {
+		nm.Header.Set(queue.HeaderMessageID, msg.ID)
+	}
+	if msg.Key != "" {
+		nm.Header.Set(queue.HeaderMessageKey, msg.Key)
+	}
+	if !msg.Timestamp.IsZero() {
+		nm.Header.Set(headerTimestamp, msg.Timestamp.Format(time.RFC3339Nano))
+	}
+	return nm
+}
+
+func toMessage(nm *nats.Msg) *queue.Message {
+	msg := &queue.Message{
+		Topic:   nm.Subject,
+		Body:    nm.Data,
+		Headers: make(map[string]string, len(nm.Header)),
+	}
+	for k := range nm.Header {
+		v := nm.Header.Get(k)
+		switch k {
+		case queue.HeaderMessageID:
+			msg.ID = v
+		case queue.HeaderMessageKey:
+			msg.Key = v
+		case headerTimestamp:
+			msg.Timestamp, _ = time.Parse(time.RFC3339Nano, v)
+		default:
+			msg.Headers[k] = v
+		}
+	}
+	return msg
 }
@@ -1,39 +1,52 @@
 package rabbitmq
 
 import (
+	"context"
+	"fmt"
 	"log"
+	"sync"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/streadway/amqp"
+
+	"github.com/go-eagle/eagle/pkg/queue"
 )
 
+// subscription a queue bound to the exchange by topic
+type subscription struct {
+	ctx         context.Context
+	topic       string
+	queueName   string
+	consumerTag string
+	autoDelete  bool // Whether to delete automatically
+	handler     queue.Handler
+}
+
 // Consumer define consumer for rabbitmq
 type Consumer struct {
 	addr          string
+	mu            sync.RWMutex
 	conn          *amqp.Connection
 	channel       *amqp.Channel
 	connNotify    chan *amqp.Error
 	channelNotify chan *amqp.Error
 	quit          chan struct{}
+	once          sync.Once
 	exchange      string
-	routingKey    string
-	queueName     string
-	consumerTag   string
-	autoDelete    bool                    // Whether to delete automatically
-	handler       func(body []byte) error // Business custom consumption function
+	group         string
+	subs          []*subscription
 }
 
-// NewConsumer instance a consumer
-func NewConsumer(addr, exchange, queueName string, autoDelete bool, handler func(body []byte) error) *Consumer {
+// NewConsumer instance a consumer,
+// the consumers in the same group share one queue of a topic,
+// otherwise every subscription has an auto delete queue of its own.
+func NewConsumer(addr, exchange, group string) *Consumer {
 	return &Consumer{
-		addr:        addr,
-		exchange:    exchange,
-		routingKey:  "",
-		queueName:   queueName,
-		consumerTag: "consumer",
-		autoDelete:  autoDelete,
-		handler:     handler,
-		quit:        make(chan struct{}),
+		addr:     addr,
+		exchange: exchange,
+		group:    group,
+		quit:     make(chan struct{}),
 	}
 }
 
@@ -48,83 +61,156 @@ func (c *Consumer) Start() error {
 	return nil
 }
 
-// Stop a consumer
-func (c *Consumer) Stop() {
-	close(c.quit)
+// Close stop a consumer
+func (c *Consumer) Close() error {
+	c.once.Do(func() {
+		close(c.quit)
+	})
 
-	if !c.conn.IsClosed() {
-		// 关闭 SubMsg message delivery
-		if err := c.channel.Cancel(c.consumerTag, true); err != nil {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.conn == nil || c.conn.IsClosed() {
+		return nil
+	}
+	// Turn off message delivery
+	for _, sub := range c.subs {
+		if err := c.channel.Cancel(sub.consumerTag, false); err != nil {
 			log.Println("rabbitmq consumer - channel cancel failed: ", err)
 		}
+	}
+	return c.conn.Close()
+}
+
+// Run connect to rabbitmq and consume all subscriptions
+func (c *Consumer) Run() error {
+	conn, err := OpenConnection(c.addr)
+	if err != nil {
+		return err
+	}
 
-		if err := c.conn.Close(); err != nil {
-			log.Println("rabbitmq consumer - connection close failed: ", err)
+	channel, err := NewChannel(conn).Create()
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, sub := range c.subs {
+		if sub.ctx.Err() != nil {
+			continue
+		}
+		if err = c.consume(channel, sub); err != nil {
+			_ = channel.Close()
+			_ = conn.Close()
+			return err
 		}
 	}
+
+	c.conn, c.channel = conn, channel
+	c.connNotify = conn.NotifyClose(make(chan *amqp.Error))
+	c.channelNotify = channel.NotifyClose(make(chan *amqp.Error))
+
+	return nil
 }
 
-// Run .
-func (c *Consumer) Run() error {
-	var err error
-	if c.conn, err = OpenConnection(c.addr); err != nil {
+// Subscribe bind a queue to the exchange with the topic as routing key
+func (c *Consumer) Subscribe(ctx context.Context, topic string, handler queue.Handler) error {
+	sub := &subscription{
+		ctx:         ctx,
+		topic:       topic,
+		queueName:   fmt.Sprintf("%s.%s", c.group, topic),
+		consumerTag: uuid.New().String(),
+		handler:     handler,
+	}
+	if c.group == "" {
+		sub.queueName = fmt.Sprintf("%s.%s", topic, sub.consumerTag)
+		sub.autoDelete = true
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.channel == nil {
+		return ErrNotConnected
+	}
+	if err := c.consume(c.channel, sub); err != nil {
 		return err
 	}
+	c.subs = append(c.subs, sub)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			c.mu.RLock()
+			if err := c.channel.Cancel(sub.consumerTag, false); err != nil {
+				log.Println("rabbitmq consumer - channel cancel failed: ", err)
+			}
+			c.mu.RUnlock()
+		case <-c.quit:
+		}
+	}()
+
+	return nil
+}
 
-	if c.channel, err = NewChannel(c.conn).Create(); err != nil {
-		_ = c.conn.Close()
+// Declare declare a durable queue named topic and bind it to the exchange,
+// so that the messages published to the topic are kept, eg: dead letter messages.
+func (c *Consumer) Declare(ctx context.Context, topic string) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.channel == nil {
+		return ErrNotConnected
+	}
+	if _, err := c.channel.QueueDeclare(topic, true, false, false, false, nil); err != nil {
 		return err
 	}
+	return c.channel.QueueBind(topic, topic, c.exchange, false, nil)
+}
 
+func (c *Consumer) consume(channel *amqp.Channel, sub *subscription) error {
 	// bind queue
-	if _, err = c.channel.QueueDeclare(c.queueName, true, c.autoDelete, false, false, nil); err != nil {
-		_ = c.channel.Close()
-		_ = c.conn.Close()
+	_, err := channel.QueueDeclare(sub.queueName, true, sub.autoDelete, false, false, nil)
+	if err != nil {
 		return err
 	}
 
-	if err = c.channel.QueueBind(c.queueName, c.routingKey, c.exchange, false, nil); err != nil {
-		_ = c.channel.Close()
-		_ = c.conn.Close()
+	if err = channel.QueueBind(sub.queueName, sub.topic, c.exchange, false, nil); err != nil {
 		return err
 	}
 
-	var delivery <-chan amqp.Delivery
-	// NOTE: autoAck param
-	delivery, err = c.channel.Consume(c.queueName, c.consumerTag, true, false, false, false, nil)
+	// NOTE: ack manually by the result of handler
+	delivery, err := channel.Consume(sub.queueName, sub.consumerTag, false, false, false, false, nil)
 	if err != nil {
-		_ = c.channel.Close()
-		_ = c.conn.Close()
 		return err
 	}
 
-	go c.Handle(delivery)
-
-	c.connNotify = c.conn.NotifyClose(make(chan *amqp.Error))
-	c.channelNotify = c.channel.NotifyClose(make(chan *amqp.Error))
+	go c.Handle(sub, delivery)
 
 	return nil
 }
 
 // Handle handle data
-func (c *Consumer) Handle(delivery <-chan amqp.Delivery) {
+func (c *Consumer) Handle(sub *subscription, delivery <-chan amqp.Delivery) {
 	for d := range delivery {
-		log.Printf("Consumer received a message: %s in queue: %s", d.Body, c.queueName)
-		log.Printf("got %dB delivery: [%v] %q", len(d.Body), d.DeliveryTag, d.Body)
 		go func(delivery amqp.Delivery) {
-			if err := c.handler(delivery.Body); err == nil {
+			if err := sub.handler(sub.ctx, toMessage(sub.topic, delivery)); err == nil {
 				// NOTE: If there are now 10 messages, they are all processed concurrently, if the 10th message is processed first,
 				// Then the first 9 messages will be confirmed by delivery.Ack(true). When the next 9 messages are processed,
 				// Execute delivery.Ack(true) again, which will obviously lead to repeated confirmation of the message
 				// Report 406 PRECONDITION_FAILED error, so here is false
 				_ = delivery.Ack(false)
-			} else {
-				// Re-queue, otherwise unacknowledged messages will continue to occupy memory
+			} else if queue.IsRequeue(err) {
+				// Re-queue, eg: the server is stopping or the retry topic is unreachable
 				_ = delivery.Reject(true)
+			} else {
+				// NOTE: don't requeue, or else a poison message is delivered again and again,
+				// it's dropped or dead lettered by the x-dead-letter-exchange of the queue,
+				// use queue.RetryPolicy to retry it.
+				_ = delivery.Reject(false)
 			}
 		}(d)
 	}
-	log.Println("handle: async deliveries channel closed")
+	log.Printf("handle: async deliveries channel of queue %s closed", sub.queueName)
 }
 
 // ReConnect .
@@ -133,11 +219,11 @@ func (c *Consumer) ReConnect() {
 		select {
 		case err := <-c.connNotify:
 			if err != nil {
-				log.Fatalf("rabbitmq consumer - connection NotifyClose: %+v", err)
+				log.Printf("rabbitmq consumer - connection NotifyClose: %+v", err)
 			}
 		case err := <-c.channelNotify:
 			if err != nil {
-				log.Fatalf("rabbitmq consumer - channel NotifyClose: %+v", err)
+				log.Printf("rabbitmq consumer - channel NotifyClose: %+v", err)
 			}
 		case <-c.quit:
 			return
@@ -145,21 +231,17 @@ func (c *Consumer) ReConnect() {
 
 		// backstop
 		if !c.conn.IsClosed() {
-			//Turn off SubMsg message delivery
-			if err := c.channel.Cancel(c.consumerTag, true); err != nil {
-				log.Fatalf("rabbitmq consumer - channel cancel failed: %+v", err)
-			}
 			if err := c.conn.Close(); err != nil {
-				log.Fatalf("rabbitmq consumer - conn cancel failed: %+v", err)
+				log.Printf("rabbitmq consumer - conn cancel failed: %+v", err)
 			}
 		}
 
 		// IMPORTANT: Notify must be cleared, otherwise dead connections will not be released
 		for err := range c.channelNotify {
-			println(err)
+			log.Println(err)
 		}
 		for err := range c.connNotify {
-			println(err)
+			log.Println(err)
 		}
 
 	quit:
@@ -183,3 +265,25 @@ func (c *Consumer) ReConnect() {
 		}
 	}
 }
+
+func toMessage(topic string, d amqp.Delivery) *queue.Message {
+	msg := &queue.Message{
+		ID:        d.MessageId,
+		Topic:     topic,
+		Body:      d.Body,
+		Headers:   make(map[string]string, len(d.Headers)),
+		Timestamp: d.Timestamp,
+	}
+	for k, v := range d.Headers {
+		s, ok := v.(string)
+		if !ok {
+			s = fmt.Sprint(v)
+		}
+		if k == queue.HeaderMessageKey {
+			msg.Key = s
+			continue
+		}
+		msg.Headers[k] = s
+	}
+	return msg
+}
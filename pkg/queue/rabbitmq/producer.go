@@ -1,23 +1,31 @@
 package rabbitmq
 
 import (
+	"context"
+	"errors"
 	"log"
+	"sync"
 	"time"
 
-	"github.com/google/uuid"
 	"github.com/streadway/amqp"
+
+	"github.com/go-eagle/eagle/pkg/queue"
 )
 
+// ErrNotConnected is returned when the connection is not ready
+var ErrNotConnected = errors.New("rabbitmq: not connected")
+
 // Producer define struct for rabbitmq
 type Producer struct {
 	addr          string
+	mu            sync.RWMutex
 	conn          *amqp.Connection
 	channel       *amqp.Channel
-	routingKey    string
 	exchange      string
 	connNotify    chan *amqp.Error
 	channelNotify chan *amqp.Error
 	quit          chan struct{}
+	once          sync.Once
 }
 
 // NewProducer create a producer
@@ -41,33 +49,40 @@ func (p *Producer) Start() error {
 	return nil
 }
 
-// Stop .
-func (p *Producer) Stop() {
-	close(p.quit)
+// Close stop the producer
+func (p *Producer) Close() error {
+	p.once.Do(func() {
+		close(p.quit)
+	})
 
-	if !p.conn.IsClosed() {
-		if err := p.conn.Close(); err != nil {
-			log.Println("rabbitmq producer - connection close failed: ", err)
-		}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.conn != nil && !p.conn.IsClosed() {
+		return p.conn.Close()
 	}
+	return nil
 }
 
 // Run .
 func (p *Producer) Run() error {
-	var err error
-	if p.conn, err = OpenConnection(p.addr); err != nil {
+	conn, err := OpenConnection(p.addr)
+	if err != nil {
 		return err
 	}
 
-	if p.channel, err = NewChannel(p.conn).Create(); err != nil {
-		_ = p.conn.Close()
+	channel, err := NewChannel(conn).Create()
+	if err != nil {
+		_ = conn.Close()
 		return err
 	}
 
-	p.connNotify = p.conn.NotifyClose(make(chan *amqp.Error))
-	p.channelNotify = p.channel.NotifyClose(make(chan *amqp.Error))
+	p.mu.Lock()
+	p.conn, p.channel = conn, channel
+	p.connNotify = conn.NotifyClose(make(chan *amqp.Error))
+	p.channelNotify = channel.NotifyClose(make(chan *amqp.Error))
+	p.mu.Unlock()
 
-	return err
+	return nil
 }
 
 // ReConnect .
@@ -123,19 +138,38 @@ func (p *Producer) ReConnect() {
 	}
 }
 
-// Publish push data to queue
-func (p *Producer) Publish(routingKey, message string) error {
-	return p.channel.Publish(
+// Publish push data to the exchange, the topic is used as routing key
+func (p *Producer) Publish(ctx context.Context, topic string, msg *queue.Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	p.mu.RLock()
+	channel := p.channel
+	p.mu.RUnlock()
+	if channel == nil {
+		return ErrNotConnected
+	}
+
+	headers := make(amqp.Table, len(msg.Headers)+1)
+	for k, v := range msg.Headers {
+		headers[k] = v
+	}
+	if msg.Key != "" {
+		headers[queue.HeaderMessageKey] = msg.Key
+	}
+
+	return channel.Publish(
 		p.exchange, // exchange
-		routingKey, // routing key
+		topic,      // routing key
 		false,      // mandatory
 		false,      // immediate
 		amqp.Publishing{
+			Headers:      headers,
 			DeliveryMode: amqp.Persistent,
 			ContentType:  "text/plain",
-			MessageId:    uuid.New().String(),
-			Type:         "",
-			Body:         []byte(message),
-			Timestamp:    time.Now(),
+			MessageId:    msg.ID,
+			Body:         msg.Body,
+			Timestamp:    msg.Timestamp,
 		})
 }
@@ -0,0 +1,37 @@
+package rabbitmq
+
+import (
+	"errors"
+
+	"github.com/go-eagle/eagle/pkg/queue"
+)
+
+var _ queue.Declarer = (*Consumer)(nil)
+
+func init() {
+	queue.Register("rabbitmq", driver{})
+}
+
+type driver struct{}
+
+func (driver) NewProducer(cfg *queue.Config) (queue.Producer, error) {
+	if len(cfg.Addrs) == 0 {
+		return nil, errors.New("rabbitmq: addrs is empty")
+	}
+	p := NewProducer(cfg.Addrs[0], cfg.Exchange)
+	if err := p.Start(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (driver) NewConsumer(cfg *queue.Config) (queue.Consumer, error) {
+	if len(cfg.Addrs) == 0 {
+		return nil, errors.New("rabbitmq: addrs is empty")
+	}
+	c := NewConsumer(cfg.Addrs[0], cfg.Exchange, cfg.Group)
+	if err := c.Start(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
@@ -0,0 +1,198 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// HeaderRetryCount is the number of times the message has been retried
+	HeaderRetryCount = "x-retry-count"
+	// HeaderRetryAt is the time in unix milliseconds a retried message is handled after
+	HeaderRetryAt = "x-retry-at"
+	// HeaderOriginalTopic is the topic the message is published to at first
+	HeaderOriginalTopic = "x-original-topic"
+	// HeaderError is the last handler error of a dead lettered message
+	HeaderError = "x-error"
+	// HeaderGroup is the consumer group of a dead lettered message
+	HeaderGroup = "x-consumer-group"
+)
+
+// RetryPolicy retry policy of consumers
+type RetryPolicy struct {
+	// MaxAttempts max number of times a message is handled, including the first time
+	MaxAttempts int
+	// InitialInterval backoff before the first retry
+	InitialInterval time.Duration
+	// MaxInterval max backoff between two retries
+	MaxInterval time.Duration
+	// Multiplier the factor the backoff grows by after each retry
+	Multiplier float64
+	// DeadLetterSuffix exhausted messages are published to topic + suffix
+	DeadLetterSuffix string
+}
+
+func (p *RetryPolicy) withDefaults() RetryPolicy {
+	policy := *p
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = 3
+	}
+	if policy.InitialInterval <= 0 {
+		policy.InitialInterval = time.Second
+	}
+	if policy.MaxInterval <= 0 {
+		policy.MaxInterval = 30 * time.Second
+	}
+	if policy.Multiplier < 1 {
+		policy.Multiplier = 2
+	}
+	if policy.DeadLetterSuffix == "" {
+		policy.DeadLetterSuffix = ".dlq"
+	}
+	return policy
+}
+
+// Backoff return the wait time before the nth retry, n starts from 1
+func (p *RetryPolicy) Backoff(n int) time.Duration {
+	interval := float64(p.InitialInterval)
+	for i := 1; i < n; i++ {
+		interval *= p.Multiplier
+		if interval >= float64(p.MaxInterval) {
+			return p.MaxInterval
+		}
+	}
+	return time.Duration(interval)
+}
+
+// Declarer is implemented by brokers that must declare a topic before
+// the messages published to it can be kept, eg: rabbitmq binds a queue to it.
+type Declarer interface {
+	Declare(ctx context.Context, topic string) error
+}
+
+// retryConsumer retry the failed messages and route the exhausted ones to dead letter topic
+type retryConsumer struct {
+	Consumer
+	producer Producer
+	group    string
+	// retryID names the retry topics, it's the group or a random id without group
+	retryID string
+	policy  RetryPolicy
+}
+
+// NewRetryConsumer wrap a consumer with retry policy.
+// A failed message is acked and published to the retry topic of the next attempt with HeaderRetryCount increased,
+// once MaxAttempts is reached it is published to the dead letter topic.
+// the nth retry topic is <topic>.retry.<group>.<n>, its messages are delayed by Backoff(n),
+// so waiting for the backoff only blocks the retry topic rather than the topic.
+// without group the retry topics are named by a random id, so the other subscribers will not get the retries.
+func NewRetryConsumer(consumer Consumer, producer Producer, group string, policy *RetryPolicy) Consumer {
+	retryID := group
+	if retryID == "" {
+		retryID = uuid.New().String()
+	}
+	return &retryConsumer{
+		Consumer: consumer,
+		producer: producer,
+		group:    group,
+		retryID:  retryID,
+		policy:   policy.withDefaults(),
+	}
+}
+
+// Subscribe subscribe the topic and its retry topics
+func (c *retryConsumer) Subscribe(ctx context.Context, topic string, handler Handler) error {
+	if d, ok := c.Consumer.(Declarer); ok {
+		if err := d.Declare(ctx, topic+c.policy.DeadLetterSuffix); err != nil {
+			return err
+		}
+	}
+
+	h := c.wrap(topic, handler)
+	if err := c.Consumer.Subscribe(ctx, topic, h); err != nil {
+		return err
+	}
+	for n := 1; n < c.policy.MaxAttempts; n++ {
+		if err := c.Consumer.Subscribe(ctx, c.retryTopic(topic, n), h); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close close the consumer and the producer
+func (c *retryConsumer) Close() error {
+	err := c.Consumer.Close()
+	if e := c.producer.Close(); e != nil && err == nil {
+		err = e
+	}
+	return err
+}
+
+// retryTopic the topic of the nth retry
+func (c *retryConsumer) retryTopic(topic string, n int) string {
+	return topic + ".retry." + c.retryID + "." + strconv.Itoa(n)
+}
+
+func (c *retryConsumer) wrap(topic string, handler Handler) Handler {
+	return func(ctx context.Context, msg *Message) error {
+		// the message from retry topic is handled as the original one,
+		// it's copied so that the delivered one is untouched
+		msg = msg.clone()
+		msg.Topic = topic
+
+		// NOTE: the messages of a retry topic have the same delay,
+		// so the ones behind are ready when the first one is handled.
+		if at, err := strconv.ParseInt(msg.Header(HeaderRetryAt), 10, 64); err == nil {
+			if wait := time.Until(time.Unix(0, at*int64(time.Millisecond))); wait > 0 {
+				timer := time.NewTimer(wait)
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					// requeue, the broker delivers it again
+					return fmt.Errorf("%w: %v", ErrServerStopped, ctx.Err())
+				case <-timer.C:
+				}
+			}
+		}
+
+		err := handler(ctx, msg)
+		if err == nil || IsRequeue(err) {
+			return err
+		}
+
+		retries, _ := strconv.Atoi(msg.Header(HeaderRetryCount))
+		if retries+1 >= c.policy.MaxAttempts {
+			return c.deadLetter(ctx, topic, msg, err)
+		}
+
+		n := retries + 1
+		// round up, so the message is not handled before the backoff
+		retryAt := (time.Now().Add(c.policy.Backoff(n)).UnixNano() + int64(time.Millisecond) - 1) / int64(time.Millisecond)
+		retry := msg.clone().
+			WithHeader(HeaderRetryCount, strconv.Itoa(n)).
+			WithHeader(HeaderRetryAt, strconv.FormatInt(retryAt, 10))
+		if err := c.producer.Publish(ctx, c.retryTopic(topic, n), retry); err != nil {
+			// the failure of the broker isn't the one of the message, requeue it
+			return fmt.Errorf("%w: publish retry: %v", ErrRequeue, err)
+		}
+		_metricRetry.Inc(topic, c.group)
+		return nil
+	}
+}
+
+func (c *retryConsumer) deadLetter(ctx context.Context, topic string, msg *Message, cause error) error {
+	dead := msg.clone().
+		WithHeader(HeaderOriginalTopic, topic).
+		WithHeader(HeaderGroup, c.group).
+		WithHeader(HeaderError, cause.Error())
+	if err := c.producer.Publish(ctx, topic+c.policy.DeadLetterSuffix, dead); err != nil {
+		return fmt.Errorf("%w: publish dead letter: %v", ErrRequeue, err)
+	}
+	_metricDeadLetter.Inc(topic, c.group)
+	return nil
+}
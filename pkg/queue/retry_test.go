@@ -0,0 +1,206 @@
+package queue_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/go-eagle/eagle/pkg/queue"
+	"github.com/go-eagle/eagle/pkg/queue/memory"
+)
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := &queue.RetryPolicy{
+		InitialInterval: 100 * time.Millisecond,
+		MaxInterval:     time.Second,
+		Multiplier:      2,
+	}
+	assert.Equal(t, 100*time.Millisecond, policy.Backoff(1))
+	assert.Equal(t, 200*time.Millisecond, policy.Backoff(2))
+	assert.Equal(t, 800*time.Millisecond, policy.Backoff(4))
+	assert.Equal(t, time.Second, policy.Backoff(5))
+}
+
+func TestRetryConsumer(t *testing.T) {
+	policy := &queue.RetryPolicy{MaxAttempts: 3, InitialInterval: time.Millisecond}
+
+	tests := []struct {
+		name  string
+		group string
+	}{
+		{name: "retry by retry topic", group: "test-group"},
+		{name: "retry without group", group: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			broker := memory.NewBroker()
+			defer broker.Close()
+			consumer := queue.NewRetryConsumer(broker.NewConsumer(tt.group), broker, tt.group, policy)
+
+			var attempts int32
+			done := make(chan *queue.Message, 1)
+			err := consumer.Subscribe(context.Background(), "test-topic", func(ctx context.Context, msg *queue.Message) error {
+				if atomic.AddInt32(&attempts, 1) < 3 {
+					return errors.New("try again")
+				}
+				done <- msg
+				return nil
+			})
+			assert.NoError(t, err)
+			assert.NoError(t, broker.Publish(context.Background(), "test-topic", queue.NewMessage([]byte("hello"))))
+
+			select {
+			case msg := <-done:
+				assert.Equal(t, "test-topic", msg.Topic)
+				assert.Equal(t, "2", msg.Header(queue.HeaderRetryCount))
+			case <-time.After(time.Second):
+				t.Fatal("message is not retried")
+			}
+		})
+	}
+}
+
+func TestRetryConsumer_Backoff(t *testing.T) {
+	broker := memory.NewBroker()
+	defer broker.Close()
+
+	policy := &queue.RetryPolicy{MaxAttempts: 2, InitialInterval: 200 * time.Millisecond}
+	consumer := queue.NewRetryConsumer(broker.NewConsumer("test-group"), broker, "test-group", policy)
+
+	handled := make(chan string, 3)
+	var failed int32
+	err := consumer.Subscribe(context.Background(), "test-topic", func(ctx context.Context, msg *queue.Message) error {
+		if string(msg.Body) == "fail" && atomic.AddInt32(&failed, 1) == 1 {
+			return errors.New("try again")
+		}
+		handled <- string(msg.Body)
+		return nil
+	})
+	assert.NoError(t, err)
+
+	begin := time.Now()
+	assert.NoError(t, broker.Publish(context.Background(), "test-topic", queue.NewMessage([]byte("fail"))))
+	assert.NoError(t, broker.Publish(context.Background(), "test-topic", queue.NewMessage([]byte("ok"))))
+
+	// the topic is not blocked by the backoff of the failed message
+	assert.Equal(t, "ok", <-handled)
+	assert.Less(t, int64(time.Since(begin)), int64(100*time.Millisecond))
+
+	select {
+	case body := <-handled:
+		assert.Equal(t, "fail", body)
+		assert.GreaterOrEqual(t, int64(time.Since(begin)), int64(200*time.Millisecond))
+	case <-time.After(time.Second):
+		t.Fatal("message is not retried")
+	}
+}
+
+func TestRetryConsumer_DeadLetter(t *testing.T) {
+	broker := memory.NewBroker()
+	defer broker.Close()
+
+	dlq := make(chan *queue.Message, 1)
+	dlqConsumer := broker.NewConsumer("dlq-group")
+	_ = dlqConsumer.Subscribe(context.Background(), "test-topic.dlq", func(ctx context.Context, msg *queue.Message) error {
+		dlq <- msg
+		return nil
+	})
+
+	policy := &queue.RetryPolicy{MaxAttempts: 2, InitialInterval: time.Millisecond}
+	consumer := queue.NewRetryConsumer(broker.NewConsumer("test-group"), broker, "test-group", policy)
+	var attempts int32
+	_ = consumer.Subscribe(context.Background(), "test-topic", func(ctx context.Context, msg *queue.Message) error {
+		atomic.AddInt32(&attempts, 1)
+		return errors.New("poison message")
+	})
+	assert.NoError(t, broker.Publish(context.Background(), "test-topic", queue.NewMessage([]byte("hello"))))
+
+	select {
+	case msg := <-dlq:
+		assert.Equal(t, int32(2), atomic.LoadInt32(&attempts))
+		assert.Equal(t, "hello", string(msg.Body))
+		assert.Equal(t, "test-topic", msg.Header(queue.HeaderOriginalTopic))
+		assert.Equal(t, "test-group", msg.Header(queue.HeaderGroup))
+		assert.Equal(t, "poison message", msg.Header(queue.HeaderError))
+	case <-time.After(time.Second):
+		t.Fatal("message is not dead lettered")
+	}
+}
+
+// handlerConsumer keep the handlers of the topics, the messages are delivered by calling them
+type handlerConsumer struct {
+	handlers map[string]queue.Handler
+}
+
+func (c *handlerConsumer) Subscribe(ctx context.Context, topic string, handler queue.Handler) error {
+	c.handlers[topic] = handler
+	return nil
+}
+
+func (c *handlerConsumer) Close() error {
+	return nil
+}
+
+// failedProducer fail to publish
+type failedProducer struct{}
+
+func (failedProducer) Publish(ctx context.Context, topic string, msg *queue.Message) error {
+	return errors.New("broker is down")
+}
+
+func (failedProducer) Close() error {
+	return nil
+}
+
+func TestRetryConsumer_Requeue(t *testing.T) {
+	t.Run("stopped while waiting for the retry", func(t *testing.T) {
+		consumer := &handlerConsumer{handlers: make(map[string]queue.Handler)}
+		policy := &queue.RetryPolicy{MaxAttempts: 2, InitialInterval: time.Hour}
+		rc := queue.NewRetryConsumer(consumer, failedProducer{}, "test-group", policy)
+		assert.NoError(t, rc.Subscribe(context.Background(), "test-topic", func(ctx context.Context, msg *queue.Message) error {
+			t.Fatal("the message is handled before the backoff")
+			return nil
+		}))
+
+		msg := queue.NewMessage([]byte("hello")).
+			WithHeader(queue.HeaderRetryCount, "1").
+			WithHeader(queue.HeaderRetryAt, strconv.FormatInt(time.Now().Add(time.Hour).UnixNano()/int64(time.Millisecond), 10))
+		msg.Topic = "test-topic.retry.test-group.1"
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		err := consumer.handlers["test-topic.retry.test-group.1"](ctx, msg)
+		assert.ErrorIs(t, err, queue.ErrServerStopped)
+		assert.True(t, queue.IsRequeue(err))
+		assert.Equal(t, "test-topic.retry.test-group.1", msg.Topic)
+	})
+
+	tests := []struct {
+		name    string
+		retries string
+	}{
+		{name: "failed to publish the retry", retries: "0"},
+		{name: "failed to publish the dead letter", retries: "1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			consumer := &handlerConsumer{handlers: make(map[string]queue.Handler)}
+			policy := &queue.RetryPolicy{MaxAttempts: 2, InitialInterval: time.Millisecond}
+			rc := queue.NewRetryConsumer(consumer, failedProducer{}, "test-group", policy)
+			assert.NoError(t, rc.Subscribe(context.Background(), "test-topic", func(ctx context.Context, msg *queue.Message) error {
+				return errors.New("try again")
+			}))
+
+			msg := queue.NewMessage([]byte("hello")).WithHeader(queue.HeaderRetryCount, tt.retries)
+			msg.Topic = "test-topic"
+			err := consumer.handlers["test-topic"](context.Background(), msg)
+			assert.ErrorIs(t, err, queue.ErrRequeue)
+			// the delivered message is untouched
+			assert.Equal(t, map[string]string{queue.HeaderRetryCount: tt.retries}, msg.Headers)
+		})
+	}
+}
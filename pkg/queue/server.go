@@ -0,0 +1,194 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-eagle/eagle/pkg/transport"
+)
+
+var (
+	_ transport.Server   = (*Server)(nil)
+	_ transport.Metadata = (*Server)(nil)
+)
+
+// ErrServerStopped is returned by handlers when the server is draining,
+// the message is nacked and will be delivered again by the broker.
+var ErrServerStopped = errors.New("queue: server stopped")
+
+// ErrRequeue is returned by handlers when the message is not handled for a temporary reason,
+// eg: the retry topic is unreachable, the message is delivered again rather than rejected.
+var ErrRequeue = errors.New("queue: requeue")
+
+// IsRequeue check if the message should be delivered again rather than rejected by the error
+func IsRequeue(err error) bool {
+	return errors.Is(err, ErrRequeue) || errors.Is(err, ErrServerStopped)
+}
+
+// ServerOption is queue server option
+type ServerOption func(*Server)
+
+// WithName with server name, it is used as the prefix of the registry metadata.
+func WithName(name string) ServerOption {
+	return func(s *Server) {
+		s.name = name
+	}
+}
+
+// WithDrainTimeout with the max time to wait for running handlers when stopping.
+func WithDrainTimeout(timeout time.Duration) ServerOption {
+	return func(s *Server) {
+		s.drainTimeout = timeout
+	}
+}
+
+type route struct {
+	topic   string
+	handler Handler
+}
+
+// Server wrap a consumer as transport.Server, so it can be managed by app.App, eg:
+//
+//	srv := queue.NewServer(consumer)
+//	srv.Handle("order-created", handler)
+//	app.New(app.WithServer(httpSrv, srv))
+type Server struct {
+	consumer     Consumer
+	name         string
+	drainTimeout time.Duration
+	routes       []route
+
+	mu       sync.Mutex
+	wg       sync.WaitGroup
+	stopping bool
+	// cancel stop the subscriptions
+	cancel context.CancelFunc
+	// drainCtx is the ctx of the running handlers, it's canceled after the drain deadline
+	drainCtx    context.Context
+	drainCancel context.CancelFunc
+}
+
+// NewServer create a queue server
+func NewServer(consumer Consumer, opts ...ServerOption) *Server {
+	srv := &Server{
+		consumer:     consumer,
+		name:         "queue",
+		drainTimeout: 10 * time.Second,
+	}
+	for _, o := range opts {
+		o(srv)
+	}
+	srv.drainCtx, srv.drainCancel = context.WithCancel(context.Background())
+	return srv
+}
+
+// Handle register a handler for the topic, it must be called before Start.
+func (s *Server) Handle(topic string, handler Handler) {
+	s.routes = append(s.routes, route{topic: topic, handler: handler})
+}
+
+// Metadata return the subscribed topics to registry
+func (s *Server) Metadata() map[string]string {
+	topics := make([]string, 0, len(s.routes))
+	for _, r := range s.routes {
+		topics = append(topics, r.topic)
+	}
+	sort.Strings(topics)
+	return map[string]string{
+		s.name + ".topics": strings.Join(topics, ","),
+	}
+}
+
+// Start subscribe all topics and block until ctx is done or the server is stopped
+func (s *Server) Start(ctx context.Context) error {
+	// subscriptions should not be canceled by app, they are stopped in Stop
+	subCtx, cancel := context.WithCancel(context.Background())
+	s.mu.Lock()
+	s.cancel = cancel
+	s.mu.Unlock()
+
+	for _, r := range s.routes {
+		if err := s.consumer.Subscribe(subCtx, r.topic, s.wrap(r.handler)); err != nil {
+			cancel()
+			return err
+		}
+	}
+	log.Printf("[%s] server is consuming topics: %s", s.name, s.Metadata()[s.name+".topics"])
+
+	select {
+	case <-ctx.Done():
+	case <-subCtx.Done():
+	}
+	return nil
+}
+
+// Stop stop receiving messages, wait for running handlers and close the consumer.
+// the handlers are canceled if they are still running after the drain timeout or the deadline of ctx.
+func (s *Server) Stop(ctx context.Context) error {
+	log.Printf("[%s] server is stopping", s.name)
+
+	s.mu.Lock()
+	s.stopping = true
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	timer := time.NewTimer(s.drainTimeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+	case <-timer.C:
+		log.Printf("[%s] server drain timeout after %s", s.name, s.drainTimeout)
+	case <-ctx.Done():
+		log.Printf("[%s] server drain canceled: %v", s.name, ctx.Err())
+	}
+	s.drainCancel()
+
+	return s.consumer.Close()
+}
+
+func (s *Server) wrap(handler Handler) Handler {
+	return func(ctx context.Context, msg *Message) error {
+		s.mu.Lock()
+		if s.stopping {
+			s.mu.Unlock()
+			return ErrServerStopped
+		}
+		s.wg.Add(1)
+		s.mu.Unlock()
+		defer s.wg.Done()
+
+		// NOTE: ctx of the consumer is canceled once the subscription is stopped,
+		// so the handler uses the drain ctx with the values of ctx.
+		return handler(drainContext{Context: ctx, drain: s.drainCtx}, msg)
+	}
+}
+
+// drainContext the values of the consumer ctx, the deadline and cancellation of the drain ctx
+type drainContext struct {
+	context.Context
+	drain context.Context
+}
+
+func (c drainContext) Deadline() (time.Time, bool) {
+	return c.drain.Deadline()
+}
+
+func (c drainContext) Done() <-chan struct{} {
+	return c.drain.Done()
+}
+
+func (c drainContext) Err() error {
+	return c.drain.Err()
+}
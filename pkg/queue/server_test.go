@@ -0,0 +1,121 @@
+package queue_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/go-eagle/eagle/pkg/queue"
+	"github.com/go-eagle/eagle/pkg/queue/memory"
+)
+
+func TestServer(t *testing.T) {
+	broker := memory.NewBroker()
+	defer broker.Close()
+
+	srv := queue.NewServer(broker.NewConsumer("test-group"), queue.WithName("order"))
+
+	var handled int32
+	release := make(chan struct{})
+	started := make(chan struct{}, 1)
+	srv.Handle("order-created", func(ctx context.Context, msg *queue.Message) error {
+		started <- struct{}{}
+		<-release
+		// the running handler is drained, not canceled
+		if ctx.Err() == nil {
+			atomic.AddInt32(&handled, 1)
+		}
+		return nil
+	})
+	srv.Handle("order-canceled", func(ctx context.Context, msg *queue.Message) error {
+		return nil
+	})
+	assert.Equal(t, map[string]string{"order.topics": "order-canceled,order-created"}, srv.Metadata())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.Start(ctx)
+	}()
+
+	assert.Eventually(t, func() bool {
+		return broker.Publish(context.Background(), "order-created", queue.NewMessage([]byte("1"))) == nil &&
+			len(started) > 0
+	}, time.Second, 10*time.Millisecond)
+
+	// stop should wait for the running handler
+	cancel()
+	assert.NoError(t, <-errCh)
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- srv.Stop(context.Background())
+	}()
+
+	select {
+	case <-stopped:
+		t.Fatal("server stopped before the running handler finished")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	assert.NoError(t, <-stopped)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&handled))
+}
+
+func TestServer_DrainTimeout(t *testing.T) {
+	tests := []struct {
+		name         string
+		drainTimeout time.Duration
+		stopTimeout  time.Duration
+	}{
+		{name: "drain timeout", drainTimeout: 50 * time.Millisecond, stopTimeout: time.Minute},
+		{name: "stop ctx deadline", drainTimeout: time.Minute, stopTimeout: 50 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			broker := memory.NewBroker()
+			defer broker.Close()
+
+			srv := queue.NewServer(broker.NewConsumer(""), queue.WithDrainTimeout(tt.drainTimeout))
+			started := make(chan struct{})
+			canceled := make(chan struct{})
+			srv.Handle("test-topic", func(ctx context.Context, msg *queue.Message) error {
+				close(started)
+				select {
+				case <-ctx.Done():
+					close(canceled)
+				case <-time.After(time.Second):
+				}
+				return nil
+			})
+
+			go func() {
+				_ = srv.Start(context.Background())
+			}()
+			assert.Eventually(t, func() bool {
+				_ = broker.Publish(context.Background(), "test-topic", queue.NewMessage([]byte("1")))
+				select {
+				case <-started:
+					return true
+				default:
+					return false
+				}
+			}, time.Second, 10*time.Millisecond)
+
+			ctx, cancel := context.WithTimeout(context.Background(), tt.stopTimeout)
+			defer cancel()
+			begin := time.Now()
+			_ = srv.Stop(ctx)
+			assert.Less(t, int64(time.Since(begin)), int64(500*time.Millisecond))
+
+			// the handler is canceled after the drain deadline
+			select {
+			case <-canceled:
+			case <-time.After(500 * time.Millisecond):
+				t.Fatal("handler is not canceled after the drain deadline")
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+// Package ratelimit provides quota limiters shared across the replicas by redis.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-eagle/eagle/pkg/config"
+)
+
+const (
+	// AlgorithmTokenBucket allow bursts up to Burst, refill Limit tokens per Period
+	AlgorithmTokenBucket = "token_bucket"
+	// AlgorithmSlidingWindow allow at most Limit requests in any Period
+	AlgorithmSlidingWindow = "sliding_window"
+
+	// KeyByIP limit by the client ip
+	KeyByIP = "ip"
+	// KeyByUID limit by the uid set by middleware.Auth, fallback to the client ip
+	KeyByUID = "uid"
+	// KeyByRoute limit the route as a whole
+	KeyByRoute = "route"
+
+	// DefaultPrefix the default key prefix in redis
+	DefaultPrefix = "eagle:ratelimit"
+)
+
+// Result the result of a limiter
+type Result struct {
+	// Allowed the request is allowed
+	Allowed bool
+	// Limit the quota of the limiter
+	Limit int64
+	// Remaining the remaining quota
+	Remaining int64
+	// RetryAfter the time to wait if the request is not allowed
+	RetryAfter time.Duration
+	// ResetAfter the time until the quota is fully restored
+	ResetAfter time.Duration
+}
+
+// Limiter is a distributed rate limiter
+type Limiter interface {
+	// Allow take a quota of the key
+	Allow(ctx context.Context, key string) (*Result, error)
+}
+
+// Config ratelimit config
+type Config struct {
+	// Prefix the key prefix in redis, default is DefaultPrefix
+	Prefix string
+	// Rules the first matched rule is used
+	Rules []Rule
+}
+
+// Rule limit the requests of a route
+type Rule struct {
+	// Path the route path, eg: /v1/users/:id, * matches all the routes
+	Path string
+	// Method the request method, empty matches all the methods
+	Method string
+	// Algorithm token_bucket or sliding_window, default is token_bucket
+	Algorithm string
+	// KeyBy ip, uid or route, default is ip
+	KeyBy string
+	// Limit the requests allowed per Period
+	Limit int64
+	// Period default is 1s
+	Period time.Duration
+	// Burst the capacity of the token bucket, default is Limit
+	Burst int64
+}
+
+// Validate check the rules, it's called before the config is reloaded
+func (c *Config) Validate() error {
+	for i, r := range c.Rules {
+		switch {
+		case r.Path == "":
+			return fmt.Errorf("rules[%d]: path is required", i)
+		case r.Limit <= 0:
+			return fmt.Errorf("rules[%d]: limit must be greater than 0", i)
+		case r.Period < 0 || (r.Period > 0 && r.Period < time.Millisecond):
+			return fmt.Errorf("rules[%d]: period must be at least 1ms", i)
+		}
+		switch r.Algorithm {
+		case "", AlgorithmTokenBucket, AlgorithmSlidingWindow:
+		default:
+			return fmt.Errorf("rules[%d]: unknown algorithm %q", i, r.Algorithm)
+		}
+		switch r.KeyBy {
+		case "", KeyByIP, KeyByUID, KeyByRoute:
+		default:
+			return fmt.Errorf("rules[%d]: unknown key by %q", i, r.KeyBy)
+		}
+	}
+	return nil
+}
+
+// Match check if the rule matches the route
+func (r Rule) Match(method, path string) bool {
+	if r.Method != "" && r.Method != method {
+		return false
+	}
+	return r.Path == "*" || r.Path == path
+}
+
+// LoadConf load the ratelimit config from ratelimit.yaml
+func LoadConf() (*Config, error) {
+	var c Config
+	if err := config.Load("ratelimit", &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// WatchConf load the ratelimit config from ratelimit.yaml,
+// onChange is called with the new config every time the file is changed.
+func WatchConf(onChange func(c *Config)) (*Config, error) {
+	var c Config
+	_, err := config.Watch("ratelimit", &c, func(_, new interface{}) {
+		onChange(new.(*Config))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
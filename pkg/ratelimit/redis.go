@@ -0,0 +1,175 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+)
+
+var (
+	// refill the bucket by the elapsed time, then take a token
+	// returns allowed, remaining, retry after(ms), reset after(ms)
+	// NOTE: the time of redis is used, so the clocks of the instances don't matter
+	tokenBucketScript = redis.NewScript(`
+redis.replicate_commands()
+local capacity = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+local bucket = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(bucket[1])
+local ts = tonumber(bucket[2])
+if tokens == nil or ts == nil then
+	tokens = capacity
+	ts = now
+end
+tokens = math.min(capacity, tokens + math.max(0, now - ts) * rate)
+local allowed = 0
+local retry = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	retry = math.ceil((1 - tokens) / rate)
+end
+local reset = math.ceil((capacity - tokens) / rate)
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', tostring(now))
+redis.call('PEXPIRE', KEYS[1], math.max(reset, 1))
+return {allowed, math.floor(tokens), retry, reset}`)
+
+	// log the requests in a sorted set by time, the expired ones are removed
+	// returns allowed, remaining, retry after(ms), reset after(ms)
+	slidingWindowScript = redis.NewScript(`
+redis.replicate_commands()
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now - window)
+local count = redis.call('ZCARD', KEYS[1])
+local allowed = 0
+if count < limit then
+	redis.call('ZADD', KEYS[1], now, ARGV[3])
+	redis.call('PEXPIRE', KEYS[1], window)
+	count = count + 1
+	allowed = 1
+end
+local oldest = redis.call('ZRANGE', KEYS[1], 0, 0, 'WITHSCORES')
+local reset = 0
+if oldest[2] then
+	reset = tonumber(oldest[2]) + window - now
+end
+local retry = 0
+if allowed == 0 then
+	retry = reset
+end
+return {allowed, limit - count, retry, reset}`)
+)
+
+// NewTokenBucket create a token bucket limiter,
+// it allows bursts up to burst requests and refills limit tokens per period, default period is 1s.
+func NewTokenBucket(client *redis.Client, limit int64, period time.Duration, burst int64) Limiter {
+	if burst <= 0 {
+		burst = limit
+	}
+	if period <= 0 {
+		period = time.Second
+	}
+	return &tokenBucket{
+		client:   client,
+		capacity: burst,
+		// tokens per millisecond
+		rate: float64(limit) / period.Seconds() / 1000,
+	}
+}
+
+type tokenBucket struct {
+	client   *redis.Client
+	capacity int64
+	rate     float64
+}
+
+// Allow take a token from the bucket of the key
+func (l *tokenBucket) Allow(ctx context.Context, key string) (*Result, error) {
+	args := []interface{}{
+		l.capacity,
+		strconv.FormatFloat(l.rate, 'f', -1, 64),
+	}
+	values, err := tokenBucketScript.Run(ctx, l.client, []string{key}, args...).Result()
+	if err != nil {
+		return nil, err
+	}
+	return newResult(l.capacity, values)
+}
+
+// NewSlidingWindow create a sliding window limiter,
+// it allows at most limit requests in any window, the window is 1ms at least.
+func NewSlidingWindow(client *redis.Client, limit int64, window time.Duration) Limiter {
+	if window < time.Millisecond {
+		window = time.Millisecond
+	}
+	return &slidingWindow{
+		client: client,
+		limit:  limit,
+		window: window,
+	}
+}
+
+type slidingWindow struct {
+	client *redis.Client
+	limit  int64
+	window time.Duration
+}
+
+// Allow log a request in the window of the key
+func (l *slidingWindow) Allow(ctx context.Context, key string) (*Result, error) {
+	args := []interface{}{
+		l.limit,
+		l.window.Milliseconds(),
+		uuid.New().String(),
+	}
+	values, err := slidingWindowScript.Run(ctx, l.client, []string{key}, args...).Result()
+	if err != nil {
+		return nil, err
+	}
+	return newResult(l.limit, values)
+}
+
+// newResult build the result from the script values: allowed, remaining, retry after(ms), reset after(ms)
+func newResult(limit int64, val interface{}) (*Result, error) {
+	values, _ := val.([]interface{})
+	nums := make([]int64, len(values))
+	for i, v := range values {
+		n, ok := v.(int64)
+		if !ok {
+			return nil, fmt.Errorf("[ratelimit] unexpected script value: %v", values)
+		}
+		nums[i] = n
+	}
+	if len(nums) != 4 {
+		return nil, fmt.Errorf("[ratelimit] unexpected script value: %v", values)
+	}
+	return &Result{
+		Allowed:    nums[0] == 1,
+		Limit:      limit,
+		Remaining:  nums[1],
+		RetryAfter: time.Duration(nums[2]) * time.Millisecond,
+		ResetAfter: time.Duration(nums[3]) * time.Millisecond,
+	}, nil
+}
+
+// NewLimiter create a limiter by the rule
+func NewLimiter(client *redis.Client, rule Rule) Limiter {
+	period := rule.Period
+	if period <= 0 {
+		period = time.Second
+	}
+	if rule.Algorithm == AlgorithmSlidingWindow {
+		return NewSlidingWindow(client, rule.Limit, period)
+	}
+	return NewTokenBucket(client, rule.Limit, period, rule.Burst)
+}
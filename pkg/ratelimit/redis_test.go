@@ -0,0 +1,130 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRedis(t *testing.T) *redis.Client {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() {
+		_ = client.Close()
+	})
+	return client
+}
+
+func allow(t *testing.T, l Limiter, key string) *Result {
+	res, err := l.Allow(context.Background(), key)
+	require.NoError(t, err)
+	return res
+}
+
+func TestTokenBucket(t *testing.T) {
+	client := newTestRedis(t)
+	// 1 token per 100ms, burst 3
+	l := NewTokenBucket(client, 1, 100*time.Millisecond, 3)
+
+	for i := 2; i >= 0; i-- {
+		res := allow(t, l, "tb")
+		assert.True(t, res.Allowed)
+		assert.Equal(t, int64(3), res.Limit)
+		assert.Equal(t, int64(i), res.Remaining)
+	}
+	res := allow(t, l, "tb")
+	assert.False(t, res.Allowed)
+	assert.True(t, res.RetryAfter > 0 && res.RetryAfter <= 100*time.Millisecond, res.RetryAfter)
+	assert.True(t, res.ResetAfter > 0 && res.ResetAfter <= 300*time.Millisecond, res.ResetAfter)
+
+	// the other keys have their own buckets
+	assert.True(t, allow(t, l, "tb2").Allowed)
+
+	// refilled
+	time.Sleep(res.RetryAfter + 10*time.Millisecond)
+	assert.True(t, allow(t, l, "tb").Allowed)
+}
+
+func TestTokenBucket_RedisTime(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	defer mr.Close()
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	// the bucket is refilled by the time of redis rather than the clients
+	now := time.Now()
+	mr.SetTime(now)
+	l := NewTokenBucket(client, 1, 100*time.Millisecond, 1)
+	assert.True(t, allow(t, l, "tb").Allowed)
+	time.Sleep(150 * time.Millisecond)
+	assert.False(t, allow(t, l, "tb").Allowed)
+
+	mr.SetTime(now.Add(100 * time.Millisecond))
+	assert.True(t, allow(t, l, "tb").Allowed)
+}
+
+func TestTokenBucket_Period(t *testing.T) {
+	client := newTestRedis(t)
+
+	// the rate of a period under 1ms is finite
+	l := NewTokenBucket(client, 1, time.Microsecond, 1)
+	res := allow(t, l, "tb")
+	assert.True(t, res.Allowed)
+	assert.Equal(t, int64(1), res.Limit)
+
+	// zero period is 1s
+	l = NewTokenBucket(client, 1, 0, 1)
+	assert.True(t, allow(t, l, "tb2").Allowed)
+	res = allow(t, l, "tb2")
+	assert.False(t, res.Allowed)
+	assert.True(t, res.RetryAfter > 900*time.Millisecond && res.RetryAfter <= time.Second, res.RetryAfter)
+}
+
+func TestSlidingWindow(t *testing.T) {
+	client := newTestRedis(t)
+	l := NewSlidingWindow(client, 2, 200*time.Millisecond)
+
+	res := allow(t, l, "sw")
+	assert.True(t, res.Allowed)
+	assert.Equal(t, int64(1), res.Remaining)
+	res = allow(t, l, "sw")
+	assert.True(t, res.Allowed)
+	assert.Equal(t, int64(0), res.Remaining)
+
+	res = allow(t, l, "sw")
+	assert.False(t, res.Allowed)
+	assert.Equal(t, int64(2), res.Limit)
+	assert.True(t, res.RetryAfter > 0 && res.RetryAfter <= 200*time.Millisecond, res.RetryAfter)
+
+	// the rejected requests are not logged
+	n, err := client.ZCard(context.Background(), "sw").Result()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+
+	time.Sleep(res.RetryAfter + 10*time.Millisecond)
+	assert.True(t, allow(t, l, "sw").Allowed)
+}
+
+func TestNewLimiter(t *testing.T) {
+	client := newTestRedis(t)
+
+	assert.IsType(t, &tokenBucket{}, NewLimiter(client, Rule{Limit: 1}))
+	assert.IsType(t, &slidingWindow{}, NewLimiter(client, Rule{Limit: 1, Algorithm: AlgorithmSlidingWindow}))
+}
+
+func TestRule_Match(t *testing.T) {
+	assert.True(t, Rule{Path: "*"}.Match("GET", "/v1/users"))
+	assert.True(t, Rule{Path: "/v1/users"}.Match("POST", "/v1/users"))
+	assert.True(t, Rule{Path: "/v1/users", Method: "GET"}.Match("GET", "/v1/users"))
+	assert.False(t, Rule{Path: "/v1/users", Method: "GET"}.Match("POST", "/v1/users"))
+	assert.False(t, Rule{Path: "/v1/users"}.Match("GET", "/v1/users/:id"))
+}
@@ -4,7 +4,7 @@ import "time"
 
 // Config redis config
 type Config struct {
-	Addr         string
+	Addr         string `validate:"required"`
 	Password     string
 	DB           int
 	MinIdleConn  int
@@ -0,0 +1,164 @@
+package file
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/go-eagle/eagle/pkg/registry"
+)
+
+var (
+	_ registry.Registry  = &Registry{}
+	_ registry.Discovery = &Registry{}
+)
+
+const ext = ".json"
+
+// ErrInvalidName the service name or instance id can not be used as a file name
+var ErrInvalidName = errors.New("file registry: invalid name")
+
+// Registry is a file based registry,
+// every instance is a json file in the directory: <dir>/<service name>/<instance id>.json
+type Registry struct {
+	dir string
+}
+
+// New create a file registry
+func New(dir string) *Registry {
+	return &Registry{
+		dir: dir,
+	}
+}
+
+// Register the registration.
+func (r *Registry) Register(ctx context.Context, service *registry.ServiceInstance) error {
+	file, err := r.instanceFile(service)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(file)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(service)
+	if err != nil {
+		return err
+	}
+
+	// write to a temp file then rename, so that the watchers never read a partial file
+	tmp, err := ioutil.TempFile(dir, "."+service.ID+"-*")
+	if err != nil {
+		return err
+	}
+	if _, err = tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), file)
+}
+
+// Deregister the registration.
+func (r *Registry) Deregister(ctx context.Context, service *registry.ServiceInstance) error {
+	file, err := r.instanceFile(service)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(file)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// GetService return the service instances in the directory according to the service name.
+func (r *Registry) GetService(ctx context.Context, name string) ([]*registry.ServiceInstance, error) {
+	dir, err := r.serviceDir(name)
+	if err != nil {
+		return nil, err
+	}
+	files, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return []*registry.ServiceInstance{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]*registry.ServiceInstance, 0, len(files))
+	for _, f := range files {
+		if f.IsDir() || strings.HasPrefix(f.Name(), ".") || filepath.Ext(f.Name()) != ext {
+			continue
+		}
+		data, err := ioutil.ReadFile(filepath.Join(dir, f.Name()))
+		if os.IsNotExist(err) {
+			// deregistered after listing
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		si := new(registry.ServiceInstance)
+		if err = json.Unmarshal(data, si); err != nil {
+			return nil, err
+		}
+		if si.Name != name {
+			continue
+		}
+		items = append(items, si)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].ID < items[j].ID
+	})
+	return items, nil
+}
+
+// Watch creates a watcher according to the service name.
+func (r *Registry) Watch(ctx context.Context, name string) (registry.Watcher, error) {
+	dir, err := r.serviceDir(name)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return newWatcher(ctx, name, dir, r)
+}
+
+func (r *Registry) serviceDir(name string) (string, error) {
+	if err := checkName(name); err != nil {
+		return "", err
+	}
+	return filepath.Join(r.dir, name), nil
+}
+
+func (r *Registry) instanceFile(service *registry.ServiceInstance) (string, error) {
+	dir, err := r.serviceDir(service.Name)
+	if err != nil {
+		return "", err
+	}
+	if err := checkName(service.ID); err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, service.ID+ext), nil
+}
+
+// checkName the name is used as a file name, so it must stay in the registry dir,
+// and it must not start with a dot, which is used by the temp files.
+func checkName(name string) error {
+	if name == "" || strings.HasPrefix(name, ".") || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("%w: %q", ErrInvalidName, name)
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package file
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/go-eagle/eagle/pkg/registry"
+)
+
+func TestRegistry(t *testing.T) {
+	ctx := context.Background()
+	s := &registry.ServiceInstance{
+		ID:        "0",
+		Name:      "helloworld",
+		Version:   "v1.0.0",
+		Metadata:  map[string]string{"zone": "a"},
+		Endpoints: []string{"grpc://127.0.0.1:9000"},
+	}
+
+	r := New(t.TempDir())
+	w, err := r.Watch(ctx, s.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = w.Stop()
+	}()
+
+	// the first time returns the current instances
+	res, err := w.Next()
+	assert.NoError(t, err)
+	assert.Len(t, res, 0)
+
+	if err1 := r.Register(ctx, s); err1 != nil {
+		t.Fatal(err1)
+	}
+	res, err = w.Next()
+	assert.NoError(t, err)
+	if assert.Len(t, res, 1) {
+		assert.Equal(t, s, res[0])
+	}
+
+	res, err = r.GetService(ctx, s.Name)
+	assert.NoError(t, err)
+	if assert.Len(t, res, 1) {
+		assert.Equal(t, s, res[0])
+	}
+
+	// other services are not returned
+	res, err = r.GetService(ctx, "other")
+	assert.NoError(t, err)
+	assert.Len(t, res, 0)
+
+	if err1 := r.Deregister(ctx, s); err1 != nil {
+		t.Fatal(err1)
+	}
+	res, err = w.Next()
+	assert.NoError(t, err)
+	assert.Len(t, res, 0)
+
+	res, err = r.GetService(ctx, s.Name)
+	assert.NoError(t, err)
+	assert.Len(t, res, 0)
+}
+
+func TestWatcher_Stop(t *testing.T) {
+	ctx := context.Background()
+	r := New(t.TempDir())
+	w, err := r.Watch(ctx, "helloworld")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = w.Next()
+	assert.NoError(t, err)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := w.Next()
+		done <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+	assert.NoError(t, w.Stop())
+
+	select {
+	case err = <-done:
+		assert.Error(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("Next is not unblocked by Stop")
+	}
+}
+
+func TestWatcher_Context(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	r := New(t.TempDir())
+	w, err := r.Watch(ctx, "helloworld")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = w.Stop()
+	}()
+	_, err = w.Next()
+	assert.NoError(t, err)
+
+	// block until context deadline exceeded
+	_, err = w.Next()
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
+
+func TestRegistry_SharedDir(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	s := &registry.ServiceInstance{ID: "1", Name: "helloworld", Endpoints: []string{"http://127.0.0.1:8000"}}
+
+	// the registries of different processes share the same directory
+	r1, r2 := New(dir), New(dir)
+	w, err := r2.Watch(ctx, s.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = w.Stop()
+	}()
+	_, err = w.Next()
+	assert.NoError(t, err)
+
+	assert.NoError(t, r1.Register(ctx, s))
+	res, err := w.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, []*registry.ServiceInstance{s}, res)
+
+	// deregister a missing instance is not an error
+	assert.NoError(t, r1.Deregister(ctx, &registry.ServiceInstance{ID: "2", Name: "helloworld"}))
+}
+
+func TestRegistry_InvalidName(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	r := New(dir + "/registry")
+
+	tests := []*registry.ServiceInstance{
+		{ID: "../x", Name: "helloworld"},
+		{ID: "a/b", Name: "helloworld"},
+		{ID: `a\b`, Name: "helloworld"},
+		{ID: "..", Name: "helloworld"},
+		{ID: "", Name: "helloworld"},
+		{ID: "0", Name: "../helloworld"},
+		{ID: "0", Name: ".."},
+	}
+	for _, s := range tests {
+		assert.ErrorIs(t, r.Register(ctx, s), ErrInvalidName, "id: %s, name: %s", s.ID, s.Name)
+		assert.ErrorIs(t, r.Deregister(ctx, s), ErrInvalidName, "id: %s, name: %s", s.ID, s.Name)
+	}
+
+	_, err := r.GetService(ctx, "../helloworld")
+	assert.ErrorIs(t, err, ErrInvalidName)
+	_, err = r.Watch(ctx, "..")
+	assert.ErrorIs(t, err, ErrInvalidName)
+
+	// nothing is written outside the registry dir
+	files, err := ioutil.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, files, 0)
+}
@@ -0,0 +1,88 @@
+package file
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/go-eagle/eagle/pkg/registry"
+)
+
+var _ registry.Watcher = &watcher{}
+
+type watcher struct {
+	name    string
+	r       *Registry
+	ctx     context.Context
+	cancel  context.CancelFunc
+	watcher *fsnotify.Watcher
+	first   bool
+	last    []*registry.ServiceInstance
+}
+
+func newWatcher(ctx context.Context, name, dir string, r *Registry) (*watcher, error) {
+	fw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	if err = fw.Add(dir); err != nil {
+		_ = fw.Close()
+		return nil, err
+	}
+	w := &watcher{
+		name:    name,
+		r:       r,
+		watcher: fw,
+		first:   true,
+	}
+	w.ctx, w.cancel = context.WithCancel(ctx)
+	return w, nil
+}
+
+func (w *watcher) Next() ([]*registry.ServiceInstance, error) {
+	if w.first {
+		w.first = false
+		return w.getInstance()
+	}
+
+	for {
+		select {
+		case <-w.ctx.Done():
+			return nil, w.ctx.Err()
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return nil, context.Canceled
+			}
+			return nil, err
+		case _, ok := <-w.watcher.Events:
+			if !ok {
+				return nil, context.Canceled
+			}
+			items, err := w.r.GetService(w.ctx, w.name)
+			if err != nil {
+				return nil, err
+			}
+			// a single write triggers several events, only return the changes
+			if reflect.DeepEqual(items, w.last) {
+				continue
+			}
+			w.last = items
+			return items, nil
+		}
+	}
+}
+
+func (w *watcher) Stop() error {
+	w.cancel()
+	return w.watcher.Close()
+}
+
+func (w *watcher) getInstance() ([]*registry.ServiceInstance, error) {
+	items, err := w.r.GetService(w.ctx, w.name)
+	if err != nil {
+		return nil, err
+	}
+	w.last = items
+	return items, nil
+}
@@ -0,0 +1,124 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/go-eagle/eagle/pkg/registry"
+)
+
+var (
+	_ registry.Registry  = &Registry{}
+	_ registry.Discovery = &Registry{}
+)
+
+// Registry is an in-process registry, the instances are kept in a shared map,
+// it is useful for local development and unit tests.
+type Registry struct {
+	mu       sync.RWMutex
+	services map[string]map[string]*registry.ServiceInstance
+	watchers map[string]map[*watcher]struct{}
+}
+
+// New create a memory registry
+func New() *Registry {
+	return &Registry{
+		services: make(map[string]map[string]*registry.ServiceInstance),
+		watchers: make(map[string]map[*watcher]struct{}),
+	}
+}
+
+// Register the registration.
+func (r *Registry) Register(ctx context.Context, service *registry.ServiceInstance) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	instances, ok := r.services[service.Name]
+	if !ok {
+		instances = make(map[string]*registry.ServiceInstance)
+		r.services[service.Name] = instances
+	}
+	instances[service.ID] = service
+	r.notify(service.Name)
+	return nil
+}
+
+// Deregister the registration.
+func (r *Registry) Deregister(ctx context.Context, service *registry.ServiceInstance) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	instances, ok := r.services[service.Name]
+	if !ok {
+		return nil
+	}
+	delete(instances, service.ID)
+	if len(instances) == 0 {
+		delete(r.services, service.Name)
+	}
+	r.notify(service.Name)
+	return nil
+}
+
+// GetService return the service instances in memory according to the service name.
+func (r *Registry) GetService(ctx context.Context, name string) ([]*registry.ServiceInstance, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.list(name), nil
+}
+
+// Watch creates a watcher according to the service name.
+func (r *Registry) Watch(ctx context.Context, name string) (registry.Watcher, error) {
+	w := &watcher{
+		name:  name,
+		r:     r,
+		first: true,
+		event: make(chan struct{}, 1),
+	}
+	w.ctx, w.cancel = context.WithCancel(ctx)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	watchers, ok := r.watchers[name]
+	if !ok {
+		watchers = make(map[*watcher]struct{})
+		r.watchers[name] = watchers
+	}
+	watchers[w] = struct{}{}
+	return w, nil
+}
+
+// list return the instances sorted by id, the caller must hold the lock
+func (r *Registry) list(name string) []*registry.ServiceInstance {
+	items := make([]*registry.ServiceInstance, 0, len(r.services[name]))
+	for _, in := range r.services[name] {
+		items = append(items, in)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].ID < items[j].ID
+	})
+	return items
+}
+
+// notify fan out the change to the watchers, the caller must hold the lock
+func (r *Registry) notify(name string) {
+	for w := range r.watchers[name] {
+		select {
+		case w.event <- struct{}{}:
+		default:
+			// there is a pending event already
+		}
+	}
+}
+
+func (r *Registry) removeWatcher(w *watcher) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.watchers[w.name], w)
+	if len(r.watchers[w.name]) == 0 {
+		delete(r.watchers, w.name)
+	}
+}
@@ -0,0 +1,38 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/go-eagle/eagle/pkg/registry"
+)
+
+var _ registry.Watcher = &watcher{}
+
+type watcher struct {
+	name   string
+	r      *Registry
+	ctx    context.Context
+	cancel context.CancelFunc
+	first  bool
+	event  chan struct{}
+}
+
+func (w *watcher) Next() ([]*registry.ServiceInstance, error) {
+	if w.first {
+		w.first = false
+		return w.r.GetService(w.ctx, w.name)
+	}
+
+	select {
+	case <-w.ctx.Done():
+		return nil, w.ctx.Err()
+	case <-w.event:
+		return w.r.GetService(w.ctx, w.name)
+	}
+}
+
+func (w *watcher) Stop() error {
+	w.cancel()
+	w.r.removeWatcher(w)
+	return nil
+}
@@ -0,0 +1,119 @@
+package registry
+
+import (
+	"context"
+	"reflect"
+	"strconv"
+)
+
+const (
+	// MetadataWeight the weight of the instance in metadata, default is DefaultWeight
+	MetadataWeight = "weight"
+	// MetadataZone the zone of the instance in metadata, eg: cn-east-1a
+	MetadataZone = "zone"
+	// MetadataColor the color of the instance in metadata, eg: canary,
+	// the instances without color are the default pool
+	MetadataColor = "color"
+
+	// DefaultWeight the default weight of the instance
+	DefaultWeight = 100
+
+	// HeaderColor the header or grpc metadata to route the request to the instances of the color
+	HeaderColor = "x-eagle-color"
+	// HeaderZone the header or grpc metadata to prefer the instances of the zone
+	HeaderZone = "x-eagle-zone"
+)
+
+// Weight the weight of the instance, DefaultWeight is returned if it is not set or invalid
+func (s *ServiceInstance) Weight() int64 {
+	if w, err := strconv.ParseInt(s.Metadata[MetadataWeight], 10, 64); err == nil && w > 0 {
+		return w
+	}
+	return DefaultWeight
+}
+
+// Zone the zone of the instance
+func (s *ServiceInstance) Zone() string {
+	return s.Metadata[MetadataZone]
+}
+
+// Color the color of the instance, empty means the default pool
+func (s *ServiceInstance) Color() string {
+	return s.Metadata[MetadataColor]
+}
+
+// Equal check if the instance is the same as o,
+// it's used by grpc to compare the addresses of the resolver.
+func (s *ServiceInstance) Equal(o interface{}) bool {
+	other, ok := o.(*ServiceInstance)
+	if !ok {
+		return false
+	}
+	if s == nil || other == nil {
+		return s == other
+	}
+	return reflect.DeepEqual(s, other)
+}
+
+// Route the routing hints of a request, it's propagated by HeaderColor and HeaderZone
+type Route struct {
+	// Color route to the instances of the color, fallback to the default pool
+	Color string
+	// Zone prefer the instances of the zone, fallback to the other zones
+	Zone string
+}
+
+// IsEmpty check if there is no hint
+func (r Route) IsEmpty() bool {
+	return r.Color == "" && r.Zone == ""
+}
+
+// Filter select the instances matching the route:
+// 1. the instances of the color, or the default pool if there is none,
+// 2. the instances of the zone in them, or all of them if there is none.
+// all the instances are returned if there is no default pool.
+func (r Route) Filter(ins []*ServiceInstance) []*ServiceInstance {
+	pool := filter(ins, func(in *ServiceInstance) bool {
+		return in.Color() == r.Color
+	})
+	if len(pool) == 0 && r.Color != "" {
+		pool = filter(ins, func(in *ServiceInstance) bool {
+			return in.Color() == ""
+		})
+	}
+	if len(pool) == 0 {
+		pool = ins
+	}
+
+	if r.Zone != "" {
+		if local := filter(pool, func(in *ServiceInstance) bool {
+			return in.Zone() == r.Zone
+		}); len(local) > 0 {
+			return local
+		}
+	}
+	return pool
+}
+
+func filter(ins []*ServiceInstance, match func(in *ServiceInstance) bool) []*ServiceInstance {
+	items := make([]*ServiceInstance, 0, len(ins))
+	for _, in := range ins {
+		if match(in) {
+			items = append(items, in)
+		}
+	}
+	return items
+}
+
+type routeKey struct{}
+
+// NewRouteContext returns a new context with the route
+func NewRouteContext(ctx context.Context, r Route) context.Context {
+	return context.WithValue(ctx, routeKey{}, r)
+}
+
+// RouteFromContext returns the route in the context
+func RouteFromContext(ctx context.Context) (Route, bool) {
+	r, ok := ctx.Value(routeKey{}).(Route)
+	return r, ok
+}
@@ -0,0 +1,74 @@
+package registry
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newInstance(id, color, zone string) *ServiceInstance {
+	return &ServiceInstance{
+		ID:       id,
+		Metadata: map[string]string{MetadataColor: color, MetadataZone: zone},
+	}
+}
+
+func ids(ins []*ServiceInstance) []string {
+	items := make([]string, 0, len(ins))
+	for _, in := range ins {
+		items = append(items, in.ID)
+	}
+	return items
+}
+
+func TestServiceInstance_Weight(t *testing.T) {
+	assert.Equal(t, int64(DefaultWeight), (&ServiceInstance{}).Weight())
+	assert.Equal(t, int64(DefaultWeight), (&ServiceInstance{Metadata: map[string]string{MetadataWeight: "-1"}}).Weight())
+	assert.Equal(t, int64(10), (&ServiceInstance{Metadata: map[string]string{MetadataWeight: "10"}}).Weight())
+}
+
+func TestServiceInstance_Equal(t *testing.T) {
+	a := newInstance("1", "canary", "a")
+	assert.True(t, a.Equal(newInstance("1", "canary", "a")))
+	assert.False(t, a.Equal(newInstance("1", "", "a")))
+	assert.False(t, a.Equal("1"))
+}
+
+func TestRoute_Filter(t *testing.T) {
+	ins := []*ServiceInstance{
+		newInstance("1", "", "a"),
+		newInstance("2", "", "b"),
+		newInstance("3", "canary", "a"),
+		newInstance("4", "canary", "b"),
+	}
+
+	tests := []struct {
+		name  string
+		route Route
+		ins   []*ServiceInstance
+		want  []string
+	}{
+		{"default pool", Route{}, ins, []string{"1", "2"}},
+		{"color", Route{Color: "canary"}, ins, []string{"3", "4"}},
+		{"color and zone", Route{Color: "canary", Zone: "b"}, ins, []string{"4"}},
+		{"unknown color fallback to default pool", Route{Color: "blue"}, ins, []string{"1", "2"}},
+		{"unknown zone fallback to all zones", Route{Zone: "c"}, ins, []string{"1", "2"}},
+		{"no default pool", Route{}, ins[2:], []string{"3", "4"}},
+		{"empty", Route{Color: "canary"}, nil, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ids(tt.route.Filter(tt.ins)))
+		})
+	}
+}
+
+func TestRouteContext(t *testing.T) {
+	_, ok := RouteFromContext(context.Background())
+	assert.False(t, ok)
+
+	r, ok := RouteFromContext(NewRouteContext(context.Background(), Route{Color: "canary"}))
+	assert.True(t, ok)
+	assert.Equal(t, Route{Color: "canary"}, r)
+}
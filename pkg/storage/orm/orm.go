@@ -18,14 +18,14 @@ import (
 
 // Config mysql config
 type Config struct {
-	Name            string
-	Addr            string
-	UserName        string
+	Name            string `validate:"required"`
+	Addr            string `validate:"required"`
+	UserName        string `validate:"required"`
 	Password        string
 	ShowLog         bool
-	MaxIdleConn     int
-	MaxOpenConn     int
-	ConnMaxLifeTime time.Duration
+	MaxIdleConn     int           `validate:"gte=0"`
+	MaxOpenConn     int           `validate:"gte=0"`
+	ConnMaxLifeTime time.Duration `validate:"gte=0"`
 	SlowThreshold   time.Duration // Slow query duration, default 500ms
 }
 
@@ -0,0 +1,89 @@
+// Package routing provides a gRPC balancer which routes the requests by the routing hints,
+// and balances them across the instances by weight.
+package routing
+
+import (
+	"context"
+	"math/rand"
+
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/go-eagle/eagle/pkg/registry"
+	"github.com/go-eagle/eagle/pkg/transport/grpc/resolver/discovery"
+)
+
+// Name is the name of the balancer, eg: {"loadBalancingPolicy": "eagle_routing"}
+const Name = "eagle_routing"
+
+func init() {
+	balancer.Register(base.NewBalancerBuilder(Name, &pickerBuilder{}, base.Config{HealthCheck: true}))
+}
+
+type pickerBuilder struct{}
+
+// Build the instances are read from the address attributes set by the discovery resolver,
+// the addresses without instance are in the default pool with the default weight.
+func (*pickerBuilder) Build(info base.PickerBuildInfo) balancer.Picker {
+	if len(info.ReadySCs) == 0 {
+		return base.NewErrPicker(balancer.ErrNoSubConnAvailable)
+	}
+
+	p := &picker{
+		subConns: make(map[*registry.ServiceInstance][]balancer.SubConn),
+	}
+	for sc, sci := range info.ReadySCs {
+		in, ok := sci.Address.Attributes.Value(discovery.InstanceKey{}).(*registry.ServiceInstance)
+		if !ok || in == nil {
+			in = &registry.ServiceInstance{}
+		}
+		if _, ok := p.subConns[in]; !ok {
+			p.instances = append(p.instances, in)
+		}
+		p.subConns[in] = append(p.subConns[in], sc)
+	}
+	return p
+}
+
+type picker struct {
+	instances []*registry.ServiceInstance
+	subConns  map[*registry.ServiceInstance][]balancer.SubConn
+}
+
+// Pick select the instances by the route of the request, then pick one of them by weight
+func (p *picker) Pick(info balancer.PickInfo) (balancer.PickResult, error) {
+	ins := routeOf(info.Ctx).Filter(p.instances)
+
+	var total int64
+	for _, in := range ins {
+		total += in.Weight() * int64(len(p.subConns[in]))
+	}
+	if total <= 0 {
+		return balancer.PickResult{}, balancer.ErrNoSubConnAvailable
+	}
+
+	n := rand.Int63n(total)
+	for _, in := range ins {
+		for _, sc := range p.subConns[in] {
+			if n -= in.Weight(); n < 0 {
+				return balancer.PickResult{SubConn: sc}, nil
+			}
+		}
+	}
+	return balancer.PickResult{}, balancer.ErrNoSubConnAvailable
+}
+
+// routeOf the route of the request, the outgoing metadata take precedence over the context
+func routeOf(ctx context.Context) registry.Route {
+	r, _ := registry.RouteFromContext(ctx)
+	if md, ok := metadata.FromOutgoingContext(ctx); ok {
+		if vs := md.Get(registry.HeaderColor); len(vs) > 0 && vs[0] != "" {
+			r.Color = vs[0]
+		}
+		if vs := md.Get(registry.HeaderZone); len(vs) > 0 && vs[0] != "" {
+			r.Zone = vs[0]
+		}
+	}
+	return r
+}
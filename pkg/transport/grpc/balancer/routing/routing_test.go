@@ -0,0 +1,80 @@
+package routing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/attributes"
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/resolver"
+
+	"github.com/go-eagle/eagle/pkg/registry"
+	"github.com/go-eagle/eagle/pkg/transport/grpc/resolver/discovery"
+)
+
+type testSubConn struct {
+	balancer.SubConn
+	name string
+}
+
+func buildPicker(ins ...*registry.ServiceInstance) balancer.Picker {
+	info := base.PickerBuildInfo{ReadySCs: make(map[balancer.SubConn]base.SubConnInfo)}
+	for _, in := range ins {
+		info.ReadySCs[&testSubConn{name: in.ID}] = base.SubConnInfo{
+			Address: resolver.Address{Addr: in.ID, Attributes: attributes.New(discovery.InstanceKey{}, in)},
+		}
+	}
+	return (&pickerBuilder{}).Build(info)
+}
+
+func pick(ctx context.Context, t *testing.T, p balancer.Picker) string {
+	res, err := p.Pick(balancer.PickInfo{Ctx: ctx})
+	if !assert.NoError(t, err) {
+		return ""
+	}
+	return res.SubConn.(*testSubConn).name
+}
+
+func TestPicker(t *testing.T) {
+	p := buildPicker(
+		&registry.ServiceInstance{ID: "stable"},
+		&registry.ServiceInstance{ID: "canary", Metadata: map[string]string{registry.MetadataColor: "canary"}},
+	)
+
+	ctx := context.Background()
+	canary := metadata.AppendToOutgoingContext(ctx, registry.HeaderColor, "canary")
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, "stable", pick(ctx, t, p))
+		assert.Equal(t, "canary", pick(canary, t, p))
+		assert.Equal(t, "canary", pick(registry.NewRouteContext(ctx, registry.Route{Color: "canary"}), t, p))
+		assert.Equal(t, "stable", pick(registry.NewRouteContext(ctx, registry.Route{Color: "blue"}), t, p))
+	}
+
+	_, err := buildPicker().Pick(balancer.PickInfo{Ctx: ctx})
+	assert.Equal(t, balancer.ErrNoSubConnAvailable, err)
+}
+
+func TestPicker_Weight(t *testing.T) {
+	p := buildPicker(
+		&registry.ServiceInstance{ID: "heavy", Metadata: map[string]string{registry.MetadataWeight: "900"}},
+		&registry.ServiceInstance{ID: "light", Metadata: map[string]string{registry.MetadataWeight: "100"}},
+	)
+
+	got := make(map[string]int)
+	for i := 0; i < 1000; i++ {
+		got[pick(context.Background(), t, p)]++
+	}
+	assert.Greater(t, got["heavy"], 800)
+	assert.Greater(t, got["light"], 50)
+}
+
+func TestPicker_WithoutInstance(t *testing.T) {
+	sc := &testSubConn{name: "static"}
+	p := (&pickerBuilder{}).Build(base.PickerBuildInfo{ReadySCs: map[balancer.SubConn]base.SubConnInfo{
+		sc: {Address: resolver.Address{Addr: "127.0.0.1:9000"}},
+	}})
+	assert.Equal(t, "static", pick(context.Background(), t, p))
+}
@@ -13,6 +13,9 @@ import (
 	"google.golang.org/grpc/balancer/roundrobin"
 	grpcInsecure "google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/encoding/gzip"
+
+	"github.com/go-eagle/eagle/pkg/transport/grpc/balancer/routing"
+	"github.com/go-eagle/eagle/pkg/transport/grpc/resolver/discovery"
 )
 
 // Dial
@@ -28,7 +31,6 @@ func dial(ctx context.Context, insecure bool, opts ...ClientOption) (*grpc.Clien
 	// default client options
 	options := clientOptions{
 		timeout:      2000 * time.Millisecond,
-		balancerName: roundrobin.Name,
 		enableGzip:   true,
 		enableMetric: true,
 		DisableRetry: false,
@@ -37,6 +39,13 @@ func dial(ctx context.Context, insecure bool, opts ...ClientOption) (*grpc.Clien
 	for _, opt := range opts {
 		opt(&options)
 	}
+	if options.balancerName == "" {
+		// route by the instances of the discovery
+		options.balancerName = roundrobin.Name
+		if options.discovery != nil {
+			options.balancerName = routing.Name
+		}
+	}
 
 	// merge inters
 	inters := []grpc.UnaryClientInterceptor{
@@ -51,14 +60,18 @@ func dial(ctx context.Context, insecure bool, opts ...ClientOption) (*grpc.Clien
 		grpc.WithDefaultServiceConfig(fmt.Sprintf(`{"loadBalancingPolicy": "%s"}`, options.balancerName)),
 		grpc.WithChainUnaryInterceptor(inters...),
 	}
+	if options.discovery != nil {
+		dialOpts = append(dialOpts, grpc.WithResolvers(discovery.NewBuilder(options.discovery, options.discoverOpts...)))
+	}
 	if len(options.dialOpts) > 0 {
 		dialOpts = append(dialOpts, options.dialOpts...)
 	}
 	if insecure {
 		dialOpts = append(dialOpts, grpc.WithTransportCredentials(grpcInsecure.NewCredentials()))
 	} else {
-		tlsConfig := &tls.Config{
-			InsecureSkipVerify: true,
+		tlsConfig := options.tlsConf
+		if tlsConfig == nil {
+			tlsConfig = &tls.Config{MinVersion: tls.VersionTLS12}
 		}
 		cred := credentials.NewTLS(tlsConfig)
 		dialOpts = append(dialOpts, grpc.WithTransportCredentials(cred))
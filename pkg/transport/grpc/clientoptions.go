@@ -1,9 +1,13 @@
 package grpc
 
 import (
+	"crypto/tls"
 	"time"
 
 	"google.golang.org/grpc"
+
+	"github.com/go-eagle/eagle/pkg/registry"
+	"github.com/go-eagle/eagle/pkg/transport/grpc/resolver/discovery"
 )
 
 // clientOptions define gRPc client options.
@@ -13,6 +17,9 @@ type clientOptions struct {
 	inters       []grpc.UnaryClientInterceptor
 	dialOpts     []grpc.DialOption
 	balancerName string
+	discovery    registry.Discovery
+	discoverOpts []discovery.Option
+	tlsConf      *tls.Config
 	enableGzip   bool
 	enableMetric bool
 	// retry config
@@ -50,3 +57,27 @@ func WithOptions(opts ...grpc.DialOption) ClientOption {
 		o.dialOpts = opts
 	}
 }
+
+// WithBalancerName with a balancer name, default is round_robin,
+// or routing.Name if the discovery is set.
+func WithBalancerName(name string) ClientOption {
+	return func(o *clientOptions) {
+		o.balancerName = name
+	}
+}
+
+// WithDiscovery with a service discovery, the endpoint should be discovery:///service-name.
+func WithDiscovery(d registry.Discovery, opts ...discovery.Option) ClientOption {
+	return func(o *clientOptions) {
+		o.discovery = d
+		o.discoverOpts = opts
+	}
+}
+
+// WithTLSConfig with the tls config of Dial, eg: tlsconfig.NewClientConfig,
+// default verifies the server certificate by the system roots.
+func WithTLSConfig(c *tls.Config) ClientOption {
+	return func(o *clientOptions) {
+		o.tlsConf = c
+	}
+}
@@ -5,6 +5,8 @@ import (
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/metadata"
+
+	"github.com/go-eagle/eagle/pkg/registry"
 )
 
 var (
@@ -15,23 +17,72 @@ var (
 func unaryServerInterceptor(s *Server) grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
 		// preprocess stage
-		md, ok := metadata.FromIncomingContext(ctx)
-		if ok {
-			ver, vs := "unknown", md.Get(MetadataClientAPIVersionKey)
-			if len(vs) > 0 {
-				ver = vs[0]
-			}
-			clientRequests.WithLabelValues("unary", ver).Inc()
+		ctx = preprocess(ctx, "unary")
+		if s.timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, s.timeout)
+			defer cancel()
 		}
 
 		return handler(ctx, req)
 	}
 }
 
+// streamServerInterceptor server stream interceptor
+func streamServerInterceptor(s *Server) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		// preprocess stage
+		ctx := preprocess(ss.Context(), "stream")
+		var cancel context.CancelFunc
+		if s.streamTimeout > 0 {
+			ctx, cancel = context.WithTimeout(ctx, s.streamTimeout)
+		} else {
+			ctx, cancel = context.WithCancel(ctx)
+		}
+		defer cancel()
+
+		if s.idleTimeout <= 0 {
+			return handler(srv, wrapStream(ctx, ss))
+		}
+		is := newIdleStream(ctx, ss, s.idleTimeout, cancel)
+		defer is.stop()
+		return handler(srv, is)
+	}
+}
+
+// preprocess count the requests by the client api version, and inject the routing hints
+func preprocess(ctx context.Context, kind string) context.Context {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ctx
+	}
+	ver, vs := "unknown", md.Get(MetadataClientAPIVersionKey)
+	if len(vs) > 0 {
+		ver = vs[0]
+	}
+	clientRequests.WithLabelValues(kind, ver).Inc()
+
+	// routing hints of the downstream requests
+	r := registry.Route{
+		Color: first(md.Get(registry.HeaderColor)),
+		Zone:  first(md.Get(registry.HeaderZone)),
+	}
+	if !r.IsEmpty() {
+		ctx = registry.NewRouteContext(ctx, r)
+	}
+	return ctx
+}
+
 // unaryClientInterceptor client unary interceptor
 func unaryClientInterceptor() grpc.UnaryClientInterceptor {
 	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
 		// preprocess stage
+		ctx = withRouteMetadata(ctx)
+		if id := RequestIDFromContext(ctx); id != "" {
+			if md, _ := metadata.FromOutgoingContext(ctx); len(md.Get(MetadataRequestIDKey)) == 0 {
+				ctx = metadata.AppendToOutgoingContext(ctx, MetadataRequestIDKey, id)
+			}
+		}
 
 		// call remote method
 		err := invoker(ctx, method, req, reply, cc, opts...)
@@ -41,3 +92,26 @@ func unaryClientInterceptor() grpc.UnaryClientInterceptor {
 		return err
 	}
 }
+
+// withRouteMetadata propagate the routing hints in the context by the outgoing metadata
+func withRouteMetadata(ctx context.Context) context.Context {
+	r, ok := registry.RouteFromContext(ctx)
+	if !ok {
+		return ctx
+	}
+	md, _ := metadata.FromOutgoingContext(ctx)
+	if r.Color != "" && len(md.Get(registry.HeaderColor)) == 0 {
+		ctx = metadata.AppendToOutgoingContext(ctx, registry.HeaderColor, r.Color)
+	}
+	if r.Zone != "" && len(md.Get(registry.HeaderZone)) == 0 {
+		ctx = metadata.AppendToOutgoingContext(ctx, registry.HeaderZone, r.Zone)
+	}
+	return ctx
+}
+
+func first(vs []string) string {
+	if len(vs) > 0 {
+		return vs[0]
+	}
+	return ""
+}
@@ -0,0 +1,72 @@
+package grpc
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	logger "github.com/go-eagle/eagle/pkg/log"
+)
+
+// accessLog log the result of a rpc, the server side failures are logged as errors
+func (s *Server) accessLog(ctx context.Context, kind, method string, start time.Time, err error) {
+	l := s.logger
+	if l == nil {
+		l = logger.GetLogger()
+	}
+	if l == nil {
+		return
+	}
+
+	code := status.Code(err)
+	fields := logger.Fields{
+		"kind":     kind,
+		"method":   method,
+		"code":     code.String(),
+		"duration": time.Since(start).String(),
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		fields["peer"] = p.Addr.String()
+	}
+	if id := RequestIDFromContext(ctx); id != "" {
+		fields["request_id"] = id
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		fields["trace_id"] = sc.TraceID().String()
+	}
+	if err != nil {
+		fields["error"] = err.Error()
+	}
+
+	switch code {
+	case codes.OK:
+		l.WithFields(fields).Info("[gRPC] access")
+	case codes.Unknown, codes.Internal, codes.Unavailable, codes.DeadlineExceeded, codes.DataLoss, codes.Unimplemented:
+		l.WithFields(fields).Error("[gRPC] access")
+	default:
+		l.WithFields(fields).Warn("[gRPC] access")
+	}
+}
+
+func unaryAccessLogInterceptor(s *Server) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		reply, err := handler(ctx, req)
+		s.accessLog(ctx, "unary", info.FullMethod, start, err)
+		return reply, err
+	}
+}
+
+func streamAccessLogInterceptor(s *Server) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		s.accessLog(ss.Context(), "stream", info.FullMethod, start, err)
+		return err
+	}
+}
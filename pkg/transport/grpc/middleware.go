@@ -0,0 +1,259 @@
+package grpc
+
+import (
+	"context"
+	"strings"
+
+	"github.com/go-kratos/aegis/circuitbreaker"
+	"github.com/go-kratos/aegis/circuitbreaker/sre"
+	"github.com/go-kratos/aegis/ratelimit"
+	"github.com/google/uuid"
+	grpcMiddleware "github.com/grpc-ecosystem/go-grpc-middleware"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/go-eagle/eagle/pkg/app"
+	"github.com/go-eagle/eagle/pkg/container/group"
+	logger "github.com/go-eagle/eagle/pkg/log"
+)
+
+const (
+	// MetadataRequestIDKey the metadata key of the request id
+	MetadataRequestIDKey = "x-request-id"
+	// MetadataAuthorizationKey the metadata key of the json web token, eg: Bearer xxx
+	MetadataAuthorizationKey = "authorization"
+)
+
+type (
+	requestIDKey struct{}
+	payloadKey   struct{}
+)
+
+// RequestIDFromContext returns the request id of the rpc
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// PayloadFromContext returns the json web token payload of the rpc
+func PayloadFromContext(ctx context.Context) (*app.Payload, bool) {
+	p, ok := ctx.Value(payloadKey{}).(*app.Payload)
+	return p, ok
+}
+
+// wrapStream replace the context of the stream
+func wrapStream(ctx context.Context, ss grpc.ServerStream) grpc.ServerStream {
+	ws := grpcMiddleware.WrapServerStream(ss)
+	ws.WrappedContext = ctx
+	return ws
+}
+
+// ------------------ request id ------------------
+
+// withRequestID use the request id of the client, or generate a new one,
+// it's sent back by the header and propagated to the downstream by the client interceptor.
+func withRequestID(ctx context.Context) context.Context {
+	var id string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		id = first(md.Get(MetadataRequestIDKey))
+	}
+	if id == "" {
+		id = uuid.New().String()
+	}
+	_ = grpc.SetHeader(ctx, metadata.Pairs(MetadataRequestIDKey, id))
+	ctx = logger.NewRequestIDContext(ctx, id)
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+func unaryRequestIDInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return handler(withRequestID(ctx), req)
+	}
+}
+
+func streamRequestIDInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, wrapStream(withRequestID(ss.Context()), ss))
+	}
+}
+
+// onDone call fn with the result of the handler, a panic is passed as an internal error,
+// then it's panicked again for the recovery interceptor, which is outside, it must be deferred.
+func onDone(err *error, fn func(err error)) {
+	if p := recover(); p != nil {
+		fn(status.Errorf(codes.Internal, "panic: %v", p))
+		panic(p)
+	}
+	fn(*err)
+}
+
+// ------------------ ratelimit ------------------
+
+func unaryRatelimitInterceptor(limiter ratelimit.Limiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (reply interface{}, err error) {
+		done, err := limiter.Allow()
+		if err != nil {
+			return nil, status.Error(codes.ResourceExhausted, err.Error())
+		}
+		defer onDone(&err, func(err error) {
+			done(ratelimit.DoneInfo{Err: err})
+		})
+		return handler(ctx, req)
+	}
+}
+
+func streamRatelimitInterceptor(limiter ratelimit.Limiter) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		done, err := limiter.Allow()
+		if err != nil {
+			return status.Error(codes.ResourceExhausted, err.Error())
+		}
+		defer onDone(&err, func(err error) {
+			done(ratelimit.DoneInfo{Err: err})
+		})
+		return handler(srv, ss)
+	}
+}
+
+// ------------------ breaker ------------------
+
+// newBreakerGroup the default breakers per method
+func newBreakerGroup() *group.Group {
+	return group.NewGroup(func() interface{} {
+		return sre.NewBreaker()
+	})
+}
+
+// allowBreaker returns the func to mark the result, or an error if the breaker is open
+func allowBreaker(g *group.Group, method string) (func(err error), error) {
+	breaker := g.Get(method).(circuitbreaker.CircuitBreaker)
+	if err := breaker.Allow(); err != nil {
+		// NOTE: when client reject requests locally,
+		// continue add counter let the drop ratio higher.
+		breaker.MarkFailed()
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	return func(err error) {
+		// NOTE: only the server side failures are counted
+		switch status.Code(err) {
+		case codes.Unknown, codes.Internal, codes.Unavailable, codes.DeadlineExceeded, codes.DataLoss:
+			breaker.MarkFailed()
+		default:
+			breaker.MarkSuccess()
+		}
+	}, nil
+}
+
+func unaryBreakerInterceptor(g *group.Group) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (reply interface{}, err error) {
+		mark, err := allowBreaker(g, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		defer onDone(&err, mark)
+		return handler(ctx, req)
+	}
+}
+
+func streamBreakerInterceptor(g *group.Group) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		mark, err := allowBreaker(g, info.FullMethod)
+		if err != nil {
+			return err
+		}
+		defer onDone(&err, mark)
+		return handler(srv, ss)
+	}
+}
+
+// ------------------ auth ------------------
+
+type authOptions struct {
+	secret string
+	skip   map[string]struct{}
+}
+
+// authenticate parse the json web token in the metadata, the payload is stored in the context
+func (o *authOptions) authenticate(ctx context.Context, method string) (context.Context, error) {
+	if _, ok := o.skip[method]; ok {
+		return ctx, nil
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	header := first(md.Get(MetadataAuthorizationKey))
+	if header == "" {
+		return nil, status.Error(codes.Unauthenticated, app.ErrMissingHeader.Error())
+	}
+	token := strings.TrimSpace(strings.TrimPrefix(header, "Bearer "))
+
+	secret := o.secret
+	if secret == "" && app.Conf != nil {
+		secret = app.Conf.JwtSecret
+	}
+	payload, err := app.Parse(token, secret)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	ctx = logger.NewUIDContext(ctx, payload.UserID)
+	return context.WithValue(ctx, payloadKey{}, payload), nil
+}
+
+func unaryAuthInterceptor(o *authOptions) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := o.authenticate(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func streamAuthInterceptor(o *authOptions) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := o.authenticate(ss.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, wrapStream(ctx, ss))
+	}
+}
+
+// ------------------ validator ------------------
+
+// validate call Validate() of the message if it's implemented, eg: protoc-gen-validate
+func validate(msg interface{}) error {
+	if v, ok := msg.(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+	return nil
+}
+
+func unaryValidatorInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := validate(req); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func streamValidatorInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &validatorStream{ServerStream: ss})
+	}
+}
+
+// validatorStream validate the received messages
+type validatorStream struct {
+	grpc.ServerStream
+}
+
+func (s *validatorStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	return validate(m)
+}
@@ -0,0 +1,204 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/go-kratos/aegis/circuitbreaker"
+	"github.com/go-kratos/aegis/ratelimit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	grpcInsecure "google.golang.org/grpc/credentials/insecure"
+	healthPb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/go-eagle/eagle/pkg/app"
+)
+
+const testSecret = "secret"
+
+type testLimiter struct {
+	reject bool
+	done   []ratelimit.DoneInfo
+}
+
+func (l *testLimiter) Allow() (ratelimit.DoneFunc, error) {
+	if l.reject {
+		return nil, ratelimit.ErrLimitExceed
+	}
+	return func(info ratelimit.DoneInfo) {
+		l.done = append(l.done, info)
+	}, nil
+}
+
+func dialTestServer(t *testing.T, opts ...ServerOption) healthPb.HealthClient {
+	lis := bufconn.Listen(1 << 20)
+	srv := NewServer(opts...)
+	go func() {
+		_ = srv.Serve(lis)
+	}()
+	t.Cleanup(srv.Server.Stop)
+
+	conn, err := grpc.DialContext(context.Background(), "bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, s string) (net.Conn, error) {
+			return lis.Dial()
+		}),
+		grpc.WithTransportCredentials(grpcInsecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+	return healthPb.NewHealthClient(conn)
+}
+
+func TestServer_Middlewares(t *testing.T) {
+	limiter := &testLimiter{}
+	client := dialTestServer(t,
+		RequestID(),
+		Ratelimit(limiter),
+		Breaker(nil),
+		Auth(testSecret, "/grpc.health.v1.Health/Watch"),
+		Validator(),
+	)
+	ctx := context.Background()
+
+	// auth
+	_, err := client.Check(ctx, &healthPb.HealthCheckRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = client.Check(metadata.AppendToOutgoingContext(ctx, MetadataAuthorizationKey, "Bearer invalid"), &healthPb.HealthCheckRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	token, err := app.Sign(ctx, map[string]interface{}{"user_id": 1}, testSecret, 60)
+	require.NoError(t, err)
+	authCtx := metadata.AppendToOutgoingContext(ctx, MetadataAuthorizationKey, "Bearer "+token)
+
+	// the request id is sent back
+	var header metadata.MD
+	_, err = client.Check(authCtx, &healthPb.HealthCheckRequest{}, grpc.Header(&header))
+	assert.NoError(t, err)
+	assert.NotEmpty(t, header.Get(MetadataRequestIDKey))
+
+	_, err = client.Check(metadata.AppendToOutgoingContext(authCtx, MetadataRequestIDKey, "id"),
+		&healthPb.HealthCheckRequest{}, grpc.Header(&header))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"id"}, header.Get(MetadataRequestIDKey))
+
+	// the stream method is skipped by auth
+	stream, err := client.Watch(ctx, &healthPb.HealthCheckRequest{})
+	require.NoError(t, err)
+	resp, err := stream.Recv()
+	assert.NoError(t, err)
+	assert.Equal(t, healthPb.HealthCheckResponse_SERVING, resp.Status)
+
+	// ratelimit
+	limiter.reject = true
+	_, err = client.Check(authCtx, &healthPb.HealthCheckRequest{})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
+
+func TestAuth_Payload(t *testing.T) {
+	token, err := app.Sign(context.Background(), map[string]interface{}{"user_id": 10}, testSecret, 60)
+	require.NoError(t, err)
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(MetadataAuthorizationKey, "Bearer "+token))
+
+	inter := unaryAuthInterceptor(&authOptions{secret: testSecret})
+	_, err = inter(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/test"}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		p, ok := PayloadFromContext(ctx)
+		if assert.True(t, ok) {
+			assert.Equal(t, uint64(10), p.UserID)
+		}
+		return nil, nil
+	})
+	assert.NoError(t, err)
+}
+
+type testRequest struct {
+	valid bool
+}
+
+func (r *testRequest) Validate() error {
+	if !r.valid {
+		return errors.New("invalid")
+	}
+	return nil
+}
+
+func TestValidator(t *testing.T) {
+	inter := unaryValidatorInterceptor()
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "ok", nil
+	}
+
+	_, err := inter(context.Background(), &testRequest{}, &grpc.UnaryServerInfo{}, handler)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	reply, err := inter(context.Background(), &testRequest{valid: true}, &grpc.UnaryServerInfo{}, handler)
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", reply)
+	// the requests without Validate() are passed
+	_, err = inter(context.Background(), "req", &grpc.UnaryServerInfo{}, handler)
+	assert.NoError(t, err)
+}
+
+func TestBreaker(t *testing.T) {
+	inter := unaryBreakerInterceptor(newBreakerGroup())
+	info := &grpc.UnaryServerInfo{FullMethod: "/test"}
+	failed := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, status.Error(codes.Unavailable, "unavailable")
+	}
+
+	var err error
+	for i := 0; i < 1000 && status.Convert(err).Message() != circuitbreaker.ErrNotAllowed.Error(); i++ {
+		_, err = inter(context.Background(), nil, info, failed)
+	}
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Equal(t, circuitbreaker.ErrNotAllowed.Error(), status.Convert(err).Message())
+}
+
+func TestRatelimit_Panic(t *testing.T) {
+	limiter := &testLimiter{}
+	panicked := func(ctx context.Context, req interface{}) (interface{}, error) {
+		panic("oops")
+	}
+
+	// the panic is passed to the recovery interceptor after done
+	assert.PanicsWithValue(t, "oops", func() {
+		_, _ = unaryRatelimitInterceptor(limiter)(context.Background(), nil, &grpc.UnaryServerInfo{}, panicked)
+	})
+	assert.PanicsWithValue(t, "oops", func() {
+		_ = streamRatelimitInterceptor(limiter)(nil, nil, &grpc.StreamServerInfo{},
+			func(srv interface{}, stream grpc.ServerStream) error {
+				panic("oops")
+			})
+	})
+	require.Len(t, limiter.done, 2)
+	for _, info := range limiter.done {
+		assert.Equal(t, codes.Internal, status.Code(info.Err))
+	}
+}
+
+func TestBreaker_Panic(t *testing.T) {
+	inter := unaryBreakerInterceptor(newBreakerGroup())
+	info := &grpc.UnaryServerInfo{FullMethod: "/test"}
+	panicked := func(ctx context.Context, req interface{}) (interface{}, error) {
+		panic("oops")
+	}
+
+	// the panics are counted as the failures
+	var err error
+	for i := 0; i < 1000 && err == nil; i++ {
+		func() {
+			defer func() {
+				_ = recover()
+			}()
+			_, err = inter(context.Background(), nil, info, panicked)
+		}()
+	}
+	assert.Equal(t, circuitbreaker.ErrNotAllowed.Error(), status.Convert(err).Message())
+}
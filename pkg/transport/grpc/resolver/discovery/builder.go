@@ -0,0 +1,73 @@
+package discovery
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc/resolver"
+
+	"github.com/go-eagle/eagle/pkg/registry"
+)
+
+// Scheme the scheme of the target, eg: discovery:///helloworld
+const Scheme = "discovery"
+
+// Option is builder option.
+type Option func(o *builder)
+
+// WithVersion only use the instances with the given version.
+func WithVersion(version string) Option {
+	return func(b *builder) {
+		b.version = version
+	}
+}
+
+// WithMetadata only use the instances which contain all the given metadata.
+func WithMetadata(md map[string]string) Option {
+	return func(b *builder) {
+		b.metadata = md
+	}
+}
+
+type builder struct {
+	discoverer registry.Discovery
+	version    string
+	metadata   map[string]string
+}
+
+// NewBuilder creates a builder which is used to resolve discovery:///service-name by registry.Discovery.
+func NewBuilder(d registry.Discovery, opts ...Option) resolver.Builder {
+	b := &builder{
+		discoverer: d,
+	}
+	for _, o := range opts {
+		o(b)
+	}
+	return b
+}
+
+// Build watch the service and feed the instances into the balancer
+func (b *builder) Build(target resolver.Target, cc resolver.ClientConn, opts resolver.BuildOptions) (resolver.Resolver, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	w, err := b.discoverer.Watch(ctx, strings.TrimPrefix(target.Endpoint, "/"))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	r := &discoveryResolver{
+		w:        w,
+		cc:       cc,
+		ctx:      ctx,
+		cancel:   cancel,
+		version:  b.version,
+		metadata: b.metadata,
+	}
+	go r.watch()
+	return r, nil
+}
+
+// Scheme return scheme of discovery
+func (*builder) Scheme() string {
+	return Scheme
+}
@@ -0,0 +1,102 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/url"
+	"time"
+
+	"google.golang.org/grpc/attributes"
+	"google.golang.org/grpc/resolver"
+
+	"github.com/go-eagle/eagle/pkg/registry"
+)
+
+// InstanceKey the key of the service instance in the address attributes
+type InstanceKey struct{}
+
+type discoveryResolver struct {
+	w  registry.Watcher
+	cc resolver.ClientConn
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	version  string
+	metadata map[string]string
+}
+
+func (r *discoveryResolver) watch() {
+	for {
+		select {
+		case <-r.ctx.Done():
+			return
+		default:
+		}
+		ins, err := r.w.Next()
+		if err != nil {
+			if errors.Is(err, context.Canceled) {
+				return
+			}
+			log.Printf("[resolver] failed to watch discovery endpoint: %v", err)
+			time.Sleep(time.Second)
+			continue
+		}
+		r.update(ins)
+	}
+}
+
+func (r *discoveryResolver) update(ins []*registry.ServiceInstance) {
+	addrs := make([]resolver.Address, 0)
+	for _, in := range ins {
+		if !r.match(in) {
+			continue
+		}
+		for _, e := range in.Endpoints {
+			u, err := url.Parse(e)
+			if err != nil || u.Scheme != "grpc" || u.Host == "" {
+				continue
+			}
+			addrs = append(addrs, resolver.Address{
+				Addr:       u.Host,
+				ServerName: in.Name,
+				Attributes: attributes.New(InstanceKey{}, in),
+			})
+		}
+	}
+	// keep the current addresses if there is no available instance
+	if len(addrs) == 0 {
+		log.Printf("[resolver] zero endpoint found, refused to write, instances: %v", ins)
+		return
+	}
+	err := r.cc.UpdateState(resolver.State{Addresses: addrs})
+	if err != nil {
+		log.Printf("[resolver] failed to update state: %v", err)
+	}
+}
+
+// match filter the instance by version and metadata
+func (r *discoveryResolver) match(in *registry.ServiceInstance) bool {
+	if r.version != "" && in.Version != r.version {
+		return false
+	}
+	for k, v := range r.metadata {
+		if in.Metadata[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// Close stop watching
+func (r *discoveryResolver) Close() {
+	r.cancel()
+	err := r.w.Stop()
+	if err != nil {
+		log.Printf("[resolver] failed to stop watcher: %v", err)
+	}
+}
+
+// ResolveNow the instances are pushed by the watcher, nothing to do
+func (r *discoveryResolver) ResolveNow(options resolver.ResolveNowOptions) {}
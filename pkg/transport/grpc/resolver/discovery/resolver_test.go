@@ -0,0 +1,165 @@
+package discovery
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/serviceconfig"
+
+	"github.com/go-eagle/eagle/pkg/registry"
+)
+
+// testDiscovery push the instances to the watchers
+type testDiscovery struct {
+	ch chan []*registry.ServiceInstance
+}
+
+func (d *testDiscovery) GetService(ctx context.Context, name string) ([]*registry.ServiceInstance, error) {
+	return nil, nil
+}
+
+func (d *testDiscovery) Watch(ctx context.Context, name string) (registry.Watcher, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	return &testWatcher{ctx: ctx, cancel: cancel, ch: d.ch}, nil
+}
+
+type testWatcher struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	ch     chan []*registry.ServiceInstance
+}
+
+func (w *testWatcher) Next() ([]*registry.ServiceInstance, error) {
+	select {
+	case <-w.ctx.Done():
+		return nil, w.ctx.Err()
+	case ins := <-w.ch:
+		return ins, nil
+	}
+}
+
+func (w *testWatcher) Stop() error {
+	w.cancel()
+	return nil
+}
+
+type testClientConn struct {
+	resolver.ClientConn
+
+	mu    sync.Mutex
+	state resolver.State
+}
+
+func (cc *testClientConn) UpdateState(state resolver.State) error {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	cc.state = state
+	return nil
+}
+
+func (cc *testClientConn) ParseServiceConfig(string) *serviceconfig.ParseResult {
+	return nil
+}
+
+func (cc *testClientConn) addrs() []string {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	addrs := make([]string, 0, len(cc.state.Addresses))
+	for _, a := range cc.state.Addresses {
+		addrs = append(addrs, a.Addr)
+	}
+	return addrs
+}
+
+func TestResolver_Filter(t *testing.T) {
+	d := &testDiscovery{ch: make(chan []*registry.ServiceInstance)}
+	b := NewBuilder(d, WithVersion("v1"), WithMetadata(map[string]string{"zone": "a"}))
+	assert.Equal(t, "discovery", b.Scheme())
+
+	cc := &testClientConn{}
+	r, err := b.Build(resolver.Target{Scheme: Scheme, Endpoint: "helloworld"}, cc, resolver.BuildOptions{})
+	assert.NoError(t, err)
+	defer r.Close()
+
+	d.ch <- []*registry.ServiceInstance{
+		{ID: "1", Name: "helloworld", Version: "v1", Metadata: map[string]string{"zone": "a"},
+			Endpoints: []string{"http://127.0.0.1:8000", "grpc://127.0.0.1:9000"}},
+		{ID: "2", Name: "helloworld", Version: "v2", Metadata: map[string]string{"zone": "a"},
+			Endpoints: []string{"grpc://127.0.0.1:9001"}},
+		{ID: "3", Name: "helloworld", Version: "v1", Metadata: map[string]string{"zone": "b"},
+			Endpoints: []string{"grpc://127.0.0.1:9002"}},
+	}
+	assert.Eventually(t, func() bool {
+		return len(cc.addrs()) == 1
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, []string{"127.0.0.1:9000"}, cc.addrs())
+
+	// no available instance, keep the current addresses
+	d.ch <- []*registry.ServiceInstance{}
+	d.ch <- []*registry.ServiceInstance{
+		{ID: "1", Name: "helloworld", Version: "v1", Metadata: map[string]string{"zone": "a"},
+			Endpoints: []string{"grpc://127.0.0.1:9003"}},
+	}
+	assert.Eventually(t, func() bool {
+		addrs := cc.addrs()
+		return len(addrs) == 1 && addrs[0] == "127.0.0.1:9003"
+	}, time.Second, 5*time.Millisecond)
+}
+
+func startServer(t *testing.T) (string, *health.Server) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer()
+	hs := health.NewServer()
+	healthpb.RegisterHealthServer(srv, hs)
+	go func() {
+		_ = srv.Serve(lis)
+	}()
+	t.Cleanup(srv.Stop)
+	return lis.Addr().String(), hs
+}
+
+func TestBuilder_Dial(t *testing.T) {
+	addr1, hs1 := startServer(t)
+	addr2, hs2 := startServer(t)
+	hs1.SetServingStatus("s1", healthpb.HealthCheckResponse_SERVING)
+	hs2.SetServingStatus("s2", healthpb.HealthCheckResponse_SERVING)
+
+	d := &testDiscovery{ch: make(chan []*registry.ServiceInstance, 1)}
+	d.ch <- []*registry.ServiceInstance{
+		{ID: "1", Name: "helloworld", Endpoints: []string{"grpc://" + addr1}},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, err := grpc.DialContext(ctx, "discovery:///helloworld",
+		grpc.WithResolvers(NewBuilder(d)),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	client := healthpb.NewHealthClient(conn)
+	_, err = client.Check(ctx, &healthpb.HealthCheckRequest{Service: "s1"})
+	assert.NoError(t, err)
+
+	// follow the instance changes
+	d.ch <- []*registry.ServiceInstance{
+		{ID: "2", Name: "helloworld", Endpoints: []string{"grpc://" + addr2}},
+	}
+	assert.Eventually(t, func() bool {
+		_, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: "s2"})
+		return err == nil
+	}, 3*time.Second, 10*time.Millisecond)
+}
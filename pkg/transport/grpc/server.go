@@ -2,21 +2,27 @@ package grpc
 
 import (
 	"context"
+	"crypto/tls"
+	"io"
 	"log"
 	"net"
 	"net/url"
 	"sync"
 	"time"
 
+	"github.com/go-kratos/aegis/ratelimit"
 	grpcRecovery "github.com/grpc-ecosystem/go-grpc-middleware/recovery"
 	grpcPrometheus "github.com/grpc-ecosystem/go-grpc-prometheus"
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	_ "google.golang.org/grpc/encoding/gzip"
 	"google.golang.org/grpc/health"
 	healthPb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
 
+	"github.com/go-eagle/eagle/pkg/container/group"
+	logger "github.com/go-eagle/eagle/pkg/log"
 	"github.com/go-eagle/eagle/pkg/utils"
 )
 
@@ -37,13 +43,27 @@ func Address(addr string) ServerOption {
 	}
 }
 
-// Timeout with server timeout.
+// Timeout with server timeout of the unary rpcs.
 func Timeout(timeout time.Duration) ServerOption {
 	return func(s *Server) {
 		s.timeout = timeout
 	}
 }
 
+// StreamTimeout with the max duration of the stream rpcs, default is no limit.
+func StreamTimeout(timeout time.Duration) ServerOption {
+	return func(s *Server) {
+		s.streamTimeout = timeout
+	}
+}
+
+// IdleTimeout cancel the stream rpcs if no message is sent or received in the timeout, default is no limit.
+func IdleTimeout(timeout time.Duration) ServerOption {
+	return func(s *Server) {
+		s.idleTimeout = timeout
+	}
+}
+
 // UnaryInterceptor returns a ServerOption that sets the UnaryServerInterceptor for the server.
 func UnaryInterceptor(in ...grpc.UnaryServerInterceptor) ServerOption {
 	return func(s *Server) {
@@ -51,6 +71,92 @@ func UnaryInterceptor(in ...grpc.UnaryServerInterceptor) ServerOption {
 	}
 }
 
+// StreamInterceptor returns a ServerOption that sets the StreamServerInterceptor for the server.
+func StreamInterceptor(in ...grpc.StreamServerInterceptor) ServerOption {
+	return func(s *Server) {
+		s.streamInters = in
+	}
+}
+
+// Tracing enable distributed tracing using OpenTelemetry protocol.
+func Tracing(opts ...otelgrpc.Option) ServerOption {
+	return func(s *Server) {
+		s.EnableTracing = true
+		s.TracerOptions = opts
+	}
+}
+
+// Logger with the logger of the access log, default is log.GetLogger(),
+// nothing is logged if the logger is nil.
+func Logger(l logger.Logger) ServerOption {
+	return func(s *Server) {
+		s.logger = l
+	}
+}
+
+// RequestID use the x-request-id of the client or generate a new one for every rpc,
+// see RequestIDFromContext.
+func RequestID() ServerOption {
+	return func(s *Server) {
+		s.requestID = true
+	}
+}
+
+// Ratelimit reject the rpcs with codes.ResourceExhausted by the limiter, eg: bbr.NewLimiter()
+func Ratelimit(limiter ratelimit.Limiter) ServerOption {
+	return func(s *Server) {
+		s.limiter = limiter
+	}
+}
+
+// Breaker reject the rpcs with codes.Unavailable by the circuit breaker of the method,
+// the default is a sre breaker per method if the group is nil.
+// NOTE: implements generics circuitbreaker.CircuitBreaker
+func Breaker(g *group.Group) ServerOption {
+	return func(s *Server) {
+		if g == nil {
+			g = newBreakerGroup()
+		}
+		s.breakers = g
+	}
+}
+
+// Auth reject the rpcs with codes.Unauthenticated if the json web token in the authorization metadata is invalid,
+// the secret defaults to app.Conf.JwtSecret, the skipped methods are full methods, eg: /helloworld.Greeter/SayHello.
+// see PayloadFromContext.
+func Auth(secret string, skipMethods ...string) ServerOption {
+	return func(s *Server) {
+		s.auth = &authOptions{
+			secret: secret,
+			skip:   make(map[string]struct{}, len(skipMethods)),
+		}
+		for _, m := range skipMethods {
+			s.auth.skip[m] = struct{}{}
+		}
+	}
+}
+
+// Validator reject the rpcs with codes.InvalidArgument if Validate() of the request returns an error
+func Validator() ServerOption {
+	return func(s *Server) {
+		s.validator = true
+	}
+}
+
+// TLSConfig serve with the tls config, eg: tlsconfig.NewServerConfig
+func TLSConfig(c *tls.Config) ServerOption {
+	return func(s *Server) {
+		s.tlsConf = c
+	}
+}
+
+// Closer close c after the server is stopped, eg: the tlsconfig.Reloader of TLSConfig
+func Closer(c io.Closer) ServerOption {
+	return func(s *Server) {
+		s.closers = append(s.closers, c)
+	}
+}
+
 // Options with grpc options.
 func Options(opts ...grpc.ServerOption) ServerOption {
 	return func(s *Server) {
@@ -74,7 +180,21 @@ type Server struct {
 	grpcOpts []grpc.ServerOption
 	health   *health.Server
 
-	// EnableTracer enables distributed tracing using OpenTelemetry protocol
+	streamTimeout time.Duration
+	idleTimeout   time.Duration
+	streamInters  []grpc.StreamServerInterceptor
+	logger        logger.Logger
+	tlsConf       *tls.Config
+	closers       []io.Closer
+
+	requestID bool
+	limiter   ratelimit.Limiter
+	breakers  *group.Group
+	auth      *authOptions
+	validator bool
+
+	// EnableTracer enables distributed tracing using OpenTelemetry protocol,
+	// Deprecated: use Tracing option, the field is ignored if it's set after NewServer.
 	EnableTracing bool
 	// TracerOptions are options for OpenTelemetry gRPC interceptor.
 	TracerOptions []otelgrpc.Option
@@ -94,17 +214,11 @@ func NewServer(opts ...ServerOption) *Server {
 	// Unary
 	chainUnaryInterceptors := []grpc.UnaryServerInterceptor{
 		unaryServerInterceptor(srv),
-		grpcPrometheus.UnaryServerInterceptor,
-		grpcRecovery.UnaryServerInterceptor(),
-	}
-	if len(srv.inters) > 0 {
-		chainUnaryInterceptors = append(chainUnaryInterceptors, srv.inters...)
 	}
 
 	// stream
 	chainStreamInterceptors := []grpc.StreamServerInterceptor{
-		grpcPrometheus.StreamServerInterceptor,
-		grpcRecovery.StreamServerInterceptor(),
+		streamServerInterceptor(srv),
 	}
 
 	// enable tracing
@@ -113,10 +227,54 @@ func NewServer(opts ...ServerOption) *Server {
 		chainStreamInterceptors = append(chainStreamInterceptors, otelgrpc.StreamServerInterceptor(srv.TracerOptions...))
 	}
 
+	// the request id is logged by the access log
+	if srv.requestID {
+		chainUnaryInterceptors = append(chainUnaryInterceptors, unaryRequestIDInterceptor())
+		chainStreamInterceptors = append(chainStreamInterceptors, streamRequestIDInterceptor())
+	}
+
+	chainUnaryInterceptors = append(chainUnaryInterceptors,
+		unaryAccessLogInterceptor(srv),
+		grpcPrometheus.UnaryServerInterceptor,
+		grpcRecovery.UnaryServerInterceptor(),
+	)
+	chainStreamInterceptors = append(chainStreamInterceptors,
+		streamAccessLogInterceptor(srv),
+		grpcPrometheus.StreamServerInterceptor,
+		grpcRecovery.StreamServerInterceptor(),
+	)
+
+	// builtin middlewares
+	if srv.limiter != nil {
+		chainUnaryInterceptors = append(chainUnaryInterceptors, unaryRatelimitInterceptor(srv.limiter))
+		chainStreamInterceptors = append(chainStreamInterceptors, streamRatelimitInterceptor(srv.limiter))
+	}
+	if srv.breakers != nil {
+		chainUnaryInterceptors = append(chainUnaryInterceptors, unaryBreakerInterceptor(srv.breakers))
+		chainStreamInterceptors = append(chainStreamInterceptors, streamBreakerInterceptor(srv.breakers))
+	}
+	if srv.auth != nil {
+		chainUnaryInterceptors = append(chainUnaryInterceptors, unaryAuthInterceptor(srv.auth))
+		chainStreamInterceptors = append(chainStreamInterceptors, streamAuthInterceptor(srv.auth))
+	}
+	if srv.validator {
+		chainUnaryInterceptors = append(chainUnaryInterceptors, unaryValidatorInterceptor())
+		chainStreamInterceptors = append(chainStreamInterceptors, streamValidatorInterceptor())
+	}
+	if len(srv.inters) > 0 {
+		chainUnaryInterceptors = append(chainUnaryInterceptors, srv.inters...)
+	}
+	if len(srv.streamInters) > 0 {
+		chainStreamInterceptors = append(chainStreamInterceptors, srv.streamInters...)
+	}
+
 	grpcOpts := []grpc.ServerOption{
 		grpc.ChainUnaryInterceptor(chainUnaryInterceptors...),
 		grpc.ChainStreamInterceptor(chainStreamInterceptors...),
 	}
+	if srv.tlsConf != nil {
+		grpcOpts = append(grpcOpts, grpc.Creds(credentials.NewTLS(srv.tlsConf)))
+	}
 	if len(srv.grpcOpts) > 0 {
 		grpcOpts = append(grpcOpts, srv.grpcOpts...)
 	}
@@ -149,6 +307,9 @@ func (s *Server) Endpoint() (*url.URL, error) {
 		return nil, err
 	}
 	s.endpoint = &url.URL{Scheme: "grpc", Host: addr}
+	if s.tlsConf != nil {
+		s.endpoint.RawQuery = "isSecure=true"
+	}
 	return s.endpoint, nil
 }
 
@@ -173,5 +334,11 @@ func (s *Server) Start(ctx context.Context) error {
 func (s *Server) Stop(ctx context.Context) error {
 	s.GracefulStop()
 	log.Printf("[gRPC] server is stopping")
-	return nil
+	var err error
+	for _, c := range s.closers {
+		if e := c.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
 }
@@ -1 +1,128 @@
 package grpc
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthPb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+
+	logger "github.com/go-eagle/eagle/pkg/log"
+)
+
+type testLogger struct {
+	mu     sync.Mutex
+	fields []logger.Fields
+	levels []string
+}
+
+func (l *testLogger) log(level string, fields logger.Fields) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.levels = append(l.levels, level)
+	l.fields = append(l.fields, fields)
+}
+
+func (l *testLogger) entries() ([]string, []logger.Fields) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.levels...), append([]logger.Fields(nil), l.fields...)
+}
+
+func (l *testLogger) Debug(args ...interface{})                 {}
+func (l *testLogger) Debugf(format string, args ...interface{}) {}
+func (l *testLogger) Info(args ...interface{})                  { l.log("info", nil) }
+func (l *testLogger) Infof(format string, args ...interface{})  { l.log("info", nil) }
+func (l *testLogger) Warn(args ...interface{})                  { l.log("warn", nil) }
+func (l *testLogger) Warnf(format string, args ...interface{})  { l.log("warn", nil) }
+func (l *testLogger) Error(args ...interface{})                 { l.log("error", nil) }
+func (l *testLogger) Errorf(format string, args ...interface{}) { l.log("error", nil) }
+func (l *testLogger) WithFields(fields logger.Fields) logger.Logger {
+	return &fieldsLogger{testLogger: l, fields: fields}
+}
+
+type fieldsLogger struct {
+	*testLogger
+	fields logger.Fields
+}
+
+func (l *fieldsLogger) Info(args ...interface{})  { l.log("info", l.fields) }
+func (l *fieldsLogger) Warn(args ...interface{})  { l.log("warn", l.fields) }
+func (l *fieldsLogger) Error(args ...interface{}) { l.log("error", l.fields) }
+
+func TestServer_Timeout(t *testing.T) {
+	inter := unaryServerInterceptor(&Server{timeout: time.Minute})
+	_, err := inter(context.Background(), nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		deadline, ok := ctx.Deadline()
+		assert.True(t, ok)
+		assert.WithinDuration(t, time.Now().Add(time.Minute), deadline, time.Second)
+		return nil, nil
+	})
+	assert.NoError(t, err)
+}
+
+func TestServer_StreamTimeout(t *testing.T) {
+	for name, opt := range map[string]ServerOption{
+		"deadline": StreamTimeout(100 * time.Millisecond),
+		"idle":     IdleTimeout(100 * time.Millisecond),
+	} {
+		t.Run(name, func(t *testing.T) {
+			client := dialTestServer(t, opt)
+			stream, err := client.Watch(context.Background(), &healthPb.HealthCheckRequest{})
+			require.NoError(t, err)
+			_, err = stream.Recv()
+			require.NoError(t, err)
+
+			// the watch blocks until the stream is canceled
+			start := time.Now()
+			_, err = stream.Recv()
+			assert.Error(t, err)
+			assert.NotEqual(t, codes.OK, status.Code(err))
+			assert.Less(t, int64(time.Since(start)), int64(time.Second))
+		})
+	}
+}
+
+func TestServer_StreamInterceptor(t *testing.T) {
+	var called bool
+	client := dialTestServer(t, StreamInterceptor(func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		called = true
+		return status.Error(codes.PermissionDenied, "denied")
+	}))
+
+	stream, err := client.Watch(context.Background(), &healthPb.HealthCheckRequest{})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	assert.True(t, called)
+}
+
+func TestServer_AccessLog(t *testing.T) {
+	l := &testLogger{}
+	client := dialTestServer(t, Logger(l), RequestID())
+
+	_, err := client.Check(context.Background(), &healthPb.HealthCheckRequest{})
+	assert.NoError(t, err)
+	_, err = client.Check(context.Background(), &healthPb.HealthCheckRequest{Service: "unknown"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	levels, fields := l.entries()
+	require.Len(t, fields, 2)
+	assert.Equal(t, []string{"info", "warn"}, levels)
+	assert.Equal(t, "unary", fields[0]["kind"])
+	assert.Equal(t, "/grpc.health.v1.Health/Check", fields[0]["method"])
+	assert.Equal(t, "OK", fields[0]["code"])
+	assert.NotEmpty(t, fields[0]["request_id"])
+	assert.Equal(t, "NotFound", fields[1]["code"])
+}
+
+func TestServer_Tracing(t *testing.T) {
+	srv := NewServer(Tracing())
+	assert.True(t, srv.EnableTracing)
+}
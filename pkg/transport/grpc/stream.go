@@ -0,0 +1,157 @@
+package grpc
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// errIdleTimeout the stream is canceled due to no message in the idle timeout
+var errIdleTimeout = status.Error(codes.DeadlineExceeded, "stream idle timeout")
+
+// idleStream cancel the stream if no message is sent or received in the idle timeout,
+// the pending RecvMsg returns errIdleTimeout immediately.
+type idleStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	cancel context.CancelFunc
+	timer  *time.Timer
+	idle   time.Duration
+
+	mu      sync.Mutex
+	expired bool
+
+	// the messages are received by one reader goroutine, see read
+	readOnce sync.Once
+	want     chan interface{}
+	recv     chan recvResult
+}
+
+// recvResult a message received by the reader
+type recvResult struct {
+	m   interface{}
+	err error
+}
+
+func newIdleStream(ctx context.Context, ss grpc.ServerStream, idle time.Duration, cancel context.CancelFunc) *idleStream {
+	s := &idleStream{
+		ServerStream: ss,
+		ctx:          ctx,
+		cancel:       cancel,
+		idle:         idle,
+	}
+	s.timer = time.AfterFunc(idle, s.expire)
+	return s
+}
+
+func (s *idleStream) expire() {
+	s.mu.Lock()
+	s.expired = true
+	s.mu.Unlock()
+	s.cancel()
+}
+
+func (s *idleStream) isExpired() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.expired
+}
+
+// touch reset the idle timer on activity
+func (s *idleStream) touch() {
+	s.timer.Reset(s.idle)
+}
+
+func (s *idleStream) stop() {
+	s.timer.Stop()
+}
+
+// Context returns the context canceled by the idle timer
+func (s *idleStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *idleStream) SendMsg(m interface{}) error {
+	if s.isExpired() {
+		return errIdleTimeout
+	}
+	err := s.ServerStream.SendMsg(m)
+	s.touch()
+	return err
+}
+
+func (s *idleStream) RecvMsg(m interface{}) error {
+	if err := s.err(); err != nil {
+		return err
+	}
+	rv := reflect.ValueOf(m)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return s.ServerStream.RecvMsg(m)
+	}
+
+	// NOTE: the underlying RecvMsg is not canceled by the context,
+	// so it's called by the reader with a new message, which is copied into m once it's received,
+	// m is never touched after RecvMsg returns even if the reader is still blocked.
+	s.readOnce.Do(func() {
+		s.want = make(chan interface{})
+		s.recv = make(chan recvResult, 1)
+		go s.read()
+	})
+	select {
+	case s.want <- reflect.New(rv.Type().Elem()).Interface():
+	case <-s.ctx.Done():
+		return s.err()
+	}
+	select {
+	case r := <-s.recv:
+		s.touch()
+		if r.err != nil {
+			return r.err
+		}
+		copyMessage(m, r.m)
+		return nil
+	case <-s.ctx.Done():
+		// the stream is done, so the pending result is never returned by the next RecvMsg
+		return s.err()
+	}
+}
+
+// read call the underlying RecvMsg one at a time until the stream is done,
+// it returns once the rpc is finished if it's blocked.
+func (s *idleStream) read() {
+	for {
+		select {
+		case m := <-s.want:
+			s.recv <- recvResult{m: m, err: s.ServerStream.RecvMsg(m)}
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
+
+// err the error of the expired or canceled stream
+func (s *idleStream) err() error {
+	if s.isExpired() {
+		return errIdleTimeout
+	}
+	if err := s.ctx.Err(); err != nil {
+		return status.FromContextError(err).Err()
+	}
+	return nil
+}
+
+// copyMessage copy the received message src into dst, both of them are pointers of the same type
+func copyMessage(dst, src interface{}) {
+	if d, ok := dst.(proto.Message); ok {
+		proto.Reset(d)
+		proto.Merge(d, src.(proto.Message))
+		return
+	}
+	reflect.ValueOf(dst).Elem().Set(reflect.ValueOf(src).Elem())
+}
@@ -0,0 +1,51 @@
+package grpc
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	healthPb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// fakeStream receive the messages from the channel, it writes into m like the grpc codec
+type fakeStream struct {
+	grpc.ServerStream
+	msgs    chan string
+	pending int32
+}
+
+func (s *fakeStream) RecvMsg(m interface{}) error {
+	if atomic.AddInt32(&s.pending, 1) > 1 {
+		panic("concurrent RecvMsg")
+	}
+	defer atomic.AddInt32(&s.pending, -1)
+	m.(*healthPb.HealthCheckRequest).Service = <-s.msgs
+	return nil
+}
+
+func TestIdleStream_RecvMsg(t *testing.T) {
+	ss := &fakeStream{msgs: make(chan string, 1)}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	is := newIdleStream(ctx, ss, 100*time.Millisecond, cancel)
+	defer is.stop()
+
+	ss.msgs <- "a"
+	var req healthPb.HealthCheckRequest
+	assert.NoError(t, is.RecvMsg(&req))
+	assert.Equal(t, "a", req.Service)
+
+	// the pending RecvMsg returns once the stream is idle
+	var idle healthPb.HealthCheckRequest
+	assert.Equal(t, errIdleTimeout, is.RecvMsg(&idle))
+	assert.Equal(t, errIdleTimeout, is.RecvMsg(&idle))
+
+	// the message received later is not written into the returned one
+	ss.msgs <- "b"
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, "", idle.Service)
+}
@@ -1,6 +1,8 @@
 package http
 
 import (
+	"crypto/tls"
+	"io"
 	"time"
 
 	"github.com/go-eagle/eagle/pkg/transport"
@@ -31,3 +33,17 @@ func WithWriteTimeout(timeout time.Duration) ServerOption {
 		s.writeTimeout = timeout
 	}
 }
+
+// WithTLSConfig serve https with the tls config, eg: tlsconfig.NewServerConfig
+func WithTLSConfig(c *tls.Config) ServerOption {
+	return func(s *Server) {
+		s.tlsConf = c
+	}
+}
+
+// WithCloser close c after the server is stopped, eg: the tlsconfig.Reloader of WithTLSConfig
+func WithCloser(c io.Closer) ServerOption {
+	return func(s *Server) {
+		s.closers = append(s.closers, c)
+	}
+}
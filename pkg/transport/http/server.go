@@ -2,7 +2,9 @@ package http
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
+	"io"
 	"log"
 	"net"
 	"net/http"
@@ -27,6 +29,8 @@ type Server struct {
 	readTimeout  time.Duration
 	writeTimeout time.Duration
 	endpoint     *url.URL
+	tlsConf      *tls.Config
+	closers      []io.Closer
 	log          log.Logger
 }
 
@@ -52,6 +56,7 @@ func NewServer(opts ...ServerOption) *Server {
 		ReadTimeout:  srv.readTimeout,
 		WriteTimeout: srv.writeTimeout,
 		Handler:      srv,
+		TLSConfig:    srv.tlsConf,
 	}
 	return srv
 }
@@ -63,13 +68,19 @@ func (s *Server) ServeHTTP(resp http.ResponseWriter, req *http.Request) {
 
 // Endpoint return a real address to registry endpoint.
 // examples:
-//   http://127.0.0.1:8080
+//
+//	http://127.0.0.1:8080
+//	https://127.0.0.1:8443
 func (s *Server) Endpoint() (*url.URL, error) {
 	addr, err := utils.Extract(s.address, s.lis)
 	if err != nil {
 		return nil, err
 	}
-	s.endpoint = &url.URL{Scheme: "http", Host: addr}
+	scheme := "http"
+	if s.tlsConf != nil {
+		scheme = "https"
+	}
+	s.endpoint = &url.URL{Scheme: scheme, Host: addr}
 	return s.endpoint, nil
 }
 
@@ -85,7 +96,13 @@ func (s *Server) Start(ctx context.Context) error {
 		return err
 	}
 	log.Printf("[HTTP] server is listening on: %s", lis.Addr().String())
-	if err := s.Serve(lis); !errors.Is(err, http.ErrServerClosed) {
+	if s.tlsConf != nil {
+		// the certificates are provided by the tls config
+		err = s.ServeTLS(lis, "", "")
+	} else {
+		err = s.Serve(lis)
+	}
+	if !errors.Is(err, http.ErrServerClosed) {
 		return err
 	}
 	return nil
@@ -94,5 +111,11 @@ func (s *Server) Start(ctx context.Context) error {
 // Stop stop server
 func (s *Server) Stop(ctx context.Context) error {
 	log.Printf("[HTTP] server is stopping")
-	return s.Shutdown(ctx)
+	err := s.Shutdown(ctx)
+	for _, c := range s.closers {
+		if e := c.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
 }
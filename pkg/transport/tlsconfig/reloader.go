@@ -0,0 +1,152 @@
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"log"
+	"path/filepath"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Reloader keep the certificate and the cert pool up to date with the files
+type Reloader struct {
+	certFile string
+	keyFile  string
+	caFile   string
+
+	mu   sync.RWMutex
+	cert *tls.Certificate
+	pool *x509.CertPool
+
+	watcher *fsnotify.Watcher
+	done    chan struct{}
+	once    sync.Once
+}
+
+// NewReloader load the files and watch them, the empty files are ignored
+func NewReloader(certFile, keyFile, caFile string) (*Reloader, error) {
+	r := &Reloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+		caFile:   caFile,
+		done:     make(chan struct{}),
+	}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	// watch the dirs, so that the files replaced by rename are followed, eg: kubernetes secrets
+	dirs := make(map[string]struct{})
+	for _, f := range []string{certFile, keyFile, caFile} {
+		if f != "" {
+			dirs[filepath.Dir(f)] = struct{}{}
+		}
+	}
+	for dir := range dirs {
+		if err := w.Add(dir); err != nil {
+			_ = w.Close()
+			return nil, err
+		}
+	}
+	r.watcher = w
+	go r.watch()
+	return r, nil
+}
+
+// Reload load the files, the current ones are kept if it fails
+func (r *Reloader) Reload() error {
+	var (
+		cert *tls.Certificate
+		pool *x509.CertPool
+	)
+	if r.certFile != "" || r.keyFile != "" {
+		c, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+		if err != nil {
+			return err
+		}
+		cert = &c
+	}
+	if r.caFile != "" {
+		p, err := LoadCertPool(r.caFile)
+		if err != nil {
+			return err
+		}
+		pool = p
+	}
+
+	r.mu.Lock()
+	r.cert = cert
+	r.pool = pool
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *Reloader) watch() {
+	for {
+		select {
+		case <-r.done:
+			return
+		case e, ok := <-r.watcher.Events:
+			if !ok {
+				return
+			}
+			if e.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename|fsnotify.Remove) == 0 {
+				continue
+			}
+			if err := r.Reload(); err != nil {
+				log.Printf("[tls] reload certificates err: %v", err)
+			}
+		case err, ok := <-r.watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("[tls] watch certificates err: %v", err)
+		}
+	}
+}
+
+// Certificate returns the current certificate
+func (r *Reloader) Certificate() *tls.Certificate {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert
+}
+
+// CertPool returns the current cert pool of the ca file
+func (r *Reloader) CertPool() *x509.CertPool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.pool
+}
+
+// GetCertificate can be used as tls.Config.GetCertificate
+func (r *Reloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	if cert := r.Certificate(); cert != nil {
+		return cert, nil
+	}
+	return nil, ErrNoCertificate
+}
+
+// GetClientCertificate can be used as tls.Config.GetClientCertificate
+func (r *Reloader) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	if cert := r.Certificate(); cert != nil {
+		return cert, nil
+	}
+	return nil, ErrNoCertificate
+}
+
+// Close stop watching the files
+func (r *Reloader) Close() error {
+	var err error
+	r.once.Do(func() {
+		close(r.done)
+		err = r.watcher.Close()
+	})
+	return err
+}
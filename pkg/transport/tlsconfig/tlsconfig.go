@@ -0,0 +1,81 @@
+// Package tlsconfig builds the tls configs of the servers and clients from the pem files,
+// the certificates are reloaded when the files change.
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+)
+
+// NewServerConfig create a server tls config from the cert and key files,
+// the client certificates are required and verified by the ca file if it's set (mTLS).
+// the files are reloaded when they change, the returned Reloader should be closed with the server.
+func NewServerConfig(certFile, keyFile, caFile string) (*tls.Config, *Reloader, error) {
+	r, err := NewReloader(certFile, keyFile, caFile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	base := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: r.GetCertificate,
+	}
+	if caFile != "" {
+		base.ClientAuth = tls.RequireAndVerifyClientCert
+		// the client CAs can't be replaced in place, so clone the config per handshake
+		base.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			c := base.Clone()
+			c.GetConfigForClient = nil
+			c.ClientCAs = r.CertPool()
+			return c, nil
+		}
+	}
+	return base, r, nil
+}
+
+// NewClientConfig create a client tls config,
+// the server certificate is verified by the ca file, or the system roots if it's empty,
+// the client certificate is sent if the cert and key files are set (mTLS).
+// the returned Reloader is nil if there is no client certificate.
+func NewClientConfig(caFile, certFile, keyFile, serverName string) (*tls.Config, *Reloader, error) {
+	c := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: serverName,
+	}
+	if caFile != "" {
+		pool, err := LoadCertPool(caFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		c.RootCAs = pool
+	}
+	if certFile == "" && keyFile == "" {
+		return c, nil, nil
+	}
+
+	r, err := NewReloader(certFile, keyFile, "")
+	if err != nil {
+		return nil, nil, err
+	}
+	c.GetClientCertificate = r.GetClientCertificate
+	return c, r, nil
+}
+
+// LoadCertPool load the pem encoded certificates of the ca file
+func LoadCertPool(caFile string) (*x509.CertPool, error) {
+	pem, err := ioutil.ReadFile(caFile)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("[tls] no certificate found in %s", caFile)
+	}
+	return pool, nil
+}
+
+// ErrNoCertificate the reloader has no certificate
+var ErrNoCertificate = errors.New("[tls] no certificate")
@@ -0,0 +1,167 @@
+package tlsconfig
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "eagle-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue a certificate for localhost, returns the pem encoded cert and key
+func (ca *testCA) issue(t *testing.T, serial int64, usage x509.ExtKeyUsage) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+}
+
+func writeFile(t *testing.T, dir, name string, data []byte) string {
+	path := filepath.Join(dir, name)
+	require.NoError(t, ioutil.WriteFile(path, data, 0600))
+	return path
+}
+
+// handshake dial the server with the client config, returns the error of the server side
+func handshake(t *testing.T, server, client *tls.Config) error {
+	lis, err := tls.Listen("tcp", "127.0.0.1:0", server)
+	require.NoError(t, err)
+	defer lis.Close()
+
+	errc := make(chan error, 1)
+	go func() {
+		conn, err := lis.Accept()
+		if err != nil {
+			errc <- err
+			return
+		}
+		defer conn.Close()
+		errc <- conn.(*tls.Conn).Handshake()
+	}()
+
+	conn, err := tls.Dial("tcp", lis.Addr().String(), client)
+	if err == nil {
+		// the client certificate is verified after the client handshake
+		_, _ = conn.Read(make([]byte, 1))
+		conn.Close()
+	}
+	return <-errc
+}
+
+func TestMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	caFile := writeFile(t, dir, "ca.pem", ca.pem)
+	serverCert, serverKey := ca.issue(t, 2, x509.ExtKeyUsageServerAuth)
+	clientCert, clientKey := ca.issue(t, 3, x509.ExtKeyUsageClientAuth)
+
+	serverConf, sr, err := NewServerConfig(
+		writeFile(t, dir, "server.pem", serverCert), writeFile(t, dir, "server.key", serverKey), caFile)
+	require.NoError(t, err)
+	defer sr.Close()
+
+	clientConf, cr, err := NewClientConfig(caFile,
+		writeFile(t, dir, "client.pem", clientCert), writeFile(t, dir, "client.key", clientKey), "localhost")
+	require.NoError(t, err)
+	defer cr.Close()
+	assert.NoError(t, handshake(t, serverConf, clientConf))
+
+	// the client certificate is required
+	noCert, r, err := NewClientConfig(caFile, "", "", "localhost")
+	require.NoError(t, err)
+	assert.Nil(t, r)
+	assert.Error(t, handshake(t, serverConf, noCert))
+}
+
+func TestReloader(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	cert, key := ca.issue(t, 2, x509.ExtKeyUsageServerAuth)
+	certFile, keyFile := writeFile(t, dir, "server.pem", cert), writeFile(t, dir, "server.key", key)
+
+	r, err := NewReloader(certFile, keyFile, "")
+	require.NoError(t, err)
+	defer r.Close()
+	assert.Nil(t, r.CertPool())
+
+	serial := func() int64 {
+		leaf, err := x509.ParseCertificate(r.Certificate().Certificate[0])
+		require.NoError(t, err)
+		return leaf.SerialNumber.Int64()
+	}
+	assert.Equal(t, int64(2), serial())
+
+	// replace the files
+	cert, key = ca.issue(t, 4, x509.ExtKeyUsageServerAuth)
+	writeFile(t, dir, "server.key", key)
+	writeFile(t, dir, "server.pem", cert)
+	assert.Eventually(t, func() bool {
+		return serial() == 4
+	}, 3*time.Second, 20*time.Millisecond)
+
+	// the current certificate is kept if the files are invalid
+	writeFile(t, dir, "server.pem", []byte("invalid"))
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, int64(4), serial())
+
+	assert.NoError(t, r.Close())
+	assert.NoError(t, r.Close())
+}
+
+func TestLoadCertPool(t *testing.T) {
+	dir := t.TempDir()
+	_, err := LoadCertPool(writeFile(t, dir, "ca.pem", []byte("invalid")))
+	assert.Error(t, err)
+	_, err = LoadCertPool(filepath.Join(dir, "none.pem"))
+	assert.Error(t, err)
+}
@@ -15,3 +15,8 @@ type Server interface {
 type Endpoint interface {
 	Endpoint() (*url.URL, error)
 }
+
+// Metadata is registry metadata of a server.
+type Metadata interface {
+	Metadata() map[string]string
+}
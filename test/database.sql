@@ -11,6 +11,33 @@ USE `eagle`;
 /*!40111 SET @OLD_SQL_NOTES=@@SQL_NOTES, SQL_NOTES=0 */;
 
 
+# Dump of table outbox
+# ------------------------------------------------------------
+
+DROP TABLE IF EXISTS `outbox`;
+
+CREATE TABLE `outbox` (
+                          `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+                          `message_id` varchar(64) NOT NULL DEFAULT '' COMMENT '消息id',
+                          `topic` varchar(128) NOT NULL DEFAULT '' COMMENT '消息主题',
+                          `msg_key` varchar(128) NOT NULL DEFAULT '' COMMENT '消息key',
+                          `headers` text COMMENT '消息头, json格式',
+                          `payload` blob COMMENT '消息体',
+                          `status` tinyint(1) unsigned NOT NULL DEFAULT '0' COMMENT '状态 0:待发送 1:已发送 2:发送失败次数过多',
+                          `attempts` int(10) unsigned NOT NULL DEFAULT '0' COMMENT '发送失败次数',
+                          `last_error` varchar(512) NOT NULL DEFAULT '' COMMENT '最后一次发送失败原因',
+                          `locked_by` varchar(64) NOT NULL DEFAULT '' COMMENT '锁定者',
+                          `locked_until` datetime DEFAULT NULL COMMENT '锁定到期时间',
+                          `sent_at` datetime DEFAULT NULL,
+                          `created_at` datetime DEFAULT NULL,
+                          `updated_at` datetime DEFAULT NULL,
+                          PRIMARY KEY (`id`),
+                          KEY `idx_status_locked_until` (`status`,`locked_until`),
+                          KEY `idx_locked_by` (`locked_by`),
+                          KEY `idx_status_sent_at` (`status`,`sent_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='事务消息发件箱';
+
+
 # Dump of table user_fans
 # ------------------------------------------------------------
 